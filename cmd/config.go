@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = "usage: lobicornis config check [owner/name...]"
+
+// effectiveConfig the configuration of the repositories, after the application of the default configuration and of the profiles.
+type effectiveConfig struct {
+	Default      conf.RepoConfig             `yaml:"default"`
+	Profiles     map[string]*conf.RepoConfig `yaml:"profiles,omitempty"`
+	Repositories map[string]*conf.RepoConfig `yaml:"repositories,omitempty"`
+	Resolved     map[string]resolvedConfig   `yaml:"resolved,omitempty"`
+}
+
+// resolvedConfig the configuration applied to a repository.
+type resolvedConfig struct {
+	// Key the key of the repositories (exact key or pattern) applied to the repository, or `default`.
+	Key string `yaml:"key"`
+
+	conf.RepoConfig `yaml:",inline"`
+}
+
+// configCheck validates the configuration file (strict decoding), and prints the effective configuration of the repositories.
+// The configuration applied to each repository given as argument is resolved (exact keys, patterns, and profiles).
+func configCheck(filename string, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New(configUsage)
+	}
+
+	cfg, err := conf.Check(filename)
+	if err != nil {
+		return fmt.Errorf("invalid configuration %s: %w", filename, err)
+	}
+
+	repositories := make(map[string]*conf.RepoConfig)
+	for name, config := range cfg.Repositories {
+		if config == nil {
+			config = &cfg.Default
+		}
+
+		repositories[name] = config
+	}
+
+	resolved := make(map[string]resolvedConfig)
+	for _, fullName := range args[1:] {
+		owner, name, ok := strings.Cut(fullName, "/")
+		if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid repository name %q: %s", fullName, configUsage)
+		}
+
+		key := cfg.GetRepoKey(fullName)
+		if key == "" {
+			key = "default"
+		}
+
+		resolved[fullName] = resolvedConfig{Key: key, RepoConfig: cfg.GetRepoConfig(fullName)}
+	}
+
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+
+	err = encoder.Encode(effectiveConfig{
+		Default:      cfg.Default,
+		Profiles:     cfg.Profiles,
+		Repositories: repositories,
+		Resolved:     resolved,
+	})
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
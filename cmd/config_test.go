@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_configCheck(t *testing.T) {
+	content := `
+github:
+  user: foo
+git:
+  email: bot@example.com
+  userName: botname
+default:
+  minReview: 2
+repositories:
+  foo/bar:
+    mergeMethod: merge
+`
+
+	filename := filepath.Join(t.TempDir(), "lobicornis.yml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	var b strings.Builder
+	require.NoError(t, configCheck(filename, []string{"check"}, &b))
+
+	expected := `default:
+  mergeMethod: squash
+  minLightReview: 0
+  minReview: 2
+  needMilestone: true
+  checkNeedUpToDate: false
+  forceNeedUpToDate: true
+  addErrorInComment: false
+  commitMessage: empty
+  useProtectionChecks: false
+  mergeTrainSize: 0
+repositories:
+  foo/bar:
+    mergeMethod: merge
+    minLightReview: 0
+    minReview: 2
+    needMilestone: true
+    checkNeedUpToDate: false
+    forceNeedUpToDate: true
+    addErrorInComment: false
+    commitMessage: empty
+    useProtectionChecks: false
+    mergeTrainSize: 0
+`
+
+	assert.Equal(t, expected, b.String())
+
+	err := configCheck(filename, []string{"foo"}, &b)
+	require.EqualError(t, err, configUsage)
+}
+
+func Test_configCheck_resolved(t *testing.T) {
+	content := `
+github:
+  user: foo
+git:
+  email: bot@example.com
+  userName: botname
+profiles:
+  plugins:
+    minReview: 3
+repositories:
+  foo/bar:
+    mergeMethod: merge
+  foo/plugin-*:
+    profile: plugins
+`
+
+	filename := filepath.Join(t.TempDir(), "lobicornis.yml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	var b strings.Builder
+	require.NoError(t, configCheck(filename, []string{"check", "Foo/Bar", "foo/plugin-log", "foo/other"}, &b))
+
+	expected := `resolved:
+  Foo/Bar:
+    key: foo/bar
+    mergeMethod: merge
+    minLightReview: 0
+    minReview: 1
+    needMilestone: true
+    checkNeedUpToDate: false
+    forceNeedUpToDate: true
+    addErrorInComment: false
+    commitMessage: empty
+    useProtectionChecks: false
+    mergeTrainSize: 0
+  foo/other:
+    key: default
+    mergeMethod: squash
+    minLightReview: 0
+    minReview: 1
+    needMilestone: true
+    checkNeedUpToDate: false
+    forceNeedUpToDate: true
+    addErrorInComment: false
+    commitMessage: empty
+    useProtectionChecks: false
+    mergeTrainSize: 0
+  foo/plugin-log:
+    key: foo/plugin-*
+    mergeMethod: squash
+    minLightReview: 0
+    minReview: 3
+    needMilestone: true
+    checkNeedUpToDate: false
+    forceNeedUpToDate: true
+    addErrorInComment: false
+    commitMessage: empty
+    useProtectionChecks: false
+    mergeTrainSize: 0
+    profile: plugins
+`
+
+	assert.Contains(t, b.String(), "profiles:\n  plugins:\n    minReview: 3\n")
+	assert.Contains(t, b.String(), expected)
+
+	err := configCheck(filename, []string{"check", "foo"}, &b)
+	require.EqualError(t, err, `invalid repository name "foo": `+configUsage)
+}
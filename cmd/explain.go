@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+const explainUsage = "usage: lobicornis explain [-format text|json] owner/repo#number"
+
+// explain prints the evaluation of the gates of a pull request, without mutating anything.
+func explain(ctx context.Context, cfg conf.Configuration, args []string, out io.Writer) error {
+	if cfg.Provider != conf.ProviderGitHub {
+		return fmt.Errorf("the %s provider is not supported by the explain command", cfg.Provider)
+	}
+
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	format := flags.String("format", "text", "Output format. (text|json)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New(explainUsage)
+	}
+
+	fullName, number, err := parsePullRequestRef(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("invalid format: %q", *format)
+	}
+
+	proc, err := newProcessor(cfg, newRepoLocks(), nil)
+	if err != nil {
+		return err
+	}
+
+	owner, _, _ := strings.Cut(fullName, "/")
+
+	client := ghapi.New(newGitHubClient(ctx, proc.tokenSource(owner), owner, cfg.Github.URL, cfg.Timeouts.API, nil))
+
+	repo := repository.New(client, fullName, "", cfg.Markers, cfg.Retry, cfg.Timeouts, cfg.Git, cfg.GetRepoConfig(fullName), cfg.Extra, nil, nil, proc.statuses)
+
+	report, err := repo.Explain(ctx, number)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(report)
+	}
+
+	return writeReport(out, report)
+}
+
+// parsePullRequestRef parses a pull request reference (owner/repo#number).
+func parsePullRequestRef(ref string) (string, int, error) {
+	fullName, rawNumber, ok := strings.Cut(ref, "#")
+	if !ok || strings.Count(fullName, "/") != 1 || strings.HasPrefix(fullName, "/") || strings.HasSuffix(fullName, "/") {
+		return "", 0, fmt.Errorf("invalid pull request: %q (%s)", ref, explainUsage)
+	}
+
+	number, err := strconv.Atoi(rawNumber)
+	if err != nil || number <= 0 {
+		return "", 0, fmt.Errorf("invalid pull request number: %q", rawNumber)
+	}
+
+	return fullName, number, nil
+}
+
+// writeReport writes the text version of a report.
+func writeReport(out io.Writer, report *repository.Report) error {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "%s#%d: %s\n\n", report.Repository, report.Number, report.Title)
+
+	if report.Note != "" {
+		_, _ = fmt.Fprintf(&b, "Note: %s\n\n", report.Note)
+	}
+
+	for _, gate := range report.Gates {
+		result := "PASS"
+		if !gate.Passed {
+			result = "FAIL"
+		}
+
+		_, _ = fmt.Fprintf(&b, "[%s] %s\n", result, gate.Name)
+
+		if gate.Message != "" {
+			for line := range strings.SplitSeq(gate.Message, "\n") {
+				_, _ = fmt.Fprintf(&b, "       %s\n", line)
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(gate.Data)) {
+			_, _ = fmt.Fprintf(&b, "       %s: %v\n", key, gate.Data[key])
+		}
+	}
+
+	_, _ = fmt.Fprintf(&b, "\nNext action: %s\n", report.Action)
+
+	_, err := io.WriteString(out, b.String())
+
+	return err
+}
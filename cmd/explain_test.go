@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+func Test_parsePullRequestRef(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		ref              string
+		expectedFullName string
+		expectedNumber   int
+		errorMsg         string
+	}{
+		{
+			desc:             "valid",
+			ref:              "traefik/traefik#123",
+			expectedFullName: "traefik/traefik",
+			expectedNumber:   123,
+		},
+		{
+			desc:     "missing number",
+			ref:      "traefik/traefik",
+			errorMsg: "invalid pull request",
+		},
+		{
+			desc:     "missing owner",
+			ref:      "traefik#123",
+			errorMsg: "invalid pull request",
+		},
+		{
+			desc:     "invalid number",
+			ref:      "traefik/traefik#abc",
+			errorMsg: `invalid pull request number: "abc"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fullName, number, err := parsePullRequestRef(test.ref)
+
+			if test.errorMsg != "" {
+				require.ErrorContains(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedFullName, fullName)
+			assert.Equal(t, test.expectedNumber, number)
+		})
+	}
+}
+
+func Test_writeReport(t *testing.T) {
+	report := &repository.Report{
+		Repository: "traefik/traefik",
+		Number:     123,
+		Title:      "Fix the bug",
+		Note:       "the merge train is not covered: the pull requests are merged by trains of 3",
+		Gates: []repository.Gate{
+			{Name: repository.GateMilestone, Passed: true, Data: map[string]any{"required": true, "present": true}},
+			{Name: repository.GateReviews, Message: "need more review [0/1]", Data: map[string]any{"required": 1}},
+		},
+		Action: repository.NextCallHuman,
+	}
+
+	var b strings.Builder
+	require.NoError(t, writeReport(&b, report))
+
+	expected := `traefik/traefik#123: Fix the bug
+
+Note: the merge train is not covered: the pull requests are merged by trains of 3
+
+[PASS] milestone
+       present: true
+       required: true
+[FAIL] reviews
+       need more review [0/1]
+       required: 1
+
+Next action: call a human
+`
+
+	assert.Equal(t, expected, b.String())
+}
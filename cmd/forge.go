@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/search"
+)
+
+// forgeQueues the queues of the pull requests of a forge (GitLab, Gitea).
+// I is an item of a queue: a merge request, or the issue of a pull request.
+type forgeQueues[I search.Pull] interface {
+	// owners gets the owners of the repositories (the groups for GitLab).
+	owners() []string
+	// search gets the queue of each repository of an owner: the open pull requests with the labels, and without the excluded labels.
+	search(ctx context.Context, owner string, labels, excludedLabels []string) (map[string][]I, error)
+	// number gets the number of the pull request of an item.
+	number(item I) int
+	// ref gets the reference of a pull request: "#1", or "!1" for GitLab.
+	ref(number int) string
+	// newRepository creates the manager of a repository of an owner.
+	newRepository(owner, fullName string) forgeRepository
+}
+
+// forgeRepository the manager of a repository of a forge.
+type forgeRepository interface {
+	Process(ctx context.Context, number int) error
+}
+
+// runForge processes the current pull request of all the repositories of all the owners of a forge.
+func runForge[I search.Pull](ctx context.Context, p *processor, queues forgeQueues[I]) error {
+	// a full sweep: the repositories without queue are removed from the metrics.
+	p.metrics.ResetQueueSizes()
+
+	var errs []error
+	for _, owner := range queues.owners() {
+		err := processForgeOwner(ctx, p, queues, owner)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", owner, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// processForgeOwner processes the current pull request of each repository of an owner.
+// The repositories not managed by the bot (include/exclude lists) are ignored.
+func processForgeOwner[I search.Pull](ctx context.Context, p *processor, queues forgeQueues[I], owner string) error {
+	markers := p.cfg.Markers
+
+	// search PRs with the FF merge method.
+	ffResults, err := queues.search(ctx, owner, []string{markers.MergeMethodPrefix + conf.MergeMethodFastForward}, []string{markers.NoMerge, markers.NeedMerge})
+	if err != nil {
+		return err
+	}
+
+	// search NeedMerge
+	results, err := queues.search(ctx, owner, []string{markers.NeedMerge}, []string{markers.NeedHumanMerge, markers.NoMerge})
+	if err != nil {
+		return err
+	}
+
+	deleteUnmanaged(ctx, p.cfg, results)
+
+	for fullName, items := range results {
+		p.metrics.QueueSize(fullName, len(items))
+	}
+
+	p.dispatch(ctx, slices.Collect(maps.Keys(results)), func(fullName string) {
+		logger := log.With().Str("repo", fullName).Logger()
+
+		if _, ok := ffResults[fullName]; ok {
+			logger.Info().Msgf("Waiting for the merge of pull request with the label: %s", markers.MergeMethodPrefix+conf.MergeMethodFastForward)
+			return
+		}
+
+		processForgeRepository(logger.WithContext(ctx), p, queues, owner, fullName, results[fullName])
+	})
+
+	return nil
+}
+
+// processForgeRepository processes the current pull request of a repository.
+func processForgeRepository[I search.Pull](ctx context.Context, p *processor, queues forgeQueues[I], owner, fullName string, items []I) {
+	unlock := p.locks.Lock(fullName)
+	defer unlock()
+
+	var queue []int
+	for _, item := range items {
+		queue = append(queue, queues.number(item))
+	}
+
+	p.status.start(fullName, queue)
+
+	err := processForgeQueue(ctx, p, queues, owner, fullName, items)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to process")
+	}
+
+	p.status.done(fullName, err)
+}
+
+// processForgeQueue processes the current pull request of the queue of a repository.
+func processForgeQueue[I search.Pull](ctx context.Context, p *processor, queues forgeQueues[I], owner, fullName string, items []I) error {
+	index, err := search.GetCurrent(ctx, p.cfg.Markers, p.cfg.Retry, items)
+	if err != nil {
+		return fmt.Errorf("unable to get the current pull request: %w", err)
+	}
+
+	if index < 0 {
+		log.Ctx(ctx).Debug().Msg("Nothing to merge.")
+		return nil
+	}
+
+	number := queues.number(items[index])
+
+	p.status.current(fullName, number)
+
+	loggerPR := log.Ctx(ctx).With().Int("pr", number).
+		Int("position", index+1).Int("queue", len(items)).Int("priority", p.cfg.Markers.GetPriority(items[index].LabelNames()...)).
+		Logger()
+
+	err = queues.newRepository(owner, fullName).Process(loggerPR.WithContext(ctx), number)
+	if err != nil {
+		return fmt.Errorf("failed to process %s: %w", queues.ref(number), err)
+	}
+
+	return nil
+}
+
+// deleteUnmanaged removes the repositories not managed by the bot from the search results.
+func deleteUnmanaged[I any](ctx context.Context, cfg conf.Configuration, results map[string][]I) {
+	maps.DeleteFunc(results, func(fullName string, _ []I) bool {
+		if cfg.IsManaged(fullName) {
+			return false
+		}
+
+		log.Ctx(ctx).Debug().Str("repo", fullName).Msg("Repository ignored.")
+
+		return true
+	})
+}
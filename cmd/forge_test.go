@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// fakeItem a pull request of the fake forge.
+type fakeItem struct {
+	number int
+	labels []string
+}
+
+func (i *fakeItem) LabelNames() []string {
+	return i.labels
+}
+
+func (i *fakeItem) LastUpdate() time.Time {
+	return time.Time{}
+}
+
+// fakeQueues an in-memory forge: the pull requests by repository, and the processed pull requests.
+type fakeQueues struct {
+	items map[string][]*fakeItem
+
+	mu        sync.Mutex
+	processed map[string][]int
+}
+
+func (f *fakeQueues) owners() []string {
+	return []string{"traefik"}
+}
+
+func (f *fakeQueues) search(_ context.Context, _ string, labels, excludedLabels []string) (map[string][]*fakeItem, error) {
+	results := make(map[string][]*fakeItem)
+
+	for fullName, items := range f.items {
+		for _, item := range items {
+			if slices.ContainsFunc(labels, func(label string) bool { return !slices.Contains(item.labels, label) }) ||
+				slices.ContainsFunc(excludedLabels, func(label string) bool { return slices.Contains(item.labels, label) }) {
+				continue
+			}
+
+			results[fullName] = append(results[fullName], item)
+		}
+	}
+
+	return results, nil
+}
+
+func (f *fakeQueues) number(item *fakeItem) int {
+	return item.number
+}
+
+func (f *fakeQueues) ref(number int) string {
+	return "#" + strconv.Itoa(number)
+}
+
+func (f *fakeQueues) newRepository(_, fullName string) forgeRepository {
+	return fakeForgeRepository{queues: f, fullName: fullName}
+}
+
+type fakeForgeRepository struct {
+	queues   *fakeQueues
+	fullName string
+}
+
+func (r fakeForgeRepository) Process(_ context.Context, number int) error {
+	r.queues.mu.Lock()
+	defer r.queues.mu.Unlock()
+
+	r.queues.processed[r.fullName] = append(r.queues.processed[r.fullName], number)
+
+	return nil
+}
+
+func Test_processForgeOwner(t *testing.T) {
+	markers := conf.Markers{
+		NeedMerge:         "status/3-needs-merge",
+		MergeInProgress:   "status/4-merge-in-progress",
+		MergeMethodPrefix: "bot/merge-method-",
+		NeedHumanMerge:    "bot/need-human-merge",
+		NoMerge:           "bot/no-merge",
+	}
+
+	cfg := conf.Configuration{
+		Provider: conf.ProviderGitea,
+		Gitea:    conf.Gitea{URL: "https://gitea.example.com", Owners: []string{"traefik"}, Exclude: []string{"traefik/archived"}},
+		Markers:  markers,
+	}
+
+	proc, err := newProcessor(cfg, newRepoLocks(), nil)
+	require.NoError(t, err)
+
+	queues := &fakeQueues{
+		items: map[string][]*fakeItem{
+			"traefik/traefik": {
+				{number: 1, labels: []string{markers.NeedMerge}},
+				{number: 2, labels: []string{markers.NeedMerge, markers.MergeInProgress}},
+			},
+			// a pull request with the ff label, without the need-merge label, blocks the repository.
+			"traefik/yaegi": {
+				{number: 3, labels: []string{markers.NeedMerge}},
+				{number: 4, labels: []string{markers.MergeMethodPrefix + conf.MergeMethodFastForward}},
+			},
+			"traefik/archived": {
+				{number: 5, labels: []string{markers.NeedMerge}},
+			},
+			"traefik/paerser": {
+				{number: 6, labels: []string{markers.NeedMerge, markers.NeedHumanMerge}},
+			},
+		},
+		processed: make(map[string][]int),
+	}
+
+	err = processForgeOwner(t.Context(), proc, queues, "traefik")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]int{"traefik/traefik": {2}}, queues.processed)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/gitea"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// giteaForge drives the pull requests of the Gitea (or Forgejo) owners with the pipeline of the pull requests.
+// The merge train, the webhook, the commit status, and the configuration file of the repository are not supported.
+type giteaForge struct {
+	proc *processor
+
+	// clients the client of each owner: the API requests are counted by owner.
+	clients map[string]*gitea.Client
+}
+
+func newGiteaForge(proc *processor) *giteaForge {
+	clients := make(map[string]*gitea.Client)
+
+	for _, owner := range proc.cfg.Gitea.Owners {
+		httpClient := &http.Client{
+			Transport: proc.metrics.ForgeTransport(nil, conf.ProviderGitea, owner),
+			Timeout:   proc.cfg.Timeouts.API,
+		}
+
+		clients[owner] = gitea.NewClient(httpClient, proc.cfg.Gitea.URL, proc.cfg.Gitea.Token)
+	}
+
+	return &giteaForge{proc: proc, clients: clients}
+}
+
+// Run processes the current pull request of all the repositories of all the owners.
+func (f *giteaForge) Run(ctx context.Context) error {
+	return runForge(ctx, f.proc, f)
+}
+
+// Ping checks that Gitea can be reached with the credentials.
+func (f *giteaForge) Ping(ctx context.Context) error {
+	_, err := f.clients[f.owners()[0]].CurrentUser(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reach Gitea: %w", err)
+	}
+
+	return nil
+}
+
+func (f *giteaForge) finder(owner string) gitea.Finder {
+	return gitea.NewFinder(f.clients[owner], f.proc.cfg.Markers)
+}
+
+func (f *giteaForge) owners() []string {
+	return f.proc.cfg.Gitea.Owners
+}
+
+func (f *giteaForge) search(ctx context.Context, owner string, labels, excludedLabels []string) (map[string][]*gitea.Issue, error) {
+	return f.finder(owner).Search(ctx, owner, labels, excludedLabels)
+}
+
+func (f *giteaForge) number(issue *gitea.Issue) int {
+	return issue.Number
+}
+
+func (f *giteaForge) ref(number int) string {
+	return "#" + strconv.Itoa(number)
+}
+
+func (f *giteaForge) newRepository(owner, fullName string) forgeRepository {
+	p := f.proc
+
+	config := p.cfg.GetRepoConfig(fullName)
+
+	repo := gitea.NewRepository(f.clients[owner], fullName, config, p.metrics)
+
+	return repository.NewForgeRepository(repo, fullName, p.cfg.Gitea.Token, p.cfg.Markers, p.cfg.Retry, p.cfg.Timeouts, config, p.cfg.Extra, p.metrics)
+}
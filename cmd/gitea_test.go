@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+func TestGiteaForge_Ping(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		status int
+		assert assert.ErrorAssertionFunc
+	}{
+		{
+			desc:   "reachable",
+			status: http.StatusOK,
+			assert: assert.NoError,
+		},
+		{
+			desc:   "unauthorized",
+			status: http.StatusUnauthorized,
+			assert: assert.Error,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/api/v1/user" || req.Header.Get("Authorization") != "token secret" {
+					http.NotFound(rw, req)
+					return
+				}
+
+				rw.WriteHeader(test.status)
+				_, _ = rw.Write([]byte(`{"login":"lobicornis"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			cfg := conf.Configuration{
+				Provider: conf.ProviderGitea,
+				Gitea:    conf.Gitea{URL: server.URL, Token: "secret", Owners: []string{"traefik"}},
+			}
+
+			proc, err := newProcessor(cfg, newRepoLocks(), nil)
+			require.NoError(t, err)
+
+			test.assert(t, proc.ping(t.Context()))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/gitlab"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// gitLabForge drives the merge requests of the GitLab groups with the pipeline of the pull requests.
+// The merge train, the webhook, the commit status, and the configuration file of the repository are not supported.
+type gitLabForge struct {
+	proc *processor
+
+	// clients the client of each group: the API requests are counted by group.
+	clients map[string]*gitlab.Client
+}
+
+func newGitLabForge(proc *processor) *gitLabForge {
+	clients := make(map[string]*gitlab.Client)
+
+	for _, group := range proc.cfg.GitLab.Groups {
+		httpClient := &http.Client{
+			Transport: proc.metrics.ForgeTransport(nil, conf.ProviderGitLab, group),
+			Timeout:   proc.cfg.Timeouts.API,
+		}
+
+		clients[group] = gitlab.NewClient(httpClient, proc.cfg.GitLab.URL, proc.cfg.GitLab.Token)
+	}
+
+	return &gitLabForge{proc: proc, clients: clients}
+}
+
+// Run processes the current merge request of all the projects of all the groups.
+func (f *gitLabForge) Run(ctx context.Context) error {
+	return runForge(ctx, f.proc, f)
+}
+
+// Ping checks that GitLab can be reached with the credentials.
+func (f *gitLabForge) Ping(ctx context.Context) error {
+	_, err := f.clients[f.owners()[0]].CurrentUser(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reach GitLab: %w", err)
+	}
+
+	return nil
+}
+
+func (f *gitLabForge) finder(group string) gitlab.Finder {
+	return gitlab.NewFinder(f.clients[group], f.proc.cfg.Markers)
+}
+
+func (f *gitLabForge) owners() []string {
+	return f.proc.cfg.GitLab.Groups
+}
+
+func (f *gitLabForge) search(ctx context.Context, group string, labels, excludedLabels []string) (map[string][]*gitlab.MergeRequest, error) {
+	return f.finder(group).Search(ctx, group, labels, excludedLabels)
+}
+
+func (f *gitLabForge) number(mr *gitlab.MergeRequest) int {
+	return mr.IID
+}
+
+func (f *gitLabForge) ref(iid int) string {
+	return "!" + strconv.Itoa(iid)
+}
+
+func (f *gitLabForge) newRepository(group, path string) forgeRepository {
+	p := f.proc
+
+	config := p.cfg.GetRepoConfig(path)
+
+	project := gitlab.NewRepository(f.clients[group], path, p.cfg.GitLab.Token, p.cfg.Timeouts, p.cfg.Git, config, p.cfg.GitLab.CloneRebase, p.metrics)
+
+	return repository.NewForgeRepository(project, path, p.cfg.GitLab.Token, p.cfg.Markers, p.cfg.Retry, p.cfg.Timeouts, config, p.cfg.Extra, p.metrics)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+)
+
+func TestGitLabForge_Ping(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		status int
+		assert assert.ErrorAssertionFunc
+	}{
+		{
+			desc:   "reachable",
+			status: http.StatusOK,
+			assert: assert.NoError,
+		},
+		{
+			desc:   "unauthorized",
+			status: http.StatusUnauthorized,
+			assert: assert.Error,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/api/v4/user" || req.Header.Get("Private-Token") != "secret" {
+					http.NotFound(rw, req)
+					return
+				}
+
+				rw.WriteHeader(test.status)
+				_, _ = rw.Write([]byte(`{"username":"lobicornis"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			cfg := conf.Configuration{
+				Provider: conf.ProviderGitLab,
+				GitLab:   conf.GitLab{URL: server.URL, Token: "secret", Groups: []string{"traefik"}},
+			}
+
+			proc, err := newProcessor(cfg, newRepoLocks(), nil)
+			require.NoError(t, err)
+
+			test.assert(t, proc.ping(t.Context()))
+		})
+	}
+}
+
+func TestGitLabForge_metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("RateLimit-Remaining", "1999")
+		_, _ = rw.Write([]byte(`{"username":"lobicornis"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := conf.Configuration{
+		Provider: conf.ProviderGitLab,
+		GitLab:   conf.GitLab{URL: server.URL, Token: "secret", Groups: []string{"traefik"}},
+	}
+
+	recorder := metrics.New()
+
+	proc, err := newProcessor(cfg, newRepoLocks(), recorder)
+	require.NoError(t, err)
+
+	require.NoError(t, proc.ping(t.Context()))
+
+	rw := httptest.NewRecorder()
+	recorder.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.Contains(t, rw.Body.String(), `lobicornis_forge_requests_total{code="200",owner="traefik",provider="gitlab"} 1`)
+	assert.Contains(t, rw.Body.String(), `lobicornis_forge_rate_limit_remaining{owner="traefik",provider="gitlab"} 1999`)
+	assert.NotContains(t, rw.Body.String(), "lobicornis_github_requests_total")
+}
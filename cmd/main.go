@@ -7,15 +7,17 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/lobicornis/v3/pkg/conf"
-	"github.com/traefik/lobicornis/v3/pkg/repository"
-	"github.com/traefik/lobicornis/v3/pkg/search"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
 	"golang.org/x/oauth2"
 )
 
@@ -32,8 +34,8 @@ func main() {
 		return
 	}
 
-	nArgs := flag.NArg()
-	if nArgs > 0 {
+	command := flag.Arg(0)
+	if command != "" && command != "explain" && command != "config" {
 		usage()
 		return
 	}
@@ -48,6 +50,15 @@ func main() {
 		return
 	}
 
+	if command == "config" {
+		err := configCheck(*filename, flag.Args()[1:], os.Stdout)
+		if err != nil {
+			log.Fatal().Err(err).Msg("unable to check the configuration")
+		}
+
+		return
+	}
+
 	cfg, err := conf.Load(*filename)
 	if err != nil {
 		log.Fatal().Err(err).Msg("unable to load config")
@@ -55,12 +66,21 @@ func main() {
 
 	setupLogger(cfg.Extra.DryRun, cfg.Extra.LogLevel)
 
-	if *serverMode {
-		err = launch(cfg)
+	switch {
+	case command == "explain":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err = explain(ctx, cfg, flag.Args()[1:], os.Stdout)
+		stop()
+
+		if err != nil {
+			log.Fatal().Err(err).Msg("unable to explain the pull request")
+		}
+	case *serverMode:
+		err = launch(cfg, *filename)
 		if err != nil {
 			log.Fatal().Err(err).Msg("unable to launch the server")
 		}
-	} else {
+	default:
 		err = run(cfg)
 		if err != nil {
 			log.Fatal().Err(err).Msg("unable to run the command")
@@ -68,22 +88,45 @@ func main() {
 	}
 }
 
-func launch(cfg conf.Configuration) error {
-	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+func launch(cfg conf.Configuration, filename string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the scheduler and the webhook never process the same repository at the same time.
+	recorder := metrics.New()
+
+	proc, err := newProcessor(cfg, newRepoLocks(), recorder)
+	if err != nil {
+		return err
+	}
+
+	// a run in progress keeps the processor (and the configuration) of its start.
+	rl := newReloader(filename, proc)
+
+	// the processing in progress is canceled after a grace period on shutdown.
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	defer cancelRuns()
+
+	sched := newScheduler(cfg.Server, func() error { return rl.Processor().Run(runCtx) })
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz(rl.Processor))
+	mux.Handle("/status", proc.status)
+
+	mux.HandleFunc("/{$}", func(rw http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodGet {
 			log.Error().Str("method", req.Method).Msg("Invalid http method")
 			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 			return
 		}
 
-		err := run(cfg)
-		if err != nil {
-			log.Error().Err(err).Msg("Report error")
-			http.Error(rw, "Report error.", http.StatusInternalServerError)
-			return
+		if !sched.Trigger() {
+			log.Debug().Msg("A run is already scheduled.")
 		}
 
-		_, err = fmt.Fprint(rw, "Myrmica Lobicornis: Scheduled.\n")
+		_, err := fmt.Fprint(rw, "Myrmica Lobicornis: Scheduled.\n")
 		if err != nil {
 			log.Error().Err(err).Msg("Report error")
 			http.Error(rw, err.Error(), http.StatusInternalServerError)
@@ -91,75 +134,85 @@ func launch(cfg conf.Configuration) error {
 		}
 	})
 
-	return http.ListenAndServe(":"+strconv.Itoa(cfg.Server.Port), handler)
-}
+	mux.Handle("/metrics", recorder)
 
-func run(cfg conf.Configuration) error {
-	ctx := context.Background()
+	// the webhook is enabled by the webhook secret of the current configuration.
+	wh := newWebhook(runCtx, rl.Processor)
+	mux.Handle("/webhook", wh)
 
-	client := newGitHubClient(ctx, cfg.Github.Token, cfg.Github.URL)
-
-	finder := search.New(client, cfg.Markers, cfg.Retry)
-
-	// search PRs with the FF merge method.
-	ffResults, err := finder.Search(ctx, cfg.Github.User,
-		search.WithLabels(cfg.Markers.MergeMethodPrefix+conf.MergeMethodFastForward),
-		search.WithExcludedLabels(cfg.Markers.NoMerge, cfg.Markers.NeedMerge))
-	if err != nil {
-		return err
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	// search NeedMerge
-	results, err := finder.Search(ctx, cfg.Github.User,
-		search.WithLabels(cfg.Markers.NeedMerge),
-		search.WithExcludedLabels(cfg.Markers.NeedHumanMerge, cfg.Markers.NoMerge))
-	if err != nil {
-		return err
-	}
+	go rl.Start(ctx)
 
-	for fullName, issues := range results {
-		logger := log.With().Str("repo", fullName).Logger()
+	schedDone := make(chan struct{})
+	go func() {
+		defer close(schedDone)
 
-		if _, ok := ffResults[fullName]; ok {
-			logger.Info().Msgf("Waiting for the merge of pull request with the label: %s", cfg.Markers.MergeMethodPrefix+conf.MergeMethodFastForward)
-			continue
+		if cfg.Server.Interval > 0 {
+			sched.Trigger()
 		}
 
-		repoConfig := getRepoConfig(cfg, fullName)
+		sched.Start(ctx)
+	}()
 
-		issue, err := finder.GetCurrentPull(logger.WithContext(ctx), issues)
-		if err != nil {
-			logger.Error().Err(err).Msg("unable to get the current pull request")
-			continue
-		}
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
 
-		if issue == nil {
-			logger.Debug().Msg("Nothing to merge.")
-			continue
-		}
+	select {
+	case err = <-errCh:
+		stop()
+	case <-ctx.Done():
+		log.Info().Msg("Shutting down.")
 
-		repo := repository.New(client, fullName, cfg.Github.Token, cfg.Markers, cfg.Retry, cfg.Git, repoConfig, cfg.Extra)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
-		loggerIssue := logger.With().Int("pr", issue.GetNumber()).Logger()
+		err = server.Shutdown(shutdownCtx)
+	}
 
-		err = repo.Process(loggerIssue.WithContext(ctx), issue.GetNumber())
-		if err != nil {
-			loggerIssue.Error().Err(err).Msg("Failed to process")
-		}
+	grace := time.AfterFunc(rl.Processor().cfg.Timeouts.Shutdown, func() {
+		log.Warn().Msg("Grace period expired, canceling the processing in progress.")
+		cancelRuns()
+	})
+	defer grace.Stop()
+
+	// waits for the in-flight processing.
+	<-schedDone
+
+	wh.Wait()
+
+	return err
+}
+
+func run(cfg conf.Configuration) error {
+	proc, err := newProcessor(cfg, newRepoLocks(), nil)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return proc.Run(ctx)
 }
 
 // newGitHubClient create a new GitHub client.
-func newGitHubClient(ctx context.Context, token string, gitHubURL string) *github.Client {
-	var tc *http.Client
+// The timeout limits each request to the API (no timeout if zero).
+// The requests are instrumented by the metrics (can be nil), labeled with the owner of the credentials.
+func newGitHubClient(ctx context.Context, ts oauth2.TokenSource, owner, gitHubURL string, timeout time.Duration, recorder *metrics.Metrics) *github.Client {
+	tc := &http.Client{}
 
-	if len(token) != 0 {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	if ts != nil {
 		tc = oauth2.NewClient(ctx, ts)
 	}
 
+	tc.Transport = recorder.Transport(tc.Transport, owner)
+	tc.Timeout = timeout
+
 	client := github.NewClient(tc)
 
 	if gitHubURL != "" {
@@ -172,17 +225,11 @@ func newGitHubClient(ctx context.Context, token string, gitHubURL string) *githu
 	return client
 }
 
-func getRepoConfig(cfg conf.Configuration, repoName string) conf.RepoConfig {
-	if repoCfg, ok := cfg.Repositories[repoName]; ok && repoCfg != nil {
-		return *repoCfg
-	}
-
-	return cfg.Default
-}
-
 func usage() {
 	_, _ = os.Stderr.WriteString("Myrmica Lobicornis:\n")
 	flag.PrintDefaults()
+	_, _ = os.Stderr.WriteString("\nCommands:\n  explain [-format text|json] owner/repo#number\n        Explain the decision of the bot for a pull request, without mutating anything.\n")
+	_, _ = os.Stderr.WriteString("  config check [owner/name...]\n        Validate the configuration file, and print the effective configuration of the repositories.\n")
 }
 
 // setupLogger is configuring the logger.
@@ -191,6 +238,12 @@ func setupLogger(dryRun bool, level string) {
 
 	log.Logger = zerolog.New(os.Stderr).With().Caller().Logger()
 
+	setLogLevel(dryRun, level)
+}
+
+// setLogLevel sets the global log level.
+// The logger is never replaced: the level can be changed while the workers are logging.
+func setLogLevel(dryRun bool, level string) {
 	logLevel := zerolog.DebugLevel
 	if !dryRun {
 		var err error
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/auth"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+	"github.com/traefik/lobicornis/v3/pkg/search"
+	"golang.org/x/oauth2"
+)
+
+// forge a platform, other than GitHub, hosting the repositories processed by the processor.
+type forge interface {
+	// Run processes the current merge request of all the repositories.
+	Run(ctx context.Context) error
+	// Ping checks that the platform can be reached with the credentials.
+	Ping(ctx context.Context) error
+}
+
+// processor processes the pull requests of the repositories.
+type processor struct {
+	cfg   conf.Configuration
+	locks *repoLocks
+	cache *repository.MirrorCache
+	app   *auth.App
+
+	// forge the platform hosting the repositories, GitHub if nil.
+	forge forge
+
+	metrics *metrics.Metrics
+	status  *statusTracker
+
+	statuses *repository.StatusPublisher
+}
+
+func newProcessor(cfg conf.Configuration, locks *repoLocks, recorder *metrics.Metrics) (*processor, error) {
+	proc := &processor{
+		cfg:     cfg,
+		locks:   locks,
+		cache:   repository.NewMirrorCache(cfg.Git.CacheDir, zerolog.GlobalLevel() <= zerolog.DebugLevel),
+		metrics: recorder,
+		status:  newStatusTracker(),
+
+		statuses: repository.NewStatusPublisher(cfg.Extra.StatusContext, cfg.Extra.DryRun),
+	}
+
+	switch cfg.Provider {
+	case conf.ProviderGitLab:
+		proc.forge = newGitLabForge(proc)
+	case conf.ProviderGitea:
+		proc.forge = newGiteaForge(proc)
+	}
+
+	if cfg.Github.App.ID != 0 {
+		app, err := auth.NewApp(cfg.Github, cfg.Timeouts.API, recorder)
+		if err != nil {
+			return nil, err
+		}
+
+		proc.app = app
+	}
+
+	return proc, nil
+}
+
+// withConfig creates a processor with a new configuration.
+// The locks, the metrics, and the status of the repositories are shared with the current processor.
+// The mirror cache and the published commit statuses are also shared, unless their configuration changed.
+func (p *processor) withConfig(cfg conf.Configuration) (*processor, error) {
+	next, err := newProcessor(cfg, p.locks, p.metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	next.status = p.status
+
+	if cfg.Git.CacheDir == p.cfg.Git.CacheDir {
+		next.cache = p.cache
+	}
+
+	if cfg.Extra.StatusContext == p.cfg.Extra.StatusContext && cfg.Extra.DryRun == p.cfg.Extra.DryRun {
+		next.statuses = p.statuses
+	}
+
+	return next, nil
+}
+
+// Run processes the current pull request of all the repositories of all the owners.
+func (p *processor) Run(ctx context.Context) error {
+	if p.forge != nil {
+		return p.forge.Run(ctx)
+	}
+
+	// a full sweep: the repositories without queue are removed from the metrics.
+	p.metrics.ResetQueueSizes()
+
+	var errs []error
+	for _, owner := range p.cfg.Github.GetOwners() {
+		ts := p.tokenSource(owner.Name)
+
+		client := ghapi.New(newGitHubClient(ctx, ts, owner.Name, p.cfg.Github.URL, p.cfg.Timeouts.API, p.metrics))
+
+		err := p.process(ctx, client, ts, owner.Name, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", owner.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ping checks that GitHub (or the forge) can be reached with the credentials.
+func (p *processor) ping(ctx context.Context) error {
+	if p.forge != nil {
+		return p.forge.Ping(ctx)
+	}
+
+	owner := p.cfg.Github.GetOwners()[0].Name
+
+	client := newGitHubClient(ctx, p.tokenSource(owner), owner, p.cfg.Github.URL, p.cfg.Timeouts.API, p.metrics)
+
+	_, _, err := client.RateLimit.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reach GitHub: %w", err)
+	}
+
+	return nil
+}
+
+// tokenSource gets the source of the tokens of an owner.
+// The token of the owner wins over the global token.
+// Returns nil if there is no token.
+func (p *processor) tokenSource(owner string) oauth2.TokenSource {
+	if p.app != nil {
+		return p.app.TokenSource(owner)
+	}
+
+	if o, ok := p.cfg.Github.GetOwner(owner); ok && o.Token != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: o.Token})
+	}
+
+	if p.cfg.Github.Token != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.cfg.Github.Token})
+	}
+
+	return nil
+}
+
+// gitToken gets the token used by the Git HTTPS remotes.
+func (p *processor) gitToken(ts oauth2.TokenSource) (string, error) {
+	if ts == nil {
+		return "", nil
+	}
+
+	token, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+
+	if p.app != nil {
+		// the installation tokens require a specific user.
+		return "x-access-token:" + token.AccessToken, nil
+	}
+
+	return token.AccessToken, nil
+}
+
+// process processes the current pull request of each repository of an owner matching the search parameters.
+// The repositories not managed by the bot (include/exclude lists) are ignored.
+// If accept is not nil, the current pull request is only processed when accept returns true.
+func (p *processor) process(ctx context.Context, client *ghapi.Client, ts oauth2.TokenSource, owner string, accept func(fullName string, number int) bool, parameters ...search.Parameter) error {
+	finder := search.New(client, p.cfg.Markers, p.cfg.Retry)
+
+	// search PRs with the FF merge method.
+	ffResults, err := finder.Search(ctx, owner, append([]search.Parameter{
+		search.WithLabels(p.cfg.Markers.MergeMethodPrefix + conf.MergeMethodFastForward),
+		search.WithExcludedLabels(p.cfg.Markers.NoMerge, p.cfg.Markers.NeedMerge),
+	}, parameters...)...)
+	if err != nil {
+		return err
+	}
+
+	// search NeedMerge
+	results, err := finder.Search(ctx, owner, append([]search.Parameter{
+		search.WithLabels(p.cfg.Markers.NeedMerge),
+		search.WithExcludedLabels(p.cfg.Markers.NeedHumanMerge, p.cfg.Markers.NoMerge),
+	}, parameters...)...)
+	if err != nil {
+		return err
+	}
+
+	deleteUnmanaged(ctx, p.cfg, results)
+
+	for fullName, issues := range results {
+		p.metrics.QueueSize(fullName, len(issues))
+	}
+
+	p.dispatch(ctx, slices.Collect(maps.Keys(results)), func(fullName string) {
+		logger := log.With().Str("repo", fullName).Logger()
+
+		if _, ok := ffResults[fullName]; ok {
+			logger.Info().Msgf("Waiting for the merge of pull request with the label: %s", p.cfg.Markers.MergeMethodPrefix+conf.MergeMethodFastForward)
+			return
+		}
+
+		p.processRepository(logger.WithContext(ctx), client, ts, finder, fullName, results[fullName], accept)
+	})
+
+	return nil
+}
+
+// dispatch processes the repositories concurrently by a pool of workers.
+func (p *processor) dispatch(ctx context.Context, fullNames []string, fn func(fullName string)) {
+	jobs := make(chan string)
+
+	var wg sync.WaitGroup
+	for range max(p.cfg.Extra.Workers, 1) {
+		wg.Go(func() {
+			for fullName := range jobs {
+				fn(fullName)
+			}
+		})
+	}
+
+	for _, fullName := range fullNames {
+		if ctx.Err() != nil {
+			// canceled: the remaining repositories are processed by the next run.
+			continue
+		}
+
+		jobs <- fullName
+	}
+
+	close(jobs)
+
+	wg.Wait()
+}
+
+// processRepository processes the current pull request of a repository.
+func (p *processor) processRepository(ctx context.Context, client *ghapi.Client, ts oauth2.TokenSource, finder search.Finder, fullName string, issues []*github.Issue, accept func(fullName string, number int) bool) {
+	unlock := p.locks.Lock(fullName)
+	defer unlock()
+
+	var queue []int
+	for _, issue := range issues {
+		queue = append(queue, issue.GetNumber())
+	}
+
+	p.status.start(fullName, queue)
+
+	err := p.processQueue(ctx, client, ts, finder, fullName, issues, accept)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to process")
+	}
+
+	p.status.done(fullName, err)
+}
+
+// processQueue processes the current pull request of the queue of a repository.
+func (p *processor) processQueue(ctx context.Context, client *ghapi.Client, ts oauth2.TokenSource, finder search.Finder, fullName string, issues []*github.Issue, accept func(fullName string, number int) bool) error {
+	logger := log.Ctx(ctx)
+
+	repoConfig := p.cfg.GetRepoConfig(fullName)
+
+	token, err := p.gitToken(ts)
+	if err != nil {
+		return fmt.Errorf("unable to get a token: %w", err)
+	}
+
+	if repoConfig.GetMergeTrainSize() > 1 {
+		repo := repository.New(client, fullName, token, p.cfg.Markers, p.cfg.Retry, p.cfg.Timeouts, p.cfg.Git, repoConfig, p.cfg.Extra, p.cache, p.metrics, p.statuses)
+
+		var numbers []int
+		for _, issue := range issues {
+			numbers = append(numbers, issue.GetNumber())
+		}
+
+		if accept != nil && !slices.ContainsFunc(numbers, func(number int) bool { return accept(fullName, number) }) {
+			logger.Debug().Msg("The merge train is not affected.")
+			return nil
+		}
+
+		err = repo.ProcessTrain(ctx, numbers)
+		if err != nil {
+			return fmt.Errorf("failed to process the merge train: %w", err)
+		}
+
+		return nil
+	}
+
+	issue, err := finder.GetCurrentPull(ctx, issues)
+	if err != nil {
+		return fmt.Errorf("unable to get the current pull request: %w", err)
+	}
+
+	repo := repository.New(client, fullName, token, p.cfg.Markers, p.cfg.Retry, p.cfg.Timeouts, p.cfg.Git, repoConfig, p.cfg.Extra, p.cache, p.metrics, p.statuses)
+
+	waiting, ahead := getWaitingPulls(issues, issue, p.cfg.Markers.MergeInProgress)
+	repo.PublishQueue(ctx, waiting, ahead)
+
+	if issue == nil {
+		logger.Debug().Msg("Nothing to merge.")
+		return nil
+	}
+
+	if accept != nil && !accept(fullName, issue.GetNumber()) {
+		logger.Debug().Int("pr", issue.GetNumber()).Msg("The current pull request is not affected.")
+		return nil
+	}
+
+	p.status.current(fullName, issue.GetNumber())
+
+	position := slices.IndexFunc(issues, func(i *github.Issue) bool { return i.GetNumber() == issue.GetNumber() }) + 1
+
+	loggerIssue := logger.With().Int("pr", issue.GetNumber()).
+		Int("position", position).Int("queue", len(issues)).Int("priority", finder.GetPriority(issue)).
+		Logger()
+
+	err = repo.Process(loggerIssue.WithContext(ctx), issue.GetNumber())
+	if err != nil {
+		return fmt.Errorf("failed to process #%d: %w", issue.GetNumber(), err)
+	}
+
+	return nil
+}
+
+// getWaitingPulls gets the pull requests waiting for the pull requests in progress (the current one, and the retries).
+func getWaitingPulls(issues []*github.Issue, current *github.Issue, mergeInProgress string) ([]*github.Issue, int) {
+	var waiting []*github.Issue
+
+	var ahead int
+	for _, issue := range issues {
+		inProgress := slices.ContainsFunc(issue.Labels, func(label *github.Label) bool {
+			return label.GetName() == mergeInProgress
+		})
+
+		if inProgress || issue.GetNumber() == current.GetNumber() {
+			ahead++
+			continue
+		}
+
+		waiting = append(waiting, issue)
+	}
+
+	return waiting, ahead
+}
+
+// repoLocks ensures that a repository is never processed concurrently.
+type repoLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newRepoLocks() *repoLocks {
+	return &repoLocks{locks: make(map[string]*sync.Mutex)}
+}
+
+// Lock locks a repository, and returns the unlock function.
+func (l *repoLocks) Lock(fullName string) func() {
+	l.mu.Lock()
+
+	lock, ok := l.locks[fullName]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[fullName] = lock
+	}
+
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return lock.Unlock
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+func TestRepoLocks_Lock(t *testing.T) {
+	locks := newRepoLocks()
+
+	var current, maxConcurrent atomic.Int32
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			unlock := locks.Lock("traefik/traefik")
+			defer unlock()
+
+			n := current.Add(1)
+			defer current.Add(-1)
+
+			for {
+				old := maxConcurrent.Load()
+				if n <= old || maxConcurrent.CompareAndSwap(old, n) {
+					break
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), maxConcurrent.Load())
+
+	// different repositories are not locked together.
+	unlockA := locks.Lock("traefik/a")
+	unlockB := locks.Lock("traefik/b")
+	unlockA()
+	unlockB()
+}
+
+func TestProcessor_withConfig(t *testing.T) {
+	cfg := conf.Configuration{
+		Git:   conf.Git{CacheDir: "/tmp/lobicornis"},
+		Extra: conf.Extra{StatusContext: "lobicornis"},
+	}
+
+	proc, err := newProcessor(cfg, newRepoLocks(), nil)
+	require.NoError(t, err)
+
+	next, err := proc.withConfig(cfg)
+	require.NoError(t, err)
+
+	assert.Same(t, proc.cache, next.cache)
+	assert.Same(t, proc.statuses, next.statuses)
+
+	// a change of the configuration of the cache or of the commit status creates a new one.
+	changed := cfg
+	changed.Git.CacheDir = "/tmp/other"
+	changed.Extra.StatusContext = "other"
+
+	next, err = proc.withConfig(changed)
+	require.NoError(t, err)
+
+	assert.NotSame(t, proc.cache, next.cache)
+	assert.NotSame(t, proc.statuses, next.statuses)
+}
+
+func Test_getWaitingPulls(t *testing.T) {
+	inProgress := []*github.Label{{Name: github.Ptr("status/4-merge-in-progress")}}
+
+	issues := []*github.Issue{
+		{Number: github.Ptr(1)},
+		{Number: github.Ptr(2), Labels: inProgress},
+		{Number: github.Ptr(3)},
+		{Number: github.Ptr(4)},
+	}
+
+	waiting, ahead := getWaitingPulls(issues, issues[0], "status/4-merge-in-progress")
+
+	assert.Equal(t, []*github.Issue{issues[2], issues[3]}, waiting)
+	assert.Equal(t, 2, ahead)
+
+	waiting, ahead = getWaitingPulls(issues, nil, "status/4-merge-in-progress")
+
+	assert.Equal(t, []*github.Issue{issues[0], issues[2], issues[3]}, waiting)
+	assert.Equal(t, 1, ahead)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// configWatchInterval the interval between two checks of the configuration file.
+const configWatchInterval = 5 * time.Second
+
+// reloader reloads the configuration on SIGHUP, or when the configuration file changes.
+// The processor is replaced atomically: a run in progress finishes with the previous configuration.
+type reloader struct {
+	filename string
+	current  atomic.Pointer[processor]
+
+	// the state of the configuration file at the last load.
+	modTime time.Time
+	size    int64
+}
+
+func newReloader(filename string, proc *processor) *reloader {
+	r := &reloader{filename: filename}
+	r.current.Store(proc)
+
+	r.modTime, r.size = fileState(filename)
+
+	return r
+}
+
+// Processor gets the processor of the current configuration.
+func (r *reloader) Processor() *processor {
+	return r.current.Load()
+}
+
+// Start watches the configuration until the context is done.
+func (r *reloader) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-hup:
+			log.Info().Msg("SIGHUP received.")
+
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+
+			log.Info().Str("file", r.filename).Msg("Configuration file changed.")
+		}
+
+		err := r.Reload()
+		if err != nil {
+			log.Error().Err(err).Msg("The previous configuration is kept.")
+		}
+	}
+}
+
+// Reload loads and validates the configuration, then replaces the processor.
+func (r *reloader) Reload() error {
+	r.modTime, r.size = fileState(r.filename)
+
+	cfg, err := conf.Load(r.filename)
+	if err != nil {
+		return fmt.Errorf("unable to reload the configuration: %w", err)
+	}
+
+	current := r.current.Load()
+
+	err = checkReloadable(current.cfg, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to reload the configuration: %w", err)
+	}
+
+	next, err := current.withConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("unable to reload the configuration: %w", err)
+	}
+
+	setLogLevel(cfg.Extra.DryRun, cfg.Extra.LogLevel)
+
+	r.current.Store(next)
+
+	log.Info().Msg("Configuration reloaded.")
+
+	return nil
+}
+
+// checkReloadable checks that the new configuration only changes the fields applied without a restart.
+func checkReloadable(current, next conf.Configuration) error {
+	var fields []string
+
+	if current.Provider != next.Provider {
+		fields = append(fields, "provider")
+	}
+
+	if current.Server.Port != next.Server.Port {
+		fields = append(fields, "server.port")
+	}
+
+	if current.Server.Interval != next.Server.Interval {
+		fields = append(fields, "server.interval")
+	}
+
+	if current.Server.Jitter != next.Server.Jitter {
+		fields = append(fields, "server.jitter")
+	}
+
+	if len(fields) > 0 {
+		return fmt.Errorf("a restart is needed to change: %s", strings.Join(fields, ", "))
+	}
+
+	return nil
+}
+
+// changed checks if the configuration file changed since the last load.
+func (r *reloader) changed() bool {
+	modTime, size := fileState(r.filename)
+
+	return !modTime.Equal(r.modTime) || size != r.size
+}
+
+func fileState(filename string) (time.Time, int64) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}, 0
+	}
+
+	return info.ModTime(), info.Size()
+}
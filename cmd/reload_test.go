@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	const content = `
+github:
+  user: traefik
+git:
+  email: bot@example.com
+  userName: botname
+  cacheDir: /tmp/lobicornis
+extra:
+  statusContext: lobicornis
+default:
+  minReview: %d
+`
+
+	filename := filepath.Join(t.TempDir(), "lobicornis.yml")
+	writeConfig(t, filename, content, 1)
+
+	cfg, err := conf.Load(filename)
+	require.NoError(t, err)
+
+	proc, err := newProcessor(cfg, newRepoLocks(), nil)
+	require.NoError(t, err)
+
+	rl := newReloader(filename, proc)
+	assert.False(t, rl.changed())
+
+	// a run in progress keeps its processor.
+	inFlight := rl.Processor()
+
+	writeConfig(t, filename, content, 2)
+	require.True(t, rl.changed())
+
+	require.NoError(t, rl.Reload())
+	assert.False(t, rl.changed())
+
+	assert.Equal(t, 2, rl.Processor().cfg.Default.GetMinReview())
+	assert.Equal(t, 1, inFlight.cfg.Default.GetMinReview())
+
+	// the shared state is kept.
+	assert.Same(t, proc.status, rl.Processor().status)
+	assert.Same(t, proc.locks, rl.Processor().locks)
+	assert.Same(t, proc.cache, rl.Processor().cache)
+	assert.Same(t, proc.statuses, rl.Processor().statuses)
+
+	// an invalid configuration is never used.
+	writeConfig(t, filename, content, -1)
+
+	require.ErrorContains(t, rl.Reload(), "default.minReview is invalid")
+	assert.Equal(t, 2, rl.Processor().cfg.Default.GetMinReview())
+}
+
+func TestReloader_Reload_restart(t *testing.T) {
+	const content = `
+github:
+  user: traefik
+git:
+  email: bot@example.com
+  userName: botname
+server:
+  port: %d
+`
+
+	filename := filepath.Join(t.TempDir(), "lobicornis.yml")
+	writeConfig(t, filename, content, 80)
+
+	cfg, err := conf.Load(filename)
+	require.NoError(t, err)
+
+	proc, err := newProcessor(cfg, newRepoLocks(), nil)
+	require.NoError(t, err)
+
+	rl := newReloader(filename, proc)
+
+	writeConfig(t, filename, content, 8080)
+
+	require.EqualError(t, rl.Reload(), "unable to reload the configuration: a restart is needed to change: server.port")
+	assert.Same(t, proc, rl.Processor())
+}
+
+func Test_checkReloadable(t *testing.T) {
+	current := conf.Configuration{
+		Provider: conf.ProviderGitHub,
+		Server:   conf.Server{Port: 80, Interval: time.Minute},
+		Extra:    conf.Extra{Workers: 1, LogLevel: "info"},
+		Default:  conf.RepoConfig{MinReview: conf.Int(1)},
+	}
+
+	testCases := []struct {
+		desc     string
+		update   func(cfg *conf.Configuration)
+		errorMsg string
+	}{
+		{
+			desc: "live changes",
+			update: func(cfg *conf.Configuration) {
+				cfg.Extra.LogLevel = "debug"
+				cfg.Extra.Workers = 4
+				cfg.Default.MinReview = conf.Int(2)
+			},
+		},
+		{
+			desc:     "provider",
+			update:   func(cfg *conf.Configuration) { cfg.Provider = conf.ProviderGitLab },
+			errorMsg: "a restart is needed to change: provider",
+		},
+		{
+			desc: "server",
+			update: func(cfg *conf.Configuration) {
+				cfg.Server.Port = 8080
+				cfg.Server.Interval = time.Hour
+				cfg.Server.Jitter = time.Second
+			},
+			errorMsg: "a restart is needed to change: server.port, server.interval, server.jitter",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := current
+			test.update(&next)
+
+			err := checkReloadable(current, next)
+
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func writeConfig(t *testing.T, filename, content string, value int) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filename, []byte(fmt.Sprintf(content, value)), 0o600))
+
+	// the modification time is not always updated on fast file systems.
+	modTime := time.Unix(int64(1000+value), 0)
+	require.NoError(t, os.Chtimes(filename, modTime, modTime))
+}
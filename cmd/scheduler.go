@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// scheduler runs a task periodically, and on demand.
+// The runs never overlap.
+type scheduler struct {
+	interval time.Duration
+	jitter   time.Duration
+
+	trigger chan struct{}
+
+	task func() error
+}
+
+func newScheduler(cfg conf.Server, task func() error) *scheduler {
+	return &scheduler{
+		interval: cfg.Interval,
+		jitter:   cfg.Jitter,
+		trigger:  make(chan struct{}, 1),
+		task:     task,
+	}
+}
+
+// Trigger requests an immediate run.
+// Returns false if a run is already requested.
+func (s *scheduler) Trigger() bool {
+	select {
+	case s.trigger <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start runs the task until the context is done.
+// A run in progress is never interrupted: Start returns only when the current run is finished.
+func (s *scheduler) Start(ctx context.Context) {
+	var timer *time.Timer
+	var tick <-chan time.Time
+
+	if s.interval > 0 {
+		timer = time.NewTimer(s.next())
+		defer timer.Stop()
+
+		tick = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-s.trigger:
+		}
+
+		s.run()
+
+		if timer != nil {
+			timer.Reset(s.next())
+		}
+	}
+}
+
+func (s *scheduler) run() {
+	err := s.task()
+	if err != nil {
+		log.Error().Err(err).Msg("Report error")
+	}
+}
+
+// next gets the delay before the next run.
+func (s *scheduler) next() time.Duration {
+	if s.jitter <= 0 {
+		return s.interval
+	}
+
+	return s.interval + rand.N(s.jitter) //nolint:gosec // no need of a secure random for a jitter.
+}
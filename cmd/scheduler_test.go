@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+func TestScheduler_Trigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count atomic.Int32
+	var running atomic.Bool
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	sched := newScheduler(conf.Server{}, func() error {
+		if !running.CompareAndSwap(false, true) {
+			t.Error("overlapping runs")
+		}
+		defer running.Store(false)
+
+		if count.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sched.Start(ctx)
+	}()
+
+	assert.True(t, sched.Trigger())
+
+	<-started
+
+	// during a run, only one extra run can be requested.
+	assert.True(t, sched.Trigger())
+	assert.False(t, sched.Trigger())
+
+	close(release)
+
+	assert.Eventually(t, func() bool { return count.Load() == 2 }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestScheduler_interval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count atomic.Int32
+
+	sched := newScheduler(conf.Server{Interval: 10 * time.Millisecond, Jitter: 5 * time.Millisecond}, func() error {
+		count.Add(1)
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sched.Start(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return count.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
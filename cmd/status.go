@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// readinessTimeout the timeout of the GitHub request of the readiness probe.
+const readinessTimeout = 5 * time.Second
+
+// repoStatus the status of the processing of a repository.
+type repoStatus struct {
+	// LastRun the end of the last processing.
+	LastRun *time.Time `json:"lastRun,omitempty"`
+	// Running true during a processing.
+	Running bool `json:"running"`
+	// Current the pull request in progress (the head of the queue).
+	Current int `json:"current,omitempty"`
+	// Queue the pull requests with the need-merge label.
+	Queue []int `json:"queue"`
+	// LastError the error of the last processing.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// statusTracker tracks the status of the repositories.
+type statusTracker struct {
+	mu    sync.Mutex
+	repos map[string]*repoStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{repos: make(map[string]*repoStatus)}
+}
+
+func (s *statusTracker) get(fullName string) *repoStatus {
+	status, ok := s.repos[fullName]
+	if !ok {
+		status = &repoStatus{}
+		s.repos[fullName] = status
+	}
+
+	return status
+}
+
+// start marks the start of the processing of a repository.
+func (s *statusTracker) start(fullName string, queue []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.get(fullName)
+	status.Running = true
+	status.Current = 0
+	status.Queue = slices.Clone(queue)
+}
+
+// current sets the pull request in progress.
+func (s *statusTracker) current(fullName string, number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(fullName).Current = number
+}
+
+// done marks the end of the processing of a repository.
+func (s *statusTracker) done(fullName string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	status := s.get(fullName)
+	status.Running = false
+	status.LastRun = &now
+	status.LastError = ""
+
+	if err != nil {
+		status.LastError = err.Error()
+	}
+}
+
+// ServeHTTP writes the status of the repositories as JSON.
+func (s *statusTracker) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	data, err := json.Marshal(map[string]any{"repositories": s.repos})
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to marshal the status.")
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	_, err = rw.Write(data)
+	if err != nil {
+		log.Error().Err(err).Msg("Report error")
+	}
+}
+
+// healthz the liveness probe.
+func healthz(rw http.ResponseWriter, _ *http.Request) {
+	_, _ = fmt.Fprint(rw, "OK\n")
+}
+
+// readyz the readiness probe: the configuration is loaded, and GitHub can be reached.
+func readyz(current func() *processor) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		proc := current()
+		if proc == nil {
+			http.Error(rw, "configuration not loaded", http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), readinessTimeout)
+		defer cancel()
+
+		err := proc.ping(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Not ready.")
+			http.Error(rw, "GitHub unreachable", http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = fmt.Fprint(rw, "OK\n")
+	}
+}
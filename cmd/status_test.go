@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+func TestStatusTracker_ServeHTTP(t *testing.T) {
+	tracker := newStatusTracker()
+
+	tracker.start("traefik/traefik", []int{1, 2, 3})
+	tracker.current("traefik/traefik", 2)
+
+	tracker.start("traefik/yaegi", []int{4})
+	tracker.done("traefik/yaegi", errors.New("boom"))
+
+	rw := httptest.NewRecorder()
+	tracker.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/status", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+	var result struct {
+		Repositories map[string]repoStatus `json:"repositories"`
+	}
+
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+
+	require.Len(t, result.Repositories, 2)
+
+	traefik := result.Repositories["traefik/traefik"]
+	assert.True(t, traefik.Running)
+	assert.Equal(t, 2, traefik.Current)
+	assert.Equal(t, []int{1, 2, 3}, traefik.Queue)
+	assert.Nil(t, traefik.LastRun)
+	assert.Empty(t, traefik.LastError)
+
+	yaegi := result.Repositories["traefik/yaegi"]
+	assert.False(t, yaegi.Running)
+	assert.Equal(t, []int{4}, yaegi.Queue)
+	assert.NotNil(t, yaegi.LastRun)
+	assert.Equal(t, "boom", yaegi.LastError)
+}
+
+func Test_readyz(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		status   int
+		expected int
+	}{
+		{
+			desc:     "GitHub reachable",
+			status:   http.StatusOK,
+			expected: http.StatusOK,
+		},
+		{
+			desc:     "GitHub unreachable",
+			status:   http.StatusBadGateway,
+			expected: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/rate_limit" {
+					http.NotFound(rw, req)
+					return
+				}
+
+				rw.WriteHeader(test.status)
+				_, _ = rw.Write([]byte(`{}`))
+			}))
+			t.Cleanup(server.Close)
+
+			cfg := conf.Configuration{Github: conf.Github{User: "traefik", URL: server.URL + "/"}}
+
+			proc, err := newProcessor(cfg, newRepoLocks(), nil)
+			require.NoError(t, err)
+
+			rw := httptest.NewRecorder()
+			readyz(func() *processor { return proc }).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+
+			assert.Equal(t, test.expected, rw.Code)
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+	"github.com/traefik/lobicornis/v3/pkg/search"
+)
+
+// Check run, check suite and status events are only relevant when the checks are done.
+const (
+	actionCompleted = "completed"
+	statePending    = "pending"
+)
+
+// Pull request actions.
+const (
+	actionClosed = "closed"
+)
+
+// pullRequestActions the actions of a pull request event that can change the merge state of the pull request.
+var pullRequestActions = []string{"opened", "reopened", "synchronize", "ready_for_review", "edited", "labeled", "unlabeled"}
+
+// eventTarget the repository and the pull requests affected by a webhook event.
+type eventTarget struct {
+	fullName string
+	// sha the head commit of the affected pull requests, used when the event doesn't provide the pull request numbers.
+	sha     string
+	numbers []int
+}
+
+// webhook handles the GitHub webhook events.
+type webhook struct {
+	// ctx the base context of the processing of the events, canceled on shutdown.
+	ctx context.Context //nolint:containedctx // the processing outlives the requests.
+
+	// current gets the processor of the current configuration.
+	current func() *processor
+	// handle processes an event target, in the background.
+	handle func(ctx context.Context, proc *processor, target eventTarget)
+	wg     sync.WaitGroup
+
+	mu sync.Mutex
+	// runs the repositories with a processing in progress, and the target queued behind it (nil if none).
+	// The events are coalesced: at most one processing is in progress, and one is queued, by repository.
+	runs map[string]*eventTarget
+}
+
+func newWebhook(ctx context.Context, current func() *processor) *webhook {
+	w := &webhook{ctx: ctx, current: current, runs: make(map[string]*eventTarget)}
+	w.handle = w.process
+
+	return w
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		log.Error().Str("method", req.Method).Msg("Invalid http method")
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the configuration used by the whole processing of the event.
+	proc := w.current()
+
+	if proc.cfg.Server.WebhookSecret == "" {
+		http.NotFound(rw, req)
+		return
+	}
+
+	logger := log.With().Str("delivery", github.DeliveryID(req)).Str("event", github.WebHookType(req)).Logger()
+
+	payload, err := github.ValidatePayload(req, []byte(proc.cfg.Server.WebhookSecret))
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid webhook payload")
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	event, err := github.ParseWebHook(github.WebHookType(req), payload)
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to parse the webhook payload")
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	target, ok := getEventTarget(event)
+	if !ok {
+		logger.Debug().Msg("Event ignored.")
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !proc.cfg.IsManaged(target.fullName) {
+		logger.Warn().Str("repo", target.fullName).Msg("Event from an unmanaged repository.")
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.schedule(logger.WithContext(w.ctx), proc, target)
+
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// schedule processes a target in the background,
+// or queues it behind the processing in progress of the repository.
+func (w *webhook) schedule(ctx context.Context, proc *processor, target eventTarget) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	queued, running := w.runs[target.fullName]
+	if running {
+		if queued == nil {
+			w.runs[target.fullName] = &target
+		} else {
+			*queued = mergeTargets(*queued, target)
+		}
+
+		log.Ctx(ctx).Debug().Str("repo", target.fullName).Msg("Event queued behind the processing in progress.")
+
+		return
+	}
+
+	w.runs[target.fullName] = nil
+
+	w.wg.Go(func() { w.run(ctx, proc, target) })
+}
+
+// run processes a target, then the targets queued during the processing.
+func (w *webhook) run(ctx context.Context, proc *processor, target eventTarget) {
+	for {
+		w.handle(ctx, proc, target)
+
+		w.mu.Lock()
+
+		queued := w.runs[target.fullName]
+		if queued == nil {
+			delete(w.runs, target.fullName)
+			w.mu.Unlock()
+
+			return
+		}
+
+		w.runs[target.fullName] = nil
+
+		w.mu.Unlock()
+
+		// the queued events can come from several deliveries, with a newer configuration.
+		logger := log.With().Str("event", "coalesced").Logger()
+
+		ctx, proc, target = logger.WithContext(w.ctx), w.current(), *queued
+	}
+}
+
+// Wait waits for the processing of the received events.
+func (w *webhook) Wait() {
+	w.wg.Wait()
+}
+
+// process processes the current pull request of the repository targeted by the event.
+func (w *webhook) process(ctx context.Context, proc *processor, target eventTarget) {
+	logger := log.Ctx(ctx).With().Str("repo", target.fullName).Logger()
+
+	owner, _, _ := strings.Cut(target.fullName, "/")
+
+	ts := proc.tokenSource(owner)
+	client := ghapi.New(newGitHubClient(ctx, ts, owner, proc.cfg.Github.URL, proc.cfg.Timeouts.API, proc.metrics))
+
+	numbers := target.numbers
+	if target.sha != "" {
+		var err error
+		numbers, err = findPullRequestsWithCommit(ctx, client, target.fullName, target.sha)
+		if err != nil {
+			logger.Error().Err(err).Str("sha", target.sha).Msg("Unable to find the pull requests related to the commit.")
+			return
+		}
+
+		if len(numbers) == 0 {
+			logger.Debug().Str("sha", target.sha).Msg("No pull request related to the commit.")
+			return
+		}
+	}
+
+	accept := func(_ string, number int) bool {
+		return len(numbers) == 0 || slices.Contains(numbers, number)
+	}
+
+	err := proc.process(logger.WithContext(ctx), client, ts, owner, accept, search.WithRepository(target.fullName))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to process the webhook event")
+	}
+}
+
+// mergeTargets merges the targets of the same repository.
+// The targets without pull request numbers (a commit, or a closed pull request) fall back to the current pull request of the repository.
+func mergeTargets(a, b eventTarget) eventTarget {
+	if len(a.numbers) == 0 || len(b.numbers) == 0 {
+		return eventTarget{fullName: a.fullName}
+	}
+
+	numbers := slices.Concat(a.numbers, b.numbers)
+	slices.Sort(numbers)
+
+	return eventTarget{fullName: a.fullName, numbers: slices.Compact(numbers)}
+}
+
+// getEventTarget extracts the repository and the pull requests affected by an event.
+func getEventTarget(event any) (eventTarget, bool) {
+	switch evt := event.(type) {
+	case *github.PullRequestEvent:
+		if evt.GetAction() == actionClosed {
+			// the next pull request of the queue can be processed.
+			return eventTarget{fullName: evt.GetRepo().GetFullName()}, true
+		}
+
+		if !slices.Contains(pullRequestActions, evt.GetAction()) {
+			return eventTarget{}, false
+		}
+
+		return eventTarget{
+			fullName: evt.GetRepo().GetFullName(),
+			numbers:  []int{evt.GetNumber()},
+		}, true
+
+	case *github.PullRequestReviewEvent:
+		return eventTarget{
+			fullName: evt.GetRepo().GetFullName(),
+			numbers:  []int{evt.GetPullRequest().GetNumber()},
+		}, true
+
+	case *github.CheckSuiteEvent:
+		if evt.GetAction() != actionCompleted {
+			return eventTarget{}, false
+		}
+
+		return newChecksTarget(evt.GetRepo().GetFullName(), evt.GetCheckSuite().GetHeadSHA(), evt.GetCheckSuite().PullRequests), true
+
+	case *github.CheckRunEvent:
+		if evt.GetAction() != actionCompleted {
+			return eventTarget{}, false
+		}
+
+		return newChecksTarget(evt.GetRepo().GetFullName(), evt.GetCheckRun().GetHeadSHA(), evt.GetCheckRun().PullRequests), true
+
+	case *github.StatusEvent:
+		if evt.GetState() == statePending {
+			return eventTarget{}, false
+		}
+
+		return eventTarget{
+			fullName: evt.GetRepo().GetFullName(),
+			sha:      evt.GetSHA(),
+		}, true
+
+	default:
+		return eventTarget{}, false
+	}
+}
+
+// newChecksTarget creates a target from a check run or a check suite.
+// The pull requests are not provided by GitHub when the head branch is on a fork, in this case the head SHA is used.
+func newChecksTarget(fullName, sha string, prs []*github.PullRequest) eventTarget {
+	if len(prs) == 0 {
+		return eventTarget{fullName: fullName, sha: sha}
+	}
+
+	target := eventTarget{fullName: fullName}
+	for _, pr := range prs {
+		target.numbers = append(target.numbers, pr.GetNumber())
+	}
+
+	return target
+}
+
+// findPullRequestsWithCommit finds the open pull requests related to a commit.
+func findPullRequestsWithCommit(ctx context.Context, client *ghapi.Client, fullName, sha string) ([]int, error) {
+	owner, name, _ := strings.Cut(fullName, "/")
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	var numbers []int
+	for {
+		prs, resp, err := client.PullRequests.ListPullRequestsWithCommit(ctx, owner, name, sha, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range prs {
+			if pr.GetState() == "open" {
+				numbers = append(numbers, pr.GetNumber())
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return numbers, nil
+}
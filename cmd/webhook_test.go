@@ -0,0 +1,443 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+func Test_getEventTarget(t *testing.T) {
+	repo := &github.Repository{FullName: github.Ptr("traefik/traefik")}
+
+	testCases := []struct {
+		desc     string
+		event    any
+		expected eventTarget
+		ok       bool
+	}{
+		{
+			desc:     "pull request",
+			event:    &github.PullRequestEvent{Action: github.Ptr("synchronize"), Number: github.Ptr(12), Repo: repo},
+			expected: eventTarget{fullName: "traefik/traefik", numbers: []int{12}},
+			ok:       true,
+		},
+		{
+			desc:     "pull request labeled",
+			event:    &github.PullRequestEvent{Action: github.Ptr("labeled"), Number: github.Ptr(12), Repo: repo},
+			expected: eventTarget{fullName: "traefik/traefik", numbers: []int{12}},
+			ok:       true,
+		},
+		{
+			desc:     "pull request unlabeled",
+			event:    &github.PullRequestEvent{Action: github.Ptr("unlabeled"), Number: github.Ptr(12), Repo: repo},
+			expected: eventTarget{fullName: "traefik/traefik", numbers: []int{12}},
+			ok:       true,
+		},
+		{
+			desc:     "pull request closed",
+			event:    &github.PullRequestEvent{Action: github.Ptr("closed"), Number: github.Ptr(12), Repo: repo},
+			expected: eventTarget{fullName: "traefik/traefik"},
+			ok:       true,
+		},
+		{
+			desc:  "pull request assigned",
+			event: &github.PullRequestEvent{Action: github.Ptr("assigned"), Number: github.Ptr(12), Repo: repo},
+		},
+		{
+			desc: "pull request review",
+			event: &github.PullRequestReviewEvent{
+				PullRequest: &github.PullRequest{Number: github.Ptr(13)},
+				Repo:        repo,
+			},
+			expected: eventTarget{fullName: "traefik/traefik", numbers: []int{13}},
+			ok:       true,
+		},
+		{
+			desc: "completed check suite with pull requests",
+			event: &github.CheckSuiteEvent{
+				Action: github.Ptr("completed"),
+				CheckSuite: &github.CheckSuite{
+					HeadSHA:      github.Ptr("abc"),
+					PullRequests: []*github.PullRequest{{Number: github.Ptr(1)}, {Number: github.Ptr(2)}},
+				},
+				Repo: repo,
+			},
+			expected: eventTarget{fullName: "traefik/traefik", numbers: []int{1, 2}},
+			ok:       true,
+		},
+		{
+			desc: "completed check run from a fork",
+			event: &github.CheckRunEvent{
+				Action:   github.Ptr("completed"),
+				CheckRun: &github.CheckRun{HeadSHA: github.Ptr("abc")},
+				Repo:     repo,
+			},
+			expected: eventTarget{fullName: "traefik/traefik", sha: "abc"},
+			ok:       true,
+		},
+		{
+			desc: "created check run",
+			event: &github.CheckRunEvent{
+				Action:   github.Ptr("created"),
+				CheckRun: &github.CheckRun{HeadSHA: github.Ptr("abc")},
+				Repo:     repo,
+			},
+		},
+		{
+			desc:     "status",
+			event:    &github.StatusEvent{SHA: github.Ptr("abc"), State: github.Ptr("success"), Repo: repo},
+			expected: eventTarget{fullName: "traefik/traefik", sha: "abc"},
+			ok:       true,
+		},
+		{
+			desc:  "pending status",
+			event: &github.StatusEvent{SHA: github.Ptr("abc"), State: github.Ptr("pending"), Repo: repo},
+		},
+		{
+			desc:  "repository label",
+			event: &github.LabelEvent{Action: github.Ptr("edited"), Repo: repo},
+		},
+		{
+			desc:  "unsupported event",
+			event: &github.PushEvent{},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			target, ok := getEventTarget(test.event)
+
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, target)
+		})
+	}
+}
+
+func TestWebhook_ServeHTTP(t *testing.T) {
+	cfg := conf.Configuration{
+		Github: conf.Github{User: "traefik", Exclude: []string{"traefik/archived-*"}},
+		Server: conf.Server{WebhookSecret: "secret"},
+	}
+
+	testCases := []struct {
+		desc     string
+		method   string
+		event    string
+		body     string
+		secret   string
+		expected int
+	}{
+		{
+			desc:     "invalid method",
+			method:   http.MethodGet,
+			expected: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:     "invalid signature",
+			method:   http.MethodPost,
+			event:    "ping",
+			body:     `{}`,
+			secret:   "foo",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			desc:     "ignored event",
+			method:   http.MethodPost,
+			event:    "ping",
+			body:     `{}`,
+			secret:   "secret",
+			expected: http.StatusNoContent,
+		},
+		{
+			desc:     "unmanaged repository",
+			method:   http.MethodPost,
+			event:    "pull_request",
+			body:     `{"action": "opened", "number": 1, "repository": {"full_name": "foo/bar"}}`,
+			secret:   "secret",
+			expected: http.StatusNoContent,
+		},
+		{
+			desc:     "excluded repository",
+			method:   http.MethodPost,
+			event:    "pull_request",
+			body:     `{"action": "opened", "number": 1, "repository": {"full_name": "traefik/archived-foo"}}`,
+			secret:   "secret",
+			expected: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(test.method, "/webhook", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(github.EventTypeHeader, test.event)
+			req.Header.Set(github.SHA256SignatureHeader, sign(test.secret, test.body))
+
+			rw := httptest.NewRecorder()
+
+			proc, err := newProcessor(cfg, newRepoLocks(), nil)
+			require.NoError(t, err)
+
+			newWebhook(t.Context(), func() *processor { return proc }).ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected, rw.Code)
+		})
+	}
+}
+
+func TestWebhook_ServeHTTP_targets(t *testing.T) {
+	cfg := conf.Configuration{
+		Github: conf.Github{User: "traefik"},
+		Server: conf.Server{WebhookSecret: "secret"},
+	}
+
+	testCases := []struct {
+		desc      string
+		event     string
+		body      string
+		signature string
+		code      int
+		expected  []eventTarget
+	}{
+		{
+			desc:      "invalid signature",
+			event:     "pull_request",
+			body:      `{"action": "opened", "number": 1, "repository": {"full_name": "traefik/traefik"}}`,
+			signature: sign("foo", `{"action": "opened", "number": 1, "repository": {"full_name": "traefik/traefik"}}`),
+			code:      http.StatusUnauthorized,
+		},
+		{
+			desc:      "missing signature",
+			event:     "pull_request",
+			body:      `{"action": "opened", "number": 1, "repository": {"full_name": "traefik/traefik"}}`,
+			signature: "",
+			code:      http.StatusUnauthorized,
+		},
+		{
+			desc:     "pull request",
+			event:    "pull_request",
+			body:     `{"action": "opened", "number": 1, "repository": {"full_name": "traefik/traefik"}}`,
+			code:     http.StatusAccepted,
+			expected: []eventTarget{{fullName: "traefik/traefik", numbers: []int{1}}},
+		},
+		{
+			desc:     "pull request labeled",
+			event:    "pull_request",
+			body:     `{"action": "labeled", "number": 2, "label": {"name": "status/3-needs-merge"}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:     http.StatusAccepted,
+			expected: []eventTarget{{fullName: "traefik/traefik", numbers: []int{2}}},
+		},
+		{
+			desc:     "pull request review",
+			event:    "pull_request_review",
+			body:     `{"action": "submitted", "pull_request": {"number": 3}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:     http.StatusAccepted,
+			expected: []eventTarget{{fullName: "traefik/traefik", numbers: []int{3}}},
+		},
+		{
+			desc:     "completed check suite",
+			event:    "check_suite",
+			body:     `{"action": "completed", "check_suite": {"head_sha": "abc", "pull_requests": [{"number": 4}]}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:     http.StatusAccepted,
+			expected: []eventTarget{{fullName: "traefik/traefik", numbers: []int{4}}},
+		},
+		{
+			desc:     "completed check run",
+			event:    "check_run",
+			body:     `{"action": "completed", "check_run": {"head_sha": "abc", "pull_requests": [{"number": 5}]}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:     http.StatusAccepted,
+			expected: []eventTarget{{fullName: "traefik/traefik", numbers: []int{5}}},
+		},
+		{
+			desc:  "check run in progress",
+			event: "check_run",
+			body:  `{"action": "created", "check_run": {"head_sha": "abc", "status": "in_progress", "pull_requests": [{"number": 5}]}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:  http.StatusNoContent,
+		},
+		{
+			desc:  "check suite requested",
+			event: "check_suite",
+			body:  `{"action": "requested", "check_suite": {"head_sha": "abc"}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:  http.StatusNoContent,
+		},
+		{
+			desc:     "status",
+			event:    "status",
+			body:     `{"sha": "abc", "state": "failure", "repository": {"full_name": "traefik/traefik"}}`,
+			code:     http.StatusAccepted,
+			expected: []eventTarget{{fullName: "traefik/traefik", sha: "abc"}},
+		},
+		{
+			desc:  "pending status",
+			event: "status",
+			body:  `{"sha": "abc", "state": "pending", "repository": {"full_name": "traefik/traefik"}}`,
+			code:  http.StatusNoContent,
+		},
+		{
+			desc:  "repository label",
+			event: "label",
+			body:  `{"action": "edited", "label": {"name": "status/3-needs-merge"}, "repository": {"full_name": "traefik/traefik"}}`,
+			code:  http.StatusNoContent,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			signature := test.signature
+			if signature == "" && test.code != http.StatusUnauthorized {
+				signature = sign("secret", test.body)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(github.EventTypeHeader, test.event)
+
+			if signature != "" {
+				req.Header.Set(github.SHA256SignatureHeader, signature)
+			}
+
+			proc, err := newProcessor(cfg, newRepoLocks(), nil)
+			require.NoError(t, err)
+
+			var (
+				mu      sync.Mutex
+				targets []eventTarget
+			)
+
+			w := newWebhook(t.Context(), func() *processor { return proc })
+			w.handle = func(_ context.Context, _ *processor, target eventTarget) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				targets = append(targets, target)
+			}
+
+			rw := httptest.NewRecorder()
+			w.ServeHTTP(rw, req)
+			w.Wait()
+
+			assert.Equal(t, test.code, rw.Code)
+			assert.Equal(t, test.expected, targets)
+		})
+	}
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhook_ServeHTTP_disabled(t *testing.T) {
+	proc, err := newProcessor(conf.Configuration{Github: conf.Github{User: "traefik"}}, newRepoLocks(), nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{}`))
+	req.Header.Set(github.EventTypeHeader, "ping")
+
+	rw := httptest.NewRecorder()
+	newWebhook(t.Context(), func() *processor { return proc }).ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+}
+
+func TestWebhook_schedule(t *testing.T) {
+	proc, err := newProcessor(conf.Configuration{Github: conf.Github{User: "traefik"}}, newRepoLocks(), nil)
+	require.NoError(t, err)
+
+	w := newWebhook(t.Context(), func() *processor { return proc })
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var (
+		mu      sync.Mutex
+		targets []eventTarget
+	)
+
+	w.handle = func(_ context.Context, _ *processor, target eventTarget) {
+		mu.Lock()
+		targets = append(targets, target)
+		first := len(targets) == 1
+		mu.Unlock()
+
+		if first {
+			close(started)
+			<-release
+		}
+	}
+
+	w.schedule(t.Context(), proc, eventTarget{fullName: "traefik/traefik", numbers: []int{1}})
+
+	<-started
+
+	// queued behind the processing in progress, and coalesced.
+	w.schedule(t.Context(), proc, eventTarget{fullName: "traefik/traefik", numbers: []int{3}})
+	w.schedule(t.Context(), proc, eventTarget{fullName: "traefik/traefik", numbers: []int{2}})
+	w.schedule(t.Context(), proc, eventTarget{fullName: "traefik/traefik", numbers: []int{3}})
+
+	close(release)
+
+	w.Wait()
+
+	expected := []eventTarget{
+		{fullName: "traefik/traefik", numbers: []int{1}},
+		{fullName: "traefik/traefik", numbers: []int{2, 3}},
+	}
+
+	assert.Equal(t, expected, targets)
+	assert.Empty(t, w.runs)
+}
+
+func Test_mergeTargets(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		a, b     eventTarget
+		expected eventTarget
+	}{
+		{
+			desc:     "numbers",
+			a:        eventTarget{fullName: "traefik/traefik", numbers: []int{2, 1}},
+			b:        eventTarget{fullName: "traefik/traefik", numbers: []int{1, 3}},
+			expected: eventTarget{fullName: "traefik/traefik", numbers: []int{1, 2, 3}},
+		},
+		{
+			desc:     "commit",
+			a:        eventTarget{fullName: "traefik/traefik", numbers: []int{1}},
+			b:        eventTarget{fullName: "traefik/traefik", sha: "abc"},
+			expected: eventTarget{fullName: "traefik/traefik"},
+		},
+		{
+			desc:     "closed pull request",
+			a:        eventTarget{fullName: "traefik/traefik"},
+			b:        eventTarget{fullName: "traefik/traefik", numbers: []int{1}},
+			expected: eventTarget{fullName: "traefik/traefik"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, mergeTargets(test.a, test.b))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Package auth provides the authentication as a GitHub App.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+	"golang.org/x/oauth2"
+)
+
+// tokenEarlyExpiry an installation token is valid 1 hour, it's refreshed when it expires in less than 30 minutes.
+// A token is always valid long enough to clone, update and push a pull request.
+const tokenEarlyExpiry = 30 * time.Minute
+
+// App a GitHub App.
+// It provides the installation tokens of the owners.
+type App struct {
+	// installations the configured installations by owner (lower case).
+	installations map[string]int64
+
+	sign      func(now time.Time) (string, error)
+	gitHubURL string
+	timeout   time.Duration
+	metrics   *metrics.Metrics
+
+	mu      sync.Mutex
+	sources map[string]oauth2.TokenSource
+}
+
+// NewApp creates a new GitHub App from the GitHub configuration.
+// The timeout limits each request to the API (no timeout if zero).
+// The requests are instrumented by the metrics (can be nil), labeled with the owner of the installation.
+func NewApp(cfg conf.Github, timeout time.Duration, recorder *metrics.Metrics) (*App, error) {
+	key, err := loadPrivateKey(cfg.App.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the GitHub App private key: %w", err)
+	}
+
+	owners := cfg.GetOwners()
+
+	installations := make(map[string]int64)
+	for _, owner := range owners {
+		if owner.InstallationID != 0 {
+			installations[strings.ToLower(owner.Name)] = owner.InstallationID
+		}
+	}
+
+	// the installation of the app configuration is restricted to a single owner.
+	if cfg.App.InstallationID != 0 && len(owners) == 1 {
+		installations[strings.ToLower(owners[0].Name)] = cfg.App.InstallationID
+	}
+
+	return &App{
+		installations: installations,
+		sign: func(now time.Time) (string, error) {
+			return signJWT(cfg.App.ID, key, now)
+		},
+		gitHubURL: cfg.URL,
+		timeout:   timeout,
+		metrics:   recorder,
+		sources:   make(map[string]oauth2.TokenSource),
+	}, nil
+}
+
+// TokenSource gets the source of the installation tokens of an owner (organization or user).
+// The tokens are cached and refreshed before their expiry.
+func (a *App) TokenSource(owner string) oauth2.TokenSource {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	src, ok := a.sources[owner]
+	if !ok {
+		src = oauth2.ReuseTokenSourceWithExpiry(nil, &installationTokenSource{
+			client:         a.newClient(owner),
+			owner:          owner,
+			installationID: a.installations[strings.ToLower(owner)],
+		}, tokenEarlyExpiry)
+
+		a.sources[owner] = src
+	}
+
+	return src
+}
+
+// newClient creates a client authenticated as the app, to mint the tokens of an owner.
+func (a *App) newClient(owner string) *github.Client {
+	transport := &jwtTransport{
+		sign: a.sign,
+		base: a.metrics.Transport(http.DefaultTransport, owner),
+	}
+
+	client := github.NewClient(&http.Client{Transport: transport, Timeout: a.timeout})
+
+	if a.gitHubURL != "" {
+		baseURL, err := url.Parse(a.gitHubURL)
+		if err == nil {
+			client.BaseURL = baseURL
+		}
+	}
+
+	return client
+}
+
+// installationTokenSource mints installation tokens.
+type installationTokenSource struct {
+	// client is authenticated as the app.
+	client *github.Client
+	owner  string
+
+	// installationID is only accessed by Token: the calls are serialized by the oauth2.ReuseTokenSource.
+	installationID int64
+}
+
+// findInstallation finds the installation of the app for the owner.
+func (s *installationTokenSource) findInstallation(ctx context.Context) (int64, error) {
+	owner := s.owner
+
+	installation, _, err := s.client.Apps.FindOrganizationInstallation(ctx, owner)
+	if err == nil {
+		return installation.GetID(), nil
+	}
+
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusNotFound {
+		return 0, fmt.Errorf("failed to find the installation of the organization %s: %w", owner, err)
+	}
+
+	installation, _, err = s.client.Apps.FindUserInstallation(ctx, owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the installation of %s: %w", owner, err)
+	}
+
+	return installation.GetID(), nil
+}
+
+// Token mints a new installation token.
+// Each request is limited by the timeout of the client.
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	ctx := context.Background()
+
+	if s.installationID == 0 {
+		id, err := s.findInstallation(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.installationID = id
+	}
+
+	token, _, err := s.client.Apps.CreateInstallationToken(ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an installation token for %s: %w", s.owner, err)
+	}
+
+	log.Debug().Str("owner", s.owner).Time("expiry", token.GetExpiresAt().Time).Msg("New installation token.")
+
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		Expiry:      token.GetExpiresAt().Time,
+	}, nil
+}
+
+// jwtTransport authenticates the requests as the app.
+type jwtTransport struct {
+	sign func(now time.Time) (string, error)
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.sign(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// a RoundTripper must not modify the request.
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(clone)
+}
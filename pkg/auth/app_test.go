@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+)
+
+func Test_signJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	token, err := signJWT(123, key, now)
+	require.NoError(t, err)
+
+	claims := verifyJWT(t, &key.PublicKey, token)
+
+	assert.Equal(t, jwtClaims{
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(9 * time.Minute).Unix(),
+		Issuer:    "123",
+	}, claims)
+}
+
+func Test_signJWT_vector(t *testing.T) {
+	key, err := loadPrivateKey(filepath.Join("testdata", "app.pem"))
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	token, err := signJWT(123, key, now)
+	require.NoError(t, err)
+
+	// signed by OpenSSL: openssl dgst -sha256 -sign testdata/app.pem
+	expected := "eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9." +
+		"eyJpYXQiOjE3MDQwNjcxNDAsImV4cCI6MTcwNDA2Nzc0MCwiaXNzIjoiMTIzIn0." +
+		"hDERvy2Swsb-3iopxyrz27oPS1bTEEuWgKqDqVtv1K4Rd-gJ6wDs3mhZcaMrOWEl0qtOZ74oZ-LefwvuiKNfEpFpdz2dn6eXmYI4-pHRuQoLH3KM-qQtuN7hMuq5ol5NP-Ko" +
+		"ZqvFJduUH37SGBhpgz1feCEsWqsAUgqsCkVvb1kLOOexl9igTNKqV1rZ-aAOcPMyIg9thmKKTg14KqXfP8teLsAW10-kxpwLegbioUECmS8RXUvlmGq28KL4Y_wqmj4_cVrA" +
+		"bbGVMBj--Wegrvd4Evs8FstaxC-8R5gsDCo7S_gFYRyLsfKgW82oRH5op6kl6jjBSoN0weay16jkcg"
+
+	assert.Equal(t, expected, token)
+}
+
+func Test_loadPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		block    *pem.Block
+		errorMsg string
+	}{
+		{
+			desc:  "PKCS#1",
+			block: &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		},
+		{
+			desc:  "PKCS#8",
+			block: &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8},
+		},
+		{
+			desc:     "invalid key",
+			block:    &pem.Block{Type: "PRIVATE KEY", Bytes: []byte("foo")},
+			errorMsg: "failed to parse the private key",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			filename := filepath.Join(t.TempDir(), "key.pem")
+			require.NoError(t, os.WriteFile(filename, pem.EncodeToMemory(test.block), 0o600))
+
+			loaded, err := loadPrivateKey(filename)
+
+			if test.errorMsg != "" {
+				require.ErrorContains(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, key.Equal(loaded))
+		})
+	}
+}
+
+func TestApp_TokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var minted atomic.Int32
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /orgs/foo/installation", func(rw http.ResponseWriter, req *http.Request) {
+		assertAppAuth(t, &key.PublicKey, req)
+
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte(`{"message": "Not Found"}`))
+	})
+
+	mux.HandleFunc("GET /users/foo/installation", func(rw http.ResponseWriter, req *http.Request) {
+		assertAppAuth(t, &key.PublicKey, req)
+
+		_, _ = rw.Write([]byte(`{"id": 42}`))
+	})
+
+	mux.HandleFunc("POST /app/installations/42/access_tokens", func(rw http.ResponseWriter, req *http.Request) {
+		assertAppAuth(t, &key.PublicKey, req)
+
+		minted.Add(1)
+
+		_ = json.NewEncoder(rw).Encode(map[string]any{
+			"token":      "ghs_token",
+			"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	filename := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600))
+
+	recorder := metrics.New()
+
+	cfg := conf.Github{
+		User: "foo",
+		URL:  server.URL + "/",
+		App:  conf.GithubApp{ID: 123, PrivateKeyFile: filename},
+	}
+
+	app, err := NewApp(cfg, time.Minute, recorder)
+	require.NoError(t, err)
+
+	for range 2 {
+		token, err := app.TokenSource("foo").Token()
+		require.NoError(t, err)
+
+		assert.Equal(t, "ghs_token", token.AccessToken)
+	}
+
+	// the token is cached.
+	assert.Equal(t, int32(1), minted.Load())
+
+	// the requests of the app are instrumented.
+	rw := httptest.NewRecorder()
+	recorder.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.Contains(t, rw.Body.String(), `lobicornis_github_requests_total{code="404"} 1`)
+	assert.Contains(t, rw.Body.String(), `lobicornis_github_requests_total{code="200"} 2`)
+}
+
+func TestApp_TokenSource_installations(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /app/installations/{id}/access_tokens", func(rw http.ResponseWriter, req *http.Request) {
+		assertAppAuth(t, &key.PublicKey, req)
+
+		if req.PathValue("id") == "8" {
+			// slower than the timeout.
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		_ = json.NewEncoder(rw).Encode(map[string]any{
+			"token":      "ghs_token_" + req.PathValue("id"),
+			"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	filename := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600))
+
+	cfg := conf.Github{
+		User: "foo",
+		URL:  server.URL + "/",
+		App:  conf.GithubApp{ID: 123, PrivateKeyFile: filename},
+		Owners: []conf.Owner{
+			{Name: "foo", InstallationID: 7},
+			{Name: "bar", InstallationID: 8},
+		},
+	}
+
+	app, err := NewApp(cfg, 100*time.Millisecond, nil)
+	require.NoError(t, err)
+
+	// the installation of the owner: no lookup.
+	token, err := app.TokenSource("Foo").Token()
+	require.NoError(t, err)
+
+	assert.Equal(t, "ghs_token_7", token.AccessToken)
+
+	// the requests are limited by the timeout.
+	_, err = app.TokenSource("bar").Token()
+	require.ErrorContains(t, err, "failed to create an installation token for bar")
+}
+
+func assertAppAuth(t *testing.T, key *rsa.PublicKey, req *http.Request) {
+	t.Helper()
+
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	require.True(t, ok)
+
+	claims := verifyJWT(t, key, token)
+
+	assert.Equal(t, "123", claims.Issuer)
+}
+
+func verifyJWT(t *testing.T, key *rsa.PublicKey, token string) jwtClaims {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alg": "RS256", "typ": "JWT"}`, string(header))
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+
+	var claims jwtClaims
+	require.NoError(t, json.Unmarshal(payload, &claims))
+
+	return claims
+}
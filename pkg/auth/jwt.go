@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// jwtLifetime the lifetime of the JWT (GitHub accepts 10 minutes at most).
+const jwtLifetime = 9 * time.Minute
+
+// jwtClockDrift allows a drift between the local clock and the GitHub clock.
+const jwtClockDrift = time.Minute
+
+// jwtHeader the encoded header of the JWT: {"alg":"RS256","typ":"JWT"}.
+const jwtHeader = "eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9"
+
+type jwtClaims struct {
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	Issuer    string `json:"iss"`
+}
+
+// signJWT creates a JWT signed with RS256 (RFC 7518) to authenticate as a GitHub App.
+// The signature is deterministic (RSASSA-PKCS1-v1_5): the same inputs always produce the same JWT.
+func signJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	claims, err := json.Marshal(jwtClaims{
+		IssuedAt:  now.Add(-jwtClockDrift).Unix(),
+		ExpiresAt: now.Add(jwtLifetime).Unix(),
+		Issuer:    strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the JWT: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// loadPrivateKey loads a PEM encoded RSA private key (PKCS#1 or PKCS#8).
+func loadPrivateKey(filename string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", filename)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key %s: %w", filename, err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not a RSA key")
+	}
+
+	return key, nil
+}
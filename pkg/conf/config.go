@@ -3,7 +3,12 @@ package conf
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -11,21 +16,165 @@ import (
 
 // Configuration the global configuration.
 type Configuration struct {
+	// Provider the platform hosting the repositories: github, gitlab, or gitea.
+	Provider     string                 `yaml:"provider,omitempty"`
 	Github       Github                 `yaml:"github"`
+	GitLab       GitLab                 `yaml:"gitlab,omitempty"`
+	Gitea        Gitea                  `yaml:"gitea,omitempty"`
 	Git          Git                    `yaml:"git"`
 	Server       Server                 `yaml:"server"`
 	Markers      Markers                `yaml:"markers"`
 	Retry        Retry                  `yaml:"retry"`
+	Timeouts     Timeouts               `yaml:"timeouts"`
 	Default      RepoConfig             `yaml:"default"`
 	Extra        Extra                  `yaml:"extra"`
+	Profiles     map[string]*RepoConfig `yaml:"profiles,omitempty"`
 	Repositories map[string]*RepoConfig `yaml:"repositories,omitempty"`
 }
 
 // Github the GitHub configuration.
 type Github struct {
-	User  string `yaml:"user,omitempty"`
+	User  string    `yaml:"user,omitempty"`
+	Token string    `yaml:"token,omitempty"`
+	URL   string    `yaml:"url,omitempty"`
+	App   GithubApp `yaml:"app,omitempty"`
+
+	// Owners the users or organizations managed by the bot, in addition to the user.
+	Owners []Owner `yaml:"owners,omitempty"`
+	// Include the repositories (names or patterns) managed by the bot, all the repositories of the owners if empty.
+	Include []string `yaml:"include,omitempty"`
+	// Exclude the repositories (names or patterns) ignored by the bot.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Owner a user or an organization managed by the bot.
+type Owner struct {
+	Name string `yaml:"name"`
+	// Token the GitHub token of the owner, the global token is used if empty.
 	Token string `yaml:"token,omitempty"`
+	// InstallationID the installation of the GitHub App for the owner, found from the owner if empty.
+	InstallationID int64 `yaml:"installationId,omitempty"`
+}
+
+// GetOwners gets the owners managed by the bot: the user and the owners.
+func (g *Github) GetOwners() []Owner {
+	var owners []Owner
+	if g.User != "" {
+		owners = append(owners, Owner{Name: g.User})
+	}
+
+	for _, owner := range g.Owners {
+		if strings.EqualFold(owner.Name, g.User) {
+			// the user defined as an owner: only the token and the installation are relevant.
+			owners[0].Token = owner.Token
+			owners[0].InstallationID = owner.InstallationID
+
+			continue
+		}
+
+		owners = append(owners, owner)
+	}
+
+	return owners
+}
+
+// GetOwner gets an owner by name (case-insensitive).
+func (g *Github) GetOwner(name string) (Owner, bool) {
+	for _, owner := range g.GetOwners() {
+		if strings.EqualFold(owner.Name, name) {
+			return owner, true
+		}
+	}
+
+	return Owner{}, false
+}
+
+// IsManaged checks if a repository is managed by the bot:
+// the owner is managed, the repository is included (if the include list is defined), and not excluded.
+func (g *Github) IsManaged(fullName string) bool {
+	owner, _, _ := strings.Cut(fullName, "/")
+	if _, ok := g.GetOwner(owner); !ok {
+		return false
+	}
+
+	return isIncluded(g.Include, g.Exclude, fullName)
+}
+
+// IsManaged checks if a project is managed by the bot:
+// the project belongs to a group (or a subgroup) of the groups, the project is included (if the include list is defined), and not excluded.
+func (g *GitLab) IsManaged(path string) bool {
+	inGroup := slices.ContainsFunc(g.Groups, func(group string) bool {
+		return len(path) > len(group) && strings.EqualFold(path[:len(group)+1], group+"/")
+	})
+
+	return inGroup && isIncluded(g.Include, g.Exclude, path)
+}
+
+// IsManaged checks if a repository is managed by the bot:
+// the owner is managed, the repository is included (if the include list is defined), and not excluded.
+func (g *Gitea) IsManaged(fullName string) bool {
+	owner, _, _ := strings.Cut(fullName, "/")
+	if !slices.ContainsFunc(g.Owners, func(o string) bool { return strings.EqualFold(o, owner) }) {
+		return false
+	}
+
+	return isIncluded(g.Include, g.Exclude, fullName)
+}
+
+// IsManaged checks if a repository (a project for GitLab) is managed by the bot, with the owners and the include/exclude lists of the provider.
+func (c *Configuration) IsManaged(fullName string) bool {
+	switch c.Provider {
+	case ProviderGitLab:
+		return c.GitLab.IsManaged(fullName)
+	case ProviderGitea:
+		return c.Gitea.IsManaged(fullName)
+	default:
+		return c.Github.IsManaged(fullName)
+	}
+}
+
+// isIncluded checks if a repository is included (if the include list is defined), and not excluded.
+func isIncluded(include, exclude []string, fullName string) bool {
+	if len(include) > 0 && !slices.ContainsFunc(include, func(key string) bool { return matchRepoKey(key, fullName) }) {
+		return false
+	}
+
+	return !slices.ContainsFunc(exclude, func(key string) bool { return matchRepoKey(key, fullName) })
+}
+
+// GitLab the GitLab configuration.
+type GitLab struct {
+	// URL the URL of the GitLab instance, gitlab.com if empty.
+	URL   string `yaml:"url,omitempty"`
+	Token string `yaml:"token,omitempty"`
+	// Groups the groups managed by the bot, with their subgroups.
+	Groups []string `yaml:"groups,omitempty"`
+	// CloneRebase if true, the merge requests are rebased with a clone instead of the API.
+	CloneRebase bool `yaml:"cloneRebase,omitempty"`
+	// Include the projects (paths or patterns) managed by the bot, all the projects of the groups if empty.
+	Include []string `yaml:"include,omitempty"`
+	// Exclude the projects (paths or patterns) ignored by the bot.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Gitea the Gitea (or Forgejo) configuration.
+type Gitea struct {
+	// URL the URL of the Gitea instance.
 	URL   string `yaml:"url,omitempty"`
+	Token string `yaml:"token,omitempty"`
+	// Owners the organizations or users managed by the bot.
+	Owners []string `yaml:"owners,omitempty"`
+	// Include the repositories (names or patterns) managed by the bot, all the repositories of the owners if empty.
+	Include []string `yaml:"include,omitempty"`
+	// Exclude the repositories (names or patterns) ignored by the bot.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// GithubApp the GitHub App configuration.
+type GithubApp struct {
+	ID             int64  `yaml:"id,omitempty"`
+	PrivateKeyFile string `yaml:"privateKeyFile,omitempty"`
+	InstallationID int64  `yaml:"installationId,omitempty"`
 }
 
 // Git the Git configuration.
@@ -33,11 +182,15 @@ type Git struct {
 	Email    string `yaml:"email,omitempty"`
 	UserName string `yaml:"userName,omitempty"`
 	SSH      bool   `yaml:"ssh,omitempty"`
+	CacheDir string `yaml:"cacheDir,omitempty"`
 }
 
 // Server the server configuration.
 type Server struct {
-	Port int `yaml:"port"`
+	Port          int           `yaml:"port"`
+	WebhookSecret string        `yaml:"webhookSecret,omitempty"`
+	Interval      time.Duration `yaml:"interval,omitempty"`
+	Jitter        time.Duration `yaml:"jitter,omitempty"`
 }
 
 // Markers the markers configuration.
@@ -50,6 +203,30 @@ type Markers struct {
 	NeedHumanMerge    string `yaml:"needHumanMerge,omitempty"`
 	MergeNoRebase     string `yaml:"mergeNoRebase,omitempty"`
 	NoMerge           string `yaml:"noMerge,omitempty"`
+	// Priorities the weights of the priority labels: the pull requests with the highest weight are merged first.
+	// The pull requests without a priority label have a weight of 0.
+	Priorities map[string]int `yaml:"priorities,omitempty"`
+}
+
+// GetPriority gets the priority of a pull request from its labels: the highest weight of its priority labels.
+func (m Markers) GetPriority(labels ...string) int {
+	var priority int
+
+	var found bool
+	for _, lbl := range labels {
+		for name, weight := range m.Priorities {
+			if !strings.EqualFold(name, lbl) {
+				continue
+			}
+
+			if !found || weight > priority {
+				priority = weight
+				found = true
+			}
+		}
+	}
+
+	return priority
 }
 
 // Retry the retry configuration.
@@ -60,23 +237,61 @@ type Retry struct {
 	OnStatuses  bool          `yaml:"onStatuses,omitempty"`
 }
 
+// Timeouts the timeouts of the operations, a timeout is disabled if zero.
+type Timeouts struct {
+	Clone  time.Duration `yaml:"clone,omitempty"`
+	Fetch  time.Duration `yaml:"fetch,omitempty"`
+	Rebase time.Duration `yaml:"rebase,omitempty"`
+	Push   time.Duration `yaml:"push,omitempty"`
+	API    time.Duration `yaml:"api,omitempty"`
+
+	// PullRequest the deadline of the processing of a pull request (all the operations).
+	PullRequest time.Duration `yaml:"pullRequest,omitempty"`
+
+	// Shutdown the delay given to the processing in progress to finish, before its cancellation, on SIGTERM. (server mode)
+	Shutdown time.Duration `yaml:"shutdown,omitempty"`
+}
+
 // Extra the extra configuration.
 type Extra struct {
 	DryRun   bool   `yaml:"dryRun,omitempty"`
 	LogLevel string `yaml:"logLevel,omitempty"`
+	Workers  int    `yaml:"workers,omitempty"`
+
+	// RepoConfigFile if true, the configuration file of the repository (.github/lobicornis.yml) is used.
+	RepoConfigFile bool `yaml:"repoConfigFile,omitempty"`
+
+	// StatusContext the context of the commit status published by the bot, disabled if empty.
+	StatusContext string `yaml:"statusContext,omitempty"`
 }
 
 // Load loads the configuration.
 func Load(filename string) (Configuration, error) {
+	return load(filename, false)
+}
+
+// Check loads the configuration with a strict decoding: the unknown fields are rejected.
+func Check(filename string) (Configuration, error) {
+	return load(filename, true)
+}
+
+func load(filename string, strict bool) (Configuration, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return Configuration{}, err
 	}
 
 	cfg := Configuration{
+		Provider: ProviderGitHub,
 		Github: Github{
 			Token: os.Getenv("GITHUB_TOKEN"),
 		},
+		GitLab: GitLab{
+			Token: os.Getenv("GITLAB_TOKEN"),
+		},
+		Gitea: Gitea{
+			Token: os.Getenv("GITEA_TOKEN"),
+		},
 		Server: Server{
 			Port: 80,
 		},
@@ -93,23 +308,55 @@ func Load(filename string) (Configuration, error) {
 		Retry: Retry{
 			Interval: 1 * time.Minute,
 		},
+		Timeouts: Timeouts{
+			Clone:       5 * time.Minute,
+			Fetch:       2 * time.Minute,
+			Rebase:      2 * time.Minute,
+			Push:        2 * time.Minute,
+			API:         30 * time.Second,
+			PullRequest: 15 * time.Minute,
+			Shutdown:    1 * time.Minute,
+		},
 		Default: RepoConfig{
-			MergeMethod:       String("squash"),
-			MinLightReview:    Int(0),
-			MinReview:         Int(1),
-			NeedMilestone:     Bool(true),
-			CheckNeedUpToDate: Bool(false),
-			ForceNeedUpToDate: Bool(true),
-			AddErrorInComment: Bool(false),
-			CommitMessage:     String("empty"),
+			MergeMethod:         String("squash"),
+			MinLightReview:      Int(0),
+			MinReview:           Int(1),
+			NeedMilestone:       Bool(true),
+			CheckNeedUpToDate:   Bool(false),
+			ForceNeedUpToDate:   Bool(true),
+			AddErrorInComment:   Bool(false),
+			CommitMessage:       String("empty"),
+			UseProtectionChecks: Bool(false),
+			MergeTrainSize:      Int(0),
 		},
 		Extra: Extra{
 			LogLevel: "info",
 			DryRun:   true,
+			Workers:  1,
 		},
 		Repositories: map[string]*RepoConfig{},
 	}
-	err = yaml.NewDecoder(file).Decode(&cfg)
+	defer func() { _ = file.Close() }()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(strict)
+
+	err = decoder.Decode(&cfg)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	err = applyEnv(&cfg, os.LookupEnv)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	err = normalizeRepoKeys(cfg.Repositories)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	err = applyProfiles(cfg)
 	if err != nil {
 		return Configuration{}, err
 	}
@@ -119,7 +366,7 @@ func Load(filename string) (Configuration, error) {
 			continue
 		}
 
-		applyDefault(config, cfg)
+		applyDefault(config, cfg.Default)
 	}
 
 	err = validate(cfg)
@@ -130,43 +377,121 @@ func Load(filename string) (Configuration, error) {
 	return cfg, nil
 }
 
-func applyDefault(config *RepoConfig, cfg Configuration) {
+// applyProfiles applies the profiles to the repositories: the fields not defined by a repository are inherited from its profile.
+// A profile can inherit from another profile.
+func applyProfiles(cfg Configuration) error {
+	for name, profile := range cfg.Profiles {
+		if profile == nil {
+			cfg.Profiles[name] = &RepoConfig{}
+		}
+	}
+
+	resolved := make(map[string]bool)
+
+	var resolve func(name string, chain []string) (*RepoConfig, error)
+	resolve = func(name string, chain []string) (*RepoConfig, error) {
+		profile := cfg.Profiles[name]
+		if profile == nil {
+			return nil, fmt.Errorf("unknown profile %q", name)
+		}
+
+		if slices.Contains(chain, name) {
+			return nil, fmt.Errorf("circular inheritance: %s", strings.Join(append(chain, name), " -> "))
+		}
+
+		if !resolved[name] && profile.Profile != "" {
+			parent, err := resolve(profile.Profile, append(chain, name))
+			if err != nil {
+				return nil, err
+			}
+
+			applyDefault(profile, *parent)
+		}
+
+		resolved[name] = true
+
+		return profile, nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		_, err := resolve(name, nil)
+		if err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+
+	for name, config := range cfg.Repositories {
+		if config == nil || config.Profile == "" {
+			continue
+		}
+
+		profile, err := resolve(config.Profile, nil)
+		if err != nil {
+			return fmt.Errorf("repositories.%s.profile: %w", name, err)
+		}
+
+		applyDefault(config, *profile)
+	}
+
+	return nil
+}
+
+func applyDefault(config *RepoConfig, defaults RepoConfig) {
 	if config.CheckNeedUpToDate == nil {
-		config.CheckNeedUpToDate = cfg.Default.CheckNeedUpToDate
+		config.CheckNeedUpToDate = defaults.CheckNeedUpToDate
 	}
 
 	if config.ForceNeedUpToDate == nil {
-		config.ForceNeedUpToDate = cfg.Default.ForceNeedUpToDate
+		config.ForceNeedUpToDate = defaults.ForceNeedUpToDate
 	}
 
 	if config.MergeMethod == nil {
-		config.MergeMethod = cfg.Default.MergeMethod
+		config.MergeMethod = defaults.MergeMethod
 	}
 
 	if config.MinLightReview == nil {
-		config.MinLightReview = cfg.Default.MinLightReview
+		config.MinLightReview = defaults.MinLightReview
 	}
 
 	if config.MinReview == nil {
-		config.MinReview = cfg.Default.MinReview
+		config.MinReview = defaults.MinReview
 	}
 
 	if config.NeedMilestone == nil {
-		config.NeedMilestone = cfg.Default.NeedMilestone
+		config.NeedMilestone = defaults.NeedMilestone
 	}
 
 	if config.AddErrorInComment == nil {
-		config.AddErrorInComment = cfg.Default.AddErrorInComment
+		config.AddErrorInComment = defaults.AddErrorInComment
 	}
 
 	if config.CommitMessage == nil {
-		config.CommitMessage = cfg.Default.CommitMessage
+		config.CommitMessage = defaults.CommitMessage
+	}
+
+	if config.RequiredChecks == nil {
+		config.RequiredChecks = defaults.RequiredChecks
+	}
+
+	if config.IgnoredChecks == nil {
+		config.IgnoredChecks = defaults.IgnoredChecks
+	}
+
+	if config.UseProtectionChecks == nil {
+		config.UseProtectionChecks = defaults.UseProtectionChecks
+	}
+
+	if config.MergeTrainSize == nil {
+		config.MergeTrainSize = defaults.MergeTrainSize
+	}
+
+	if config.ProtectedBranches == nil {
+		config.ProtectedBranches = defaults.ProtectedBranches
 	}
 }
 
 func validate(cfg Configuration) error {
 	fields := map[string]string{
-		"github.user":               cfg.Github.User,
 		"git.email":                 cfg.Git.Email,
 		"git.userName":              cfg.Git.UserName,
 		"markers.needMerge":         cfg.Markers.NeedMerge,
@@ -184,21 +509,321 @@ func validate(cfg Configuration) error {
 		}
 	}
 
-	if cfg.Default.GetMinReview() < 0 {
-		return errors.New("default.minReview is invalid")
+	err := validateProvider(cfg)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{})
+	for i, owner := range cfg.Github.Owners {
+		if owner.Name == "" || strings.Contains(owner.Name, "/") {
+			return fmt.Errorf("github.owners[%d].name is invalid: %q", i, owner.Name)
+		}
+
+		if _, ok := names[strings.ToLower(owner.Name)]; ok {
+			return fmt.Errorf("github.owners[%d].name is duplicated: %q", i, owner.Name)
+		}
+
+		names[strings.ToLower(owner.Name)] = struct{}{}
+
+		if owner.InstallationID < 0 || (owner.InstallationID > 0 && cfg.Github.App.ID == 0) {
+			return fmt.Errorf("github.owners[%d].installationId is invalid: the installation requires github.app", i)
+		}
+	}
+
+	err = validateRepoKeys("github", cfg.Github.Include, cfg.Github.Exclude)
+	if err != nil {
+		return err
 	}
 
-	if cfg.Default.GetMinLightReview() < 0 {
-		return errors.New("default.minLightReview is invalid")
+	if cfg.Github.App.ID < 0 || cfg.Github.App.InstallationID < 0 {
+		return errors.New("github.app is invalid")
 	}
 
-	if cfg.Default.GetMergeMethod() == "" {
-		return errors.New("default.mergeMethod is required")
+	if cfg.Github.App.ID > 0 && cfg.Github.App.PrivateKeyFile == "" {
+		return errors.New("github.app.privateKeyFile is required")
+	}
+
+	// an installation belongs to a single owner.
+	if cfg.Github.App.InstallationID > 0 && len(cfg.Github.GetOwners()) > 1 {
+		return errors.New("github.app.installationId requires a single owner: use github.owners[].installationId")
+	}
+
+	if cfg.Github.URL != "" {
+		u, err := url.Parse(cfg.Github.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("github.url is invalid: %q", cfg.Github.URL)
+		}
+	}
+
+	for name := range cfg.Markers.Priorities {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("markers.priorities: the label name is required")
+		}
+	}
+
+	if cfg.Retry.Number < 0 {
+		return errors.New("retry.number is invalid")
+	}
+
+	if cfg.Retry.Interval < 0 {
+		return errors.New("retry.interval is invalid")
+	}
+
+	if cfg.Retry.Number > 0 && cfg.Markers.MergeRetryPrefix == "" {
+		return errors.New("markers.mergeRetryPrefix is required")
+	}
+
+	timeouts := []struct {
+		field string
+		value time.Duration
+	}{
+		{field: "timeouts.clone", value: cfg.Timeouts.Clone},
+		{field: "timeouts.fetch", value: cfg.Timeouts.Fetch},
+		{field: "timeouts.rebase", value: cfg.Timeouts.Rebase},
+		{field: "timeouts.push", value: cfg.Timeouts.Push},
+		{field: "timeouts.api", value: cfg.Timeouts.API},
+		{field: "timeouts.pullRequest", value: cfg.Timeouts.PullRequest},
+		{field: "timeouts.shutdown", value: cfg.Timeouts.Shutdown},
+	}
+
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			return fmt.Errorf("%s is invalid", timeout.field)
+		}
+	}
+
+	if cfg.Extra.Workers < 1 {
+		return errors.New("extra.workers is invalid")
+	}
+
+	switch strings.ToLower(cfg.Extra.LogLevel) {
+	case "", "trace", "debug", "info", "warn", "error", "fatal", "panic":
+	default:
+		return fmt.Errorf("extra.logLevel is invalid: %q", cfg.Extra.LogLevel)
+	}
+
+	if cfg.Server.Port < 0 || cfg.Server.Port > 65535 {
+		return errors.New("server.port is invalid")
+	}
+
+	if cfg.Server.Interval < 0 {
+		return errors.New("server.interval is invalid")
+	}
+
+	if cfg.Server.Jitter < 0 {
+		return errors.New("server.jitter is invalid")
+	}
+
+	err = validateRepoConfig("default.", cfg.Default)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Default.Profile != "" {
+		return errors.New("default.profile is not allowed")
+	}
+
+	for name, profile := range cfg.Profiles {
+		config := *profile
+		applyDefault(&config, cfg.Default)
+
+		err = validateRepoConfig("profiles."+name+".", config)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, config := range cfg.Repositories {
+		err = validateRepoKey(name)
+		if err != nil {
+			return fmt.Errorf("repositories: %w", err)
+		}
+
+		if config == nil {
+			continue
+		}
+
+		err = validateRepoConfig("repositories."+name+".", *config)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateProvider validates the configuration of the provider.
+func validateProvider(cfg Configuration) error {
+	switch cfg.Provider {
+	case ProviderGitHub:
+		if cfg.Github.User == "" && len(cfg.Github.Owners) == 0 {
+			return errors.New("github.user is required")
+		}
+
+	case ProviderGitLab:
+		if len(cfg.GitLab.Groups) == 0 {
+			return errors.New("gitlab.groups is required")
+		}
+
+		for i, group := range cfg.GitLab.Groups {
+			if group == "" || strings.HasPrefix(group, "/") || strings.HasSuffix(group, "/") {
+				return fmt.Errorf("gitlab.groups[%d] is invalid: %q", i, group)
+			}
+		}
+
+		if cfg.GitLab.URL != "" {
+			u, err := url.Parse(cfg.GitLab.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("gitlab.url is invalid: %q", cfg.GitLab.URL)
+			}
+		}
+
+		err := validateRepoKeys("gitlab", cfg.GitLab.Include, cfg.GitLab.Exclude)
+		if err != nil {
+			return err
+		}
+
+		return validateForge(cfg)
+
+	case ProviderGitea:
+		if len(cfg.Gitea.Owners) == 0 {
+			return errors.New("gitea.owners is required")
+		}
+
+		for i, owner := range cfg.Gitea.Owners {
+			if owner == "" || strings.Contains(owner, "/") {
+				return fmt.Errorf("gitea.owners[%d] is invalid: %q", i, owner)
+			}
+		}
+
+		u, err := url.Parse(cfg.Gitea.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("gitea.url is invalid: %q", cfg.Gitea.URL)
+		}
+
+		err = validateRepoKeys("gitea", cfg.Gitea.Include, cfg.Gitea.Exclude)
+		if err != nil {
+			return err
+		}
+
+		return validateForge(cfg)
+
+	default:
+		return fmt.Errorf("provider is invalid: %q", cfg.Provider)
+	}
+
+	return nil
+}
+
+// validateForge rejects the features only supported by GitHub:
+// the webhook (events of GitHub), the commit status, the configuration file of the repository, and the merge train.
+func validateForge(cfg Configuration) error {
+	type feature struct {
+		field string
+		used  bool
+	}
+
+	features := []feature{
+		{field: "server.webhookSecret", used: cfg.Server.WebhookSecret != ""},
+		{field: "extra.statusContext", used: cfg.Extra.StatusContext != ""},
+		{field: "extra.repoConfigFile", used: cfg.Extra.RepoConfigFile},
+		{field: "default.mergeTrainSize", used: cfg.Default.GetMergeTrainSize() > 1},
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		features = append(features, feature{field: "profiles." + name + ".mergeTrainSize", used: cfg.Profiles[name].GetMergeTrainSize() > 1})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Repositories)) {
+		config := cfg.Repositories[name]
+		if config == nil {
+			continue
+		}
+
+		features = append(features, feature{field: "repositories." + name + ".mergeTrainSize", used: config.GetMergeTrainSize() > 1})
+	}
+
+	for _, feature := range features {
+		if feature.used {
+			return fmt.Errorf("%s is not supported by the %s provider", feature.field, cfg.Provider)
+		}
+	}
+
+	if cfg.Provider == ProviderGitLab {
+		return validateGitLabMergeMethods(cfg)
+	}
+
+	return nil
+}
+
+// validateGitLabMergeMethods rejects the merge methods other than squash:
+// the merge method of a merge request is the merge method of its project, the bot can only select the squash.
+func validateGitLabMergeMethods(cfg Configuration) error {
+	type repoConfig struct {
+		field  string
+		config *RepoConfig
+	}
+
+	configs := []repoConfig{{field: "default", config: &cfg.Default}}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		configs = append(configs, repoConfig{field: "profiles." + name, config: cfg.Profiles[name]})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Repositories)) {
+		if cfg.Repositories[name] != nil {
+			configs = append(configs, repoConfig{field: "repositories." + name, config: cfg.Repositories[name]})
+		}
+	}
+
+	for _, c := range configs {
+		mergeMethod := c.config.GetMergeMethod()
+		if mergeMethod != "" && mergeMethod != MergeMethodSquash {
+			return fmt.Errorf("%s.mergeMethod is not supported by the gitlab provider: %q (only %q)", c.field, mergeMethod, MergeMethodSquash)
+		}
 	}
 
 	return nil
 }
 
+// validateRepoConfig validates a repository configuration.
+// The prefix is the path of the configuration, with a trailing dot.
+func validateRepoConfig(prefix string, config RepoConfig) error {
+	if config.GetMinReview() < 0 {
+		return fmt.Errorf("%sminReview is invalid", prefix)
+	}
+
+	if config.GetMinLightReview() < 0 {
+		return fmt.Errorf("%sminLightReview is invalid", prefix)
+	}
+
+	if config.GetMergeTrainSize() < 0 {
+		return fmt.Errorf("%smergeTrainSize is invalid", prefix)
+	}
+
+	switch config.GetMergeMethod() {
+	case "":
+		return fmt.Errorf("%smergeMethod is required", prefix)
+	case MergeMethodSquash, MergeMethodMerge, MergeMethodRebase, MergeMethodFastForward:
+	default:
+		return fmt.Errorf("%smergeMethod is invalid: %q", prefix, config.GetMergeMethod())
+	}
+
+	// the merge train is merged by a fast-forward of the base branch: the pull requests are merged with merge commits.
+	if config.GetMergeTrainSize() > 1 && config.GetMergeMethod() != MergeMethodMerge {
+		return fmt.Errorf("%smergeTrainSize requires the merge method %s: %q", prefix, MergeMethodMerge, config.GetMergeMethod())
+	}
+
+	switch config.GetCommitMessage() {
+	case "", CommitMessageGitHub, CommitMessageEmpty, CommitMessageDescription:
+	default:
+		return fmt.Errorf("%scommitMessage is invalid: %q", prefix, config.GetCommitMessage())
+	}
+
+	return validatePatterns(prefix, config)
+}
+
 // String convert a string to a string pointer.
 func String(v string) *string { return &v }
 
@@ -207,3 +832,25 @@ func Int(v int) *int { return &v }
 
 // Bool convert a bool to a bool pointer.
 func Bool(v bool) *bool { return &v }
+
+func validatePatterns(prefix string, config RepoConfig) error {
+	for _, pattern := range config.GetRequiredChecks() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%srequiredChecks: invalid pattern %q: %w", prefix, pattern, err)
+		}
+	}
+
+	for _, pattern := range config.GetIgnoredChecks() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%signoredChecks: invalid pattern %q: %w", prefix, pattern, err)
+		}
+	}
+
+	for _, pattern := range config.GetProtectedBranches() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%sprotectedBranches: invalid pattern %q: %w", prefix, pattern, err)
+		}
+	}
+
+	return nil
+}
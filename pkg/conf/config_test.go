@@ -1,7 +1,9 @@
 package conf
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +21,7 @@ func TestLoad(t *testing.T) {
 			desc:     "simple",
 			filename: filepath.FromSlash("./fixtures/config.yml"),
 			expected: Configuration{
+				Provider: ProviderGitHub,
 				Github: Github{
 					User:  "ldez",
 					Token: "XXXX",
@@ -48,40 +51,60 @@ func TestLoad(t *testing.T) {
 					OnMergeable: false,
 					OnStatuses:  false,
 				},
+				Timeouts: Timeouts{
+					Clone:       5 * time.Minute,
+					Fetch:       2 * time.Minute,
+					Rebase:      2 * time.Minute,
+					Push:        2 * time.Minute,
+					API:         30 * time.Second,
+					PullRequest: 15 * time.Minute,
+					Shutdown:    1 * time.Minute,
+				},
 				Default: RepoConfig{
-					MergeMethod:       String("squash"),
-					MinLightReview:    Int(0),
-					MinReview:         Int(1),
-					NeedMilestone:     Bool(true),
-					CheckNeedUpToDate: Bool(false),
-					ForceNeedUpToDate: Bool(true),
-					AddErrorInComment: Bool(false),
-					CommitMessage:     String("empty"),
+					MergeMethod:         String("squash"),
+					MinLightReview:      Int(0),
+					MinReview:           Int(1),
+					NeedMilestone:       Bool(true),
+					CheckNeedUpToDate:   Bool(false),
+					ForceNeedUpToDate:   Bool(true),
+					AddErrorInComment:   Bool(false),
+					CommitMessage:       String("empty"),
+					UseProtectionChecks: Bool(false),
+					MergeTrainSize:      Int(0),
+					IgnoredChecks:       []string{"codecov/*"},
 				},
 				Extra: Extra{
 					DryRun:   true,
 					LogLevel: "info",
+					Workers:  1,
 				},
 				Repositories: map[string]*RepoConfig{
 					"ldez/myrepo1": {
-						MergeMethod:       String("squash"),
-						MinLightReview:    Int(1),
-						MinReview:         Int(0),
-						NeedMilestone:     Bool(true),
-						CheckNeedUpToDate: Bool(false),
-						ForceNeedUpToDate: Bool(true),
-						AddErrorInComment: Bool(false),
-						CommitMessage:     String("empty"),
+						MergeMethod:         String("squash"),
+						MinLightReview:      Int(1),
+						MinReview:           Int(0),
+						NeedMilestone:       Bool(true),
+						CheckNeedUpToDate:   Bool(false),
+						ForceNeedUpToDate:   Bool(true),
+						AddErrorInComment:   Bool(false),
+						CommitMessage:       String("empty"),
+						UseProtectionChecks: Bool(false),
+						MergeTrainSize:      Int(0),
+						IgnoredChecks:       []string{"codecov/*"},
 					},
 					"ldez/myrepo2": {
-						MergeMethod:       String("squash"),
-						MinLightReview:    Int(1),
-						MinReview:         Int(1),
-						NeedMilestone:     Bool(false),
-						CheckNeedUpToDate: Bool(false),
-						ForceNeedUpToDate: Bool(true),
-						AddErrorInComment: Bool(false),
-						CommitMessage:     String("description"),
+						MergeMethod:         String("squash"),
+						MinLightReview:      Int(1),
+						MinReview:           Int(1),
+						NeedMilestone:       Bool(false),
+						CheckNeedUpToDate:   Bool(false),
+						ForceNeedUpToDate:   Bool(true),
+						AddErrorInComment:   Bool(false),
+						CommitMessage:       String("description"),
+						UseProtectionChecks: Bool(false),
+						MergeTrainSize:      Int(0),
+						RequiredChecks:      []string{"Test", "Lint"},
+						IgnoredChecks:       []string{},
 					},
 				},
 			},
@@ -90,6 +113,7 @@ func TestLoad(t *testing.T) {
 			desc:     "defaulting",
 			filename: filepath.FromSlash("./fixtures/config_01.yml"),
 			expected: Configuration{
+				Provider: ProviderGitHub,
 				Github: Github{
 					User:  "ldez",
 					Token: "XXXX",
@@ -119,40 +143,56 @@ func TestLoad(t *testing.T) {
 					OnMergeable: false,
 					OnStatuses:  false,
 				},
+				Timeouts: Timeouts{
+					Clone:       5 * time.Minute,
+					Fetch:       2 * time.Minute,
+					Rebase:      2 * time.Minute,
+					Push:        2 * time.Minute,
+					API:         30 * time.Second,
+					PullRequest: 15 * time.Minute,
+					Shutdown:    1 * time.Minute,
+				},
 				Default: RepoConfig{
-					MergeMethod:       String("squash"),
-					MinLightReview:    Int(25),
-					MinReview:         Int(1),
-					NeedMilestone:     Bool(true),
-					CheckNeedUpToDate: Bool(false),
-					ForceNeedUpToDate: Bool(true),
-					AddErrorInComment: Bool(false),
-					CommitMessage:     String("empty"),
+					MergeMethod:         String("squash"),
+					MinLightReview:      Int(25),
+					MinReview:           Int(1),
+					NeedMilestone:       Bool(true),
+					CheckNeedUpToDate:   Bool(false),
+					ForceNeedUpToDate:   Bool(true),
+					AddErrorInComment:   Bool(false),
+					CommitMessage:       String("empty"),
+					UseProtectionChecks: Bool(false),
+					MergeTrainSize:      Int(0),
 				},
 				Extra: Extra{
 					DryRun:   true,
 					LogLevel: "info",
+					Workers:  1,
 				},
 				Repositories: map[string]*RepoConfig{
 					"ldez/myrepo1": {
-						MergeMethod:       String("squash"),
-						MinLightReview:    Int(25),
-						MinReview:         Int(0),
-						NeedMilestone:     Bool(true),
-						CheckNeedUpToDate: Bool(false),
-						ForceNeedUpToDate: Bool(true),
-						AddErrorInComment: Bool(false),
-						CommitMessage:     String("empty"),
+						MergeMethod:         String("squash"),
+						MinLightReview:      Int(25),
+						MinReview:           Int(0),
+						NeedMilestone:       Bool(true),
+						CheckNeedUpToDate:   Bool(false),
+						ForceNeedUpToDate:   Bool(true),
+						AddErrorInComment:   Bool(false),
+						CommitMessage:       String("empty"),
+						UseProtectionChecks: Bool(false),
+						MergeTrainSize:      Int(0),
 					},
 					"ldez/myrepo2": {
-						MergeMethod:       String("squash"),
-						MinLightReview:    Int(1),
-						MinReview:         Int(1),
-						NeedMilestone:     Bool(false),
-						CheckNeedUpToDate: Bool(false),
-						ForceNeedUpToDate: Bool(true),
-						AddErrorInComment: Bool(false),
-						CommitMessage:     String("empty"),
+						MergeMethod:         String("squash"),
+						MinLightReview:      Int(1),
+						MinReview:           Int(1),
+						NeedMilestone:       Bool(false),
+						CheckNeedUpToDate:   Bool(false),
+						ForceNeedUpToDate:   Bool(true),
+						AddErrorInComment:   Bool(false),
+						CommitMessage:       String("empty"),
+						UseProtectionChecks: Bool(false),
+						MergeTrainSize:      Int(0),
 					},
 				},
 			},
@@ -170,3 +210,447 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestCheck(t *testing.T) {
+	const base = `
+github:
+  user: foo
+git:
+  email: bot@example.com
+  userName: botname
+`
+
+	testCases := []struct {
+		desc     string
+		content  string
+		errorMsg string
+	}{
+		{
+			desc:    "valid",
+			content: base + "repositories:\n  foo/bar:\n    minReview: 2\n",
+		},
+		{
+			desc:     "unknown field",
+			content:  base + "extra:\n  debug: true\n",
+			errorMsg: "field debug not found in type conf.Extra",
+		},
+		{
+			desc:     "invalid merge method",
+			content:  base + "default:\n  mergeMethod: foo\n",
+			errorMsg: `default.mergeMethod is invalid: "foo"`,
+		},
+		{
+			desc:     "invalid commit message",
+			content:  base + "repositories:\n  foo/bar:\n    commitMessage: foo\n",
+			errorMsg: `repositories.foo/bar.commitMessage is invalid: "foo"`,
+		},
+		{
+			desc:     "merge train with squash",
+			content:  base + "repositories:\n  foo/bar:\n    mergeTrainSize: 2\n",
+			errorMsg: `repositories.foo/bar.mergeTrainSize requires the merge method merge: "squash"`,
+		},
+		{
+			desc:     "merge train with ff",
+			content:  base + "repositories:\n  foo/bar:\n    mergeTrainSize: 2\n    mergeMethod: ff\n",
+			errorMsg: `repositories.foo/bar.mergeTrainSize requires the merge method merge: "ff"`,
+		},
+		{
+			desc:    "app installation of a single owner",
+			content: "github:\n  user: foo\n  app:\n    id: 1\n    privateKeyFile: app.pem\n    installationId: 2\ngit:\n  email: bot@example.com\n  userName: botname\n",
+		},
+		{
+			desc:     "app installation of several owners",
+			content:  "github:\n  user: foo\n  owners:\n    - name: bar\n  app:\n    id: 1\n    privateKeyFile: app.pem\n    installationId: 2\ngit:\n  email: bot@example.com\n  userName: botname\n",
+			errorMsg: "github.app.installationId requires a single owner: use github.owners[].installationId",
+		},
+		{
+			desc:    "app installations by owner",
+			content: "github:\n  user: foo\n  owners:\n    - name: foo\n      installationId: 2\n    - name: bar\n      installationId: 3\n  app:\n    id: 1\n    privateKeyFile: app.pem\ngit:\n  email: bot@example.com\n  userName: botname\n",
+		},
+		{
+			desc:     "owner installation without app",
+			content:  "github:\n  user: foo\n  owners:\n    - name: bar\n      installationId: 3\ngit:\n  email: bot@example.com\n  userName: botname\n",
+			errorMsg: "github.owners[0].installationId is invalid: the installation requires github.app",
+		},
+		{
+			desc:    "merge train with merge",
+			content: base + "repositories:\n  foo/bar:\n    mergeTrainSize: 2\n    mergeMethod: merge\n",
+		},
+		{
+			desc:     "negative retry number",
+			content:  base + "retry:\n  number: -1\n",
+			errorMsg: "retry.number is invalid",
+		},
+		{
+			desc:     "negative timeout",
+			content:  base + "timeouts:\n  push: -1s\n",
+			errorMsg: "timeouts.push is invalid",
+		},
+		{
+			desc:     "invalid log level",
+			content:  base + "extra:\n  logLevel: foo\n",
+			errorMsg: `extra.logLevel is invalid: "foo"`,
+		},
+		{
+			desc:     "invalid URL",
+			content:  strings.Replace(base, "user: foo", "user: foo\n  url: foo", 1),
+			errorMsg: `github.url is invalid: "foo"`,
+		},
+		{
+			desc:    "gitlab",
+			content: "provider: gitlab\ngitlab:\n  url: https://gitlab.example.com\n  groups: [traefik]\n" + base,
+		},
+		{
+			desc:     "gitlab without groups",
+			content:  "provider: gitlab\n" + base,
+			errorMsg: "gitlab.groups is required",
+		},
+		{
+			desc:     "gitlab with a webhook",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\nserver:\n  webhookSecret: foo\n" + base,
+			errorMsg: "server.webhookSecret is not supported by the gitlab provider",
+		},
+		{
+			desc:     "gitlab with a commit status",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\nextra:\n  statusContext: lobicornis\n" + base,
+			errorMsg: "extra.statusContext is not supported by the gitlab provider",
+		},
+		{
+			desc:     "gitlab with the configuration file of the repository",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\nextra:\n  repoConfigFile: true\n" + base,
+			errorMsg: "extra.repoConfigFile is not supported by the gitlab provider",
+		},
+		{
+			desc:     "gitlab with a merge train",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\ndefault:\n  mergeMethod: merge\n  mergeTrainSize: 2\n" + base,
+			errorMsg: "default.mergeTrainSize is not supported by the gitlab provider",
+		},
+		{
+			desc:     "gitlab with a merge train in a profile",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\nprofiles:\n  oss:\n    mergeMethod: merge\n    mergeTrainSize: 2\n" + base,
+			errorMsg: "profiles.oss.mergeTrainSize is not supported by the gitlab provider",
+		},
+		{
+			desc:     "gitlab with a merge train in a repository",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\n" + base + "repositories:\n  foo/bar:\n    mergeTrainSize: 2\n    mergeMethod: merge\n",
+			errorMsg: "repositories.foo/bar.mergeTrainSize is not supported by the gitlab provider",
+		},
+		{
+			desc:     "gitlab with the merge method merge",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\ndefault:\n  mergeMethod: merge\n" + base,
+			errorMsg: `default.mergeMethod is not supported by the gitlab provider: "merge" (only "squash")`,
+		},
+		{
+			desc:     "gitlab with the merge method rebase in a profile",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\nprofiles:\n  oss:\n    mergeMethod: rebase\n" + base,
+			errorMsg: `profiles.oss.mergeMethod is not supported by the gitlab provider: "rebase" (only "squash")`,
+		},
+		{
+			desc:     "gitlab with the merge method ff in a repository",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\n" + base + "repositories:\n  foo/bar:\n    mergeMethod: ff\n",
+			errorMsg: `repositories.foo/bar.mergeMethod is not supported by the gitlab provider: "ff" (only "squash")`,
+		},
+		{
+			desc:     "gitlab with an invalid exclude",
+			content:  "provider: gitlab\ngitlab:\n  groups: [traefik]\n  exclude: [foo]\n" + base,
+			errorMsg: `gitlab.exclude: invalid repository name "foo"`,
+		},
+		{
+			desc:    "gitlab without merge train",
+			content: "provider: gitlab\ngitlab:\n  groups: [traefik]\n" + base + "repositories:\n  foo/bar:\n    mergeTrainSize: 1\n",
+		},
+		{
+			desc:    "gitea",
+			content: "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik]\n" + base,
+		},
+		{
+			desc:     "gitea without URL",
+			content:  "provider: gitea\ngitea:\n  owners: [traefik]\n" + base,
+			errorMsg: `gitea.url is invalid: ""`,
+		},
+		{
+			desc:     "gitea with an invalid owner",
+			content:  "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik/traefik]\n" + base,
+			errorMsg: `gitea.owners[0] is invalid: "traefik/traefik"`,
+		},
+		{
+			desc:     "gitea with an invalid include",
+			content:  "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik]\n  include: [\"re:(\"]\n" + base,
+			errorMsg: "gitea.include: invalid regular expression \"re:(\": error parsing regexp: missing closing ): `(`",
+		},
+		{
+			desc:     "gitea with a webhook",
+			content:  "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik]\nserver:\n  webhookSecret: foo\n" + base,
+			errorMsg: "server.webhookSecret is not supported by the gitea provider",
+		},
+		{
+			desc:     "gitea with a commit status",
+			content:  "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik]\nextra:\n  statusContext: lobicornis\n" + base,
+			errorMsg: "extra.statusContext is not supported by the gitea provider",
+		},
+		{
+			desc:     "gitea with the configuration file of the repository",
+			content:  "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik]\nextra:\n  repoConfigFile: true\n" + base,
+			errorMsg: "extra.repoConfigFile is not supported by the gitea provider",
+		},
+		{
+			desc:     "gitea with a merge train",
+			content:  "provider: gitea\ngitea:\n  url: https://gitea.example.com\n  owners: [traefik]\n" + base + "repositories:\n  foo/bar:\n    mergeTrainSize: 2\n    mergeMethod: merge\n",
+			errorMsg: "repositories.foo/bar.mergeTrainSize is not supported by the gitea provider",
+		},
+		{
+			desc:    "priorities",
+			content: base + "markers:\n  priorities:\n    bot/priority-high: 10\n    bot/priority-low: -10\n",
+		},
+		{
+			desc:     "priority without label",
+			content:  base + "markers:\n  priorities:\n    \"\": 10\n",
+			errorMsg: "markers.priorities: the label name is required",
+		},
+		{
+			desc:     "invalid provider",
+			content:  "provider: foo\n" + base,
+			errorMsg: `provider is invalid: "foo"`,
+		},
+		{
+			desc:     "invalid repository name",
+			content:  base + "repositories:\n  bar:\n    minReview: 2\n",
+			errorMsg: `repositories: invalid repository name "bar"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			filename := filepath.Join(t.TempDir(), "lobicornis.yml")
+			require.NoError(t, os.WriteFile(filename, []byte(test.content), 0o600))
+
+			_, err := Check(filename)
+
+			if test.errorMsg != "" {
+				require.ErrorContains(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoad_notStrict(t *testing.T) {
+	_, err := Load(filepath.FromSlash("./fixtures/config.yml"))
+	require.NoError(t, err)
+
+	_, err = Check(filepath.FromSlash("./fixtures/config.yml"))
+	require.ErrorContains(t, err, "field debug not found")
+}
+
+func TestLoad_profiles(t *testing.T) {
+	const base = `
+github:
+  user: foo
+git:
+  email: bot@example.com
+  userName: botname
+`
+
+	testCases := []struct {
+		desc     string
+		content  string
+		expected map[string]RepoConfig
+		errorMsg string
+	}{
+		{
+			desc: "inheritance",
+			content: base + `
+default:
+  minReview: 1
+profiles:
+  oss:
+    minReview: 2
+    needMilestone: false
+  plugins:
+    profile: oss
+    mergeMethod: merge
+repositories:
+  foo/bar:
+    profile: plugins
+    minReview: 3
+  foo/plugin-*:
+    profile: plugins
+`,
+			expected: map[string]RepoConfig{
+				"foo/bar":      {MinReview: Int(3), MergeMethod: String("merge"), NeedMilestone: Bool(false), Profile: "plugins"},
+				"foo/plugin-a": {MinReview: Int(2), MergeMethod: String("merge"), NeedMilestone: Bool(false), Profile: "plugins"},
+				"foo/other":    {MinReview: Int(1), MergeMethod: String("squash"), NeedMilestone: Bool(true)},
+			},
+		},
+		{
+			desc:     "unknown profile",
+			content:  base + "repositories:\n  foo/bar:\n    profile: oss\n",
+			errorMsg: `repositories.foo/bar.profile: unknown profile "oss"`,
+		},
+		{
+			desc:     "circular inheritance",
+			content:  base + "profiles:\n  a:\n    profile: b\n  b:\n    profile: a\n",
+			errorMsg: "profiles.a: circular inheritance: a -> b -> a",
+		},
+		{
+			desc:     "invalid profile",
+			content:  base + "profiles:\n  oss:\n    commitMessage: foo\n",
+			errorMsg: `profiles.oss.commitMessage is invalid: "foo"`,
+		},
+		{
+			desc:     "invalid regular expression",
+			content:  base + "repositories:\n  're:^foo/(':\n    minReview: 2\n",
+			errorMsg: `repositories: invalid regular expression "re:^foo/("`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			filename := filepath.Join(t.TempDir(), "lobicornis.yml")
+			require.NoError(t, os.WriteFile(filename, []byte(test.content), 0o600))
+
+			cfg, err := Load(filename)
+
+			if test.errorMsg != "" {
+				require.ErrorContains(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+
+			for fullName, expected := range test.expected {
+				config := cfg.GetRepoConfig(fullName)
+
+				assert.Equal(t, expected.MinReview, config.MinReview, fullName)
+				assert.Equal(t, expected.MergeMethod, config.MergeMethod, fullName)
+				assert.Equal(t, expected.NeedMilestone, config.NeedMilestone, fullName)
+				assert.Equal(t, expected.Profile, config.Profile, fullName)
+			}
+		})
+	}
+}
+
+func TestMarkers_GetPriority(t *testing.T) {
+	markers := Markers{
+		Priorities: map[string]int{
+			"bot/priority-high": 10,
+			"bot/priority-low":  -10,
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		labels   []string
+		expected int
+	}{
+		{
+			desc:     "no label",
+			expected: 0,
+		},
+		{
+			desc:     "no priority label",
+			labels:   []string{"status/3-needs-merge"},
+			expected: 0,
+		},
+		{
+			desc:     "high",
+			labels:   []string{"status/3-needs-merge", "bot/priority-high"},
+			expected: 10,
+		},
+		{
+			desc:     "low",
+			labels:   []string{"bot/priority-low"},
+			expected: -10,
+		},
+		{
+			desc:     "case-insensitive",
+			labels:   []string{"Bot/Priority-High"},
+			expected: 10,
+		},
+		{
+			desc:     "the highest",
+			labels:   []string{"bot/priority-low", "bot/priority-high"},
+			expected: 10,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, markers.GetPriority(test.labels...))
+		})
+	}
+}
+
+func TestGitLab_IsManaged(t *testing.T) {
+	gl := GitLab{
+		Groups:  []string{"traefik", "ldez/oss"},
+		Exclude: []string{"traefik/archived-*", "re:^ldez/oss/.*-old$"},
+	}
+
+	testCases := map[string]bool{
+		"traefik/traefik":         true,
+		"Traefik/Traefik":         true,
+		"traefik/sub/traefik":     true,
+		"traefik/archived-foo":    false,
+		"ldez/oss/lobicornis":     true,
+		"ldez/oss/lobicornis-old": false,
+		"ldez/lobicornis":         false,
+		"traefiklabs/traefik":     false,
+		"traefik":                 false,
+	}
+
+	for path, expected := range testCases {
+		assert.Equal(t, expected, gl.IsManaged(path), path)
+	}
+}
+
+func TestGitea_IsManaged(t *testing.T) {
+	gt := Gitea{
+		Owners:  []string{"traefik", "ldez"},
+		Include: []string{"traefik/*", "ldez/lobicornis"},
+		Exclude: []string{"traefik/archived-*"},
+	}
+
+	testCases := map[string]bool{
+		"traefik/traefik":      true,
+		"Traefik/Traefik":      true,
+		"traefik/archived-foo": false,
+		"ldez/lobicornis":      true,
+		"ldez/foo":             false,
+		"containous/foo":       false,
+	}
+
+	for fullName, expected := range testCases {
+		assert.Equal(t, expected, gt.IsManaged(fullName), fullName)
+	}
+}
+
+func TestConfiguration_IsManaged(t *testing.T) {
+	cfg := Configuration{
+		Github: Github{User: "traefik"},
+		GitLab: GitLab{Groups: []string{"ldez"}},
+		Gitea:  Gitea{Owners: []string{"containous"}},
+	}
+
+	testCases := map[string]string{
+		ProviderGitHub: "traefik/traefik",
+		ProviderGitLab: "ldez/lobicornis",
+		ProviderGitea:  "containous/foo",
+	}
+
+	for provider, fullName := range testCases {
+		cfg.Provider = provider
+
+		for _, other := range testCases {
+			assert.Equal(t, other == fullName, cfg.IsManaged(other), provider+" "+other)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package conf
 
+// Providers.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
 // Merge Methods.
 const (
 	MergeMethodSquash      = "squash"
@@ -7,3 +14,10 @@ const (
 	MergeMethodMerge       = "merge"
 	MergeMethodFastForward = "ff"
 )
+
+// Commit message strategies (squash merge method only).
+const (
+	CommitMessageGitHub      = "github"
+	CommitMessageEmpty       = "empty"
+	CommitMessageDescription = "description"
+)
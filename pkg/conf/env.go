@@ -0,0 +1,177 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvPrefix the prefix of the environment variables overriding the configuration.
+const EnvPrefix = "LOBICORNIS_"
+
+// envFileSuffix the suffix of the environment variables containing the path of a file holding the value (secrets).
+const envFileSuffix = "_FILE"
+
+// applyEnv overrides the configuration with the environment variables.
+// The name of a variable is built from the path of the field: git.userName is LOBICORNIS_GIT_USER_NAME.
+// The value of a string field can also be read from a file: LOBICORNIS_GITHUB_TOKEN_FILE.
+// The lists are comma-separated, the repositories and the owners cannot be overridden.
+func applyEnv(cfg *Configuration, lookup func(string) (string, bool)) error {
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookup)
+}
+
+func applyEnvStruct(value reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + "_" + toEnvName(name)
+
+		switch field.Type.Kind() {
+		case reflect.Map:
+			continue
+		case reflect.Slice:
+			if field.Type.Elem().Kind() == reflect.Struct {
+				continue
+			}
+		case reflect.Struct:
+			err := applyEnvStruct(value.Field(i), key, lookup)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		raw, ok, err := lookupEnv(key, field.Type, lookup)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		err = setField(value.Field(i), raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv gets the value of a variable, or the content of the file defined by the variable with the suffix _FILE (string fields only).
+func lookupEnv(key string, typ reflect.Type, lookup func(string) (string, bool)) (string, bool, error) {
+	raw, ok := lookup(key)
+
+	if typ.Kind() != reflect.String {
+		return raw, ok, nil
+	}
+
+	filename, okFile := lookup(key + envFileSuffix)
+	if !okFile {
+		return raw, ok, nil
+	}
+
+	if ok {
+		return "", false, fmt.Errorf("%s and %s are mutually exclusive", key, key+envFileSuffix)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid %s: %w", key+envFileSuffix, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Pointer {
+		value := reflect.New(field.Type().Elem())
+
+		err := setField(value.Elem(), raw)
+		if err != nil {
+			return err
+		}
+
+		field.Set(value)
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(v)
+
+	case reflect.Int, reflect.Int64:
+		if field.Type() == reflect.TypeFor[time.Duration]() {
+			v, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+
+			field.SetInt(int64(v))
+
+			return nil
+		}
+
+		v, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(v)
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type: %s", field.Type())
+		}
+
+		values := []string{}
+		for v := range strings.SplitSeq(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+
+		field.Set(reflect.ValueOf(values))
+
+	default:
+		return fmt.Errorf("unsupported type: %s", field.Type())
+	}
+
+	return nil
+}
+
+// toEnvName converts a YAML name (camel case) to the name of an environment variable: userName is USER_NAME.
+func toEnvName(name string) string {
+	var b strings.Builder
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && !unicode.IsUpper(runes[i-1]) {
+			b.WriteRune('_')
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyEnv(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cr3t\n"), 0o600))
+
+	testCases := []struct {
+		desc     string
+		env      map[string]string
+		expected Configuration
+		errorMsg string
+	}{
+		{
+			desc: "all types",
+			env: map[string]string{
+				"LOBICORNIS_GIT_EMAIL":                  "bot@example.com",
+				"LOBICORNIS_GIT_USER_NAME":              "bot",
+				"LOBICORNIS_GIT_SSH":                    "true",
+				"LOBICORNIS_GITHUB_APP_INSTALLATION_ID": "42",
+				"LOBICORNIS_RETRY_NUMBER":               "3",
+				"LOBICORNIS_RETRY_INTERVAL":             "2m",
+				"LOBICORNIS_DEFAULT_MIN_REVIEW":         "2",
+				"LOBICORNIS_DEFAULT_NEED_MILESTONE":     "false",
+				"LOBICORNIS_DEFAULT_REQUIRED_CHECKS":    "Test, Lint",
+			},
+			expected: Configuration{
+				Github: Github{App: GithubApp{InstallationID: 42}},
+				Git:    Git{Email: "bot@example.com", UserName: "bot", SSH: true},
+				Retry:  Retry{Number: 3, Interval: 2 * time.Minute},
+				Default: RepoConfig{
+					MinReview:      Int(2),
+					NeedMilestone:  Bool(false),
+					RequiredChecks: []string{"Test", "Lint"},
+				},
+			},
+		},
+		{
+			desc: "secret file",
+			env: map[string]string{
+				"LOBICORNIS_GITHUB_TOKEN_FILE":          secret,
+				"LOBICORNIS_SERVER_WEBHOOK_SECRET_FILE": secret,
+			},
+			expected: Configuration{
+				Github: Github{Token: "s3cr3t"},
+				Server: Server{WebhookSecret: "s3cr3t"},
+			},
+		},
+		{
+			desc: "value and file",
+			env: map[string]string{
+				"LOBICORNIS_GITHUB_TOKEN":      "foo",
+				"LOBICORNIS_GITHUB_TOKEN_FILE": secret,
+			},
+			errorMsg: "LOBICORNIS_GITHUB_TOKEN and LOBICORNIS_GITHUB_TOKEN_FILE are mutually exclusive",
+		},
+		{
+			desc:     "missing file",
+			env:      map[string]string{"LOBICORNIS_GITHUB_TOKEN_FILE": filepath.Join(t.TempDir(), "missing")},
+			errorMsg: "invalid LOBICORNIS_GITHUB_TOKEN_FILE",
+		},
+		{
+			desc:     "invalid number",
+			env:      map[string]string{"LOBICORNIS_RETRY_NUMBER": "foo"},
+			errorMsg: "invalid LOBICORNIS_RETRY_NUMBER",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg Configuration
+
+			err := applyEnv(&cfg, func(key string) (string, bool) {
+				value, ok := test.env[key]
+				return value, ok
+			})
+
+			if test.errorMsg != "" {
+				require.ErrorContains(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, cfg)
+		})
+	}
+}
+
+func Test_toEnvName(t *testing.T) {
+	testCases := map[string]string{
+		"user":           "USER",
+		"userName":       "USER_NAME",
+		"installationId": "INSTALLATION_ID",
+		"privateKeyFile": "PRIVATE_KEY_FILE",
+	}
+
+	for name, expected := range testCases {
+		assert.Equal(t, expected, toEnvName(name))
+	}
+}
@@ -0,0 +1,51 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithub_GetOwners(t *testing.T) {
+	gh := Github{
+		User: "traefik",
+		Owners: []Owner{
+			{Name: "Traefik", Token: "aaa"},
+			{Name: "containous"},
+			{Name: "ldez", Token: "bbb"},
+		},
+	}
+
+	expected := []Owner{
+		{Name: "traefik", Token: "aaa"},
+		{Name: "containous"},
+		{Name: "ldez", Token: "bbb"},
+	}
+
+	assert.Equal(t, expected, gh.GetOwners())
+}
+
+func TestGithub_IsManaged(t *testing.T) {
+	gh := Github{
+		User:    "traefik",
+		Owners:  []Owner{{Name: "ldez"}},
+		Include: []string{"traefik/*", "ldez/lobicornis", "re:^ldez/go-.*$"},
+		Exclude: []string{"traefik/archived-*", "ldez/go-old"},
+	}
+
+	testCases := map[string]bool{
+		"traefik/traefik":      true,
+		"traefik/archived-foo": false,
+		"Traefik/Archived-Foo": false,
+		"ldez/lobicornis":      true,
+		"ldez/go-foo":          true,
+		"Ldez/Go-Foo":          true,
+		"ldez/go-old":          false,
+		"ldez/foo":             false,
+		"containous/foo":       false,
+	}
+
+	for fullName, expected := range testCases {
+		assert.Equal(t, expected, gh.IsManaged(fullName), fullName)
+	}
+}
@@ -0,0 +1,216 @@
+package conf
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// RegexPrefix the prefix of the repository keys defined as regular expressions (ex: `re:^traefik/.*-docs$`).
+const RegexPrefix = "re:"
+
+// GetRepoConfig gets the configuration of a repository.
+// An exact key (owner/name, case-insensitive) wins over the patterns, and the most specific pattern wins over the other patterns.
+// Returns the default configuration if no key matches.
+func (c *Configuration) GetRepoConfig(fullName string) RepoConfig {
+	config := c.Repositories[c.GetRepoKey(fullName)]
+	if config == nil {
+		return c.Default
+	}
+
+	return *config
+}
+
+// GetRepoKey gets the key of the repositories (exact key or pattern) applied to a repository.
+// Returns an empty string if no key matches: the default configuration applies.
+func (c *Configuration) GetRepoKey(fullName string) string {
+	key := strings.ToLower(fullName)
+	if _, ok := c.Repositories[key]; ok {
+		return key
+	}
+
+	return matchRepository(c.Repositories, fullName)
+}
+
+// matchRepository gets the most specific pattern matching a repository.
+// Returns an empty string if no pattern matches.
+func matchRepository(repositories map[string]*RepoConfig, fullName string) string {
+	var (
+		best      string
+		bestScore specificity
+	)
+
+	for key := range repositories {
+		if !isRepoPattern(key) {
+			continue
+		}
+
+		score, ok := matchRepoPattern(key, fullName)
+		if !ok {
+			continue
+		}
+
+		// the order of a map is random: the key is used to break the ties.
+		if best == "" || bestScore.less(score) || (score == bestScore && key < best) {
+			best = key
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// specificity the specificity of a pattern: a glob is more specific than a regular expression,
+// and between two patterns of the same kind, the pattern with more literal characters is more specific.
+type specificity struct {
+	glob     bool
+	literals int
+}
+
+func (s specificity) less(other specificity) bool {
+	if s.glob != other.glob {
+		return other.glob
+	}
+
+	return s.literals < other.literals
+}
+
+func isRepoPattern(key string) bool {
+	return strings.HasPrefix(key, RegexPrefix) || strings.ContainsAny(key, `*?[\`)
+}
+
+// matchRepoPattern checks if a pattern (glob or regular expression) matches a repository, and gets the specificity of the pattern.
+// The matching is case-insensitive, like the exact keys.
+func matchRepoPattern(pattern, fullName string) (specificity, bool) {
+	if expr, ok := strings.CutPrefix(pattern, RegexPrefix); ok {
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil || !re.MatchString(fullName) {
+			return specificity{}, false
+		}
+
+		parsed, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			return specificity{}, false
+		}
+
+		return specificity{literals: countRegexLiterals(parsed)}, true
+	}
+
+	if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(fullName)); !ok {
+		return specificity{}, false
+	}
+
+	return specificity{glob: true, literals: countGlobLiterals(pattern)}, true
+}
+
+func countRegexLiterals(re *syntax.Regexp) int {
+	if re.Op == syntax.OpLiteral {
+		return len(re.Rune)
+	}
+
+	var count int
+	for _, sub := range re.Sub {
+		count += countRegexLiterals(sub)
+	}
+
+	return count
+}
+
+func countGlobLiterals(pattern string) int {
+	var count int
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+		case '[':
+			// a character class is not a literal.
+			for i < len(pattern) && pattern[i] != ']' {
+				i++
+			}
+		case '\\':
+			i++
+			count++
+		default:
+			count++
+		}
+	}
+
+	return count
+}
+
+// matchRepoKey checks if a key (owner/name, glob pattern, or regular expression) matches a repository.
+func matchRepoKey(key, fullName string) bool {
+	if !isRepoPattern(key) {
+		return strings.EqualFold(key, fullName)
+	}
+
+	_, ok := matchRepoPattern(key, fullName)
+
+	return ok
+}
+
+// normalizeRepoKeys lower-cases the exact keys (owner/name) of the repositories.
+// The patterns are kept as is.
+func normalizeRepoKeys(repositories map[string]*RepoConfig) error {
+	for _, key := range slices.Sorted(maps.Keys(repositories)) {
+		lower := strings.ToLower(key)
+		if isRepoPattern(key) || lower == key {
+			continue
+		}
+
+		if _, exists := repositories[lower]; exists {
+			return fmt.Errorf("repositories: duplicate repository %q: %q is already defined", key, lower)
+		}
+
+		repositories[lower] = repositories[key]
+		delete(repositories, key)
+	}
+
+	return nil
+}
+
+// validateRepoKeys validates the include and exclude lists of a provider.
+func validateRepoKeys(provider string, include, exclude []string) error {
+	for _, key := range include {
+		err := validateRepoKey(key)
+		if err != nil {
+			return fmt.Errorf("%s.include: %w", provider, err)
+		}
+	}
+
+	for _, key := range exclude {
+		err := validateRepoKey(key)
+		if err != nil {
+			return fmt.Errorf("%s.exclude: %w", provider, err)
+		}
+	}
+
+	return nil
+}
+
+// validateRepoKey validates a repository key: owner/name, a glob pattern, or a regular expression.
+func validateRepoKey(key string) error {
+	if expr, ok := strings.CutPrefix(key, RegexPrefix); ok {
+		_, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", key, err)
+		}
+
+		return nil
+	}
+
+	owner, name, ok := strings.Cut(key, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid repository name %q", key)
+	}
+
+	if _, err := path.Match(key, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", key, err)
+	}
+
+	return nil
+}
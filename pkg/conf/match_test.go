@@ -0,0 +1,108 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration_GetRepoConfig(t *testing.T) {
+	cfg := Configuration{
+		Default: RepoConfig{MinReview: Int(1)},
+		Repositories: map[string]*RepoConfig{
+			"traefik/traefik":          {MinReview: Int(2)},
+			"traefik/plugin-*":         {MinReview: Int(3)},
+			"traefik/plugin-demo*":     {MinReview: Int(4)},
+			"traefik/*":                {MinReview: Int(5)},
+			`re:^traefik/.*-docs$`:     {MinReview: Int(6)},
+			`re:^traefik/plugin-.*$`:   {MinReview: Int(7)},
+			`re:^containous/(foo|bar)`: {MinReview: Int(8)},
+			"Ldez/Plugin-*":            {MinReview: Int(9)},
+			`re:^Juju/.*-Docs$`:        {MinReview: Int(10)},
+			"containous/empty":         nil,
+		},
+	}
+
+	testCases := []struct {
+		fullName  string
+		key       string
+		minReview int
+	}{
+		{fullName: "traefik/traefik", key: "traefik/traefik", minReview: 2},
+		{fullName: "Traefik/Traefik", key: "traefik/traefik", minReview: 2},
+		{fullName: "traefik/plugin-log", key: "traefik/plugin-*", minReview: 3},
+		{fullName: "Traefik/Plugin-Log", key: "traefik/plugin-*", minReview: 3},
+		{fullName: "traefik/plugin-demo-go", key: "traefik/plugin-demo*", minReview: 4},
+		{fullName: "traefik/yaegi", key: "traefik/*", minReview: 5},
+		// a glob is more specific than a regular expression.
+		{fullName: "traefik/yaegi-docs", key: "traefik/*", minReview: 5},
+		{fullName: "containous/foo", key: `re:^containous/(foo|bar)`, minReview: 8},
+		{fullName: "Containous/Foo", key: `re:^containous/(foo|bar)`, minReview: 8},
+		{fullName: "ldez/plugin-log", key: "Ldez/Plugin-*", minReview: 9},
+		{fullName: "juju/lobicornis-docs", key: `re:^Juju/.*-Docs$`, minReview: 10},
+		{fullName: "containous/empty", key: "containous/empty", minReview: 1},
+		{fullName: "ldez/traefik", minReview: 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.fullName, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.key, cfg.GetRepoKey(test.fullName))
+
+			config := cfg.GetRepoConfig(test.fullName)
+
+			assert.Equal(t, test.minReview, config.GetMinReview())
+		})
+	}
+}
+
+func Test_countGlobLiterals(t *testing.T) {
+	testCases := map[string]int{
+		"traefik/traefik": 15,
+		"traefik/*":       8,
+		"traefik/plug?n":  13,
+		"traefik/[ab]*":   8,
+		`traefik/\*`:      9,
+	}
+
+	for pattern, expected := range testCases {
+		assert.Equal(t, expected, countGlobLiterals(pattern), pattern)
+	}
+}
+
+func Test_normalizeRepoKeys(t *testing.T) {
+	repositories := map[string]*RepoConfig{
+		"Traefik/Traefik":  {MinReview: Int(2)},
+		"Traefik/*":        {MinReview: Int(3)},
+		`re:^Traefik/.*$`:  {MinReview: Int(4)},
+		"containous/empty": nil,
+	}
+
+	require.NoError(t, normalizeRepoKeys(repositories))
+
+	assert.Equal(t, map[string]*RepoConfig{
+		"traefik/traefik":  {MinReview: Int(2)},
+		"Traefik/*":        {MinReview: Int(3)},
+		`re:^Traefik/.*$`:  {MinReview: Int(4)},
+		"containous/empty": nil,
+	}, repositories)
+
+	cfg := Configuration{Repositories: repositories}
+
+	config := cfg.GetRepoConfig("TRAEFIK/traefik")
+
+	assert.Equal(t, 2, config.GetMinReview())
+}
+
+func Test_normalizeRepoKeys_duplicate(t *testing.T) {
+	repositories := map[string]*RepoConfig{
+		"Traefik/traefik": {MinReview: Int(2)},
+		"traefik/traefik": {MinReview: Int(3)},
+	}
+
+	err := normalizeRepoKeys(repositories)
+
+	require.EqualError(t, err, `repositories: duplicate repository "Traefik/traefik": "traefik/traefik" is already defined`)
+}
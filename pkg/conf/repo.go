@@ -1,5 +1,18 @@
 package conf
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFile the path of the configuration file inside a repository.
+const RepoConfigFile = ".github/lobicornis.yml"
+
 // RepoConfig the repo configuration.
 type RepoConfig struct {
 	MergeMethod       *string `yaml:"mergeMethod,omitempty"`
@@ -10,6 +23,17 @@ type RepoConfig struct {
 	ForceNeedUpToDate *bool   `yaml:"forceNeedUpToDate,omitempty"`
 	AddErrorInComment *bool   `yaml:"addErrorInComment,omitempty"`
 	CommitMessage     *string `yaml:"commitMessage,omitempty"`
+
+	RequiredChecks      []string `yaml:"requiredChecks,omitempty"`
+	IgnoredChecks       []string `yaml:"ignoredChecks,omitempty"`
+	UseProtectionChecks *bool    `yaml:"useProtectionChecks,omitempty"`
+
+	MergeTrainSize *int `yaml:"mergeTrainSize,omitempty"`
+
+	ProtectedBranches []string `yaml:"protectedBranches,omitempty"`
+
+	// Profile the name of the profile inherited by the configuration.
+	Profile string `yaml:"profile,omitempty"`
 }
 
 // GetMergeMethod gets merge method.
@@ -83,3 +107,125 @@ func (r *RepoConfig) GetCommitMessage() string {
 
 	return ""
 }
+
+// GetRequiredChecks gets the names (or glob patterns) of the required checks.
+func (r *RepoConfig) GetRequiredChecks() []string {
+	return r.RequiredChecks
+}
+
+// GetIgnoredChecks gets the names (or glob patterns) of the ignored checks.
+func (r *RepoConfig) GetIgnoredChecks() []string {
+	return r.IgnoredChecks
+}
+
+// GetUseProtectionChecks gets UseProtectionChecks.
+func (r *RepoConfig) GetUseProtectionChecks() bool {
+	if r.UseProtectionChecks != nil {
+		return *r.UseProtectionChecks
+	}
+
+	return false
+}
+
+// GetMergeTrainSize gets the maximum number of pull requests in a merge train.
+// The merge train pushes to the base branch: the branch protection must not require pull request reviews.
+func (r *RepoConfig) GetMergeTrainSize() int {
+	if r.MergeTrainSize != nil {
+		return *r.MergeTrainSize
+	}
+
+	return 0
+}
+
+// GetProtectedBranches gets the patterns of the branches that must never be rebased or force-pushed.
+func (r *RepoConfig) GetProtectedBranches() []string {
+	return r.ProtectedBranches
+}
+
+// ParseRepoConfig parses the configuration file of a repository.
+// The fields defined in the file override the fields of the base configuration,
+// except the security gates that can only be tightened (see restrictGates).
+func ParseRepoConfig(data []byte, base RepoConfig) (RepoConfig, error) {
+	var config RepoConfig
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(&config)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return RepoConfig{}, fmt.Errorf("invalid %s: %w", RepoConfigFile, err)
+	}
+
+	// the merge train is managed before the reading of the file.
+	if config.MergeTrainSize != nil {
+		return RepoConfig{}, fmt.Errorf("invalid %s: mergeTrainSize can only be defined in the central configuration", RepoConfigFile)
+	}
+
+	if config.Profile != "" {
+		return RepoConfig{}, fmt.Errorf("invalid %s: profile can only be defined in the central configuration", RepoConfigFile)
+	}
+
+	// the required checks and the protected branches of the file are added to the central ones.
+	if config.RequiredChecks != nil {
+		config.RequiredChecks = union(base.RequiredChecks, config.RequiredChecks)
+	}
+
+	if config.ProtectedBranches != nil {
+		config.ProtectedBranches = union(base.ProtectedBranches, config.ProtectedBranches)
+	}
+
+	applyDefault(&config, base)
+
+	err = validateRepoConfig("", config)
+	if err != nil {
+		return RepoConfig{}, fmt.Errorf("invalid %s: %w", RepoConfigFile, err)
+	}
+
+	err = restrictGates(config, base)
+	if err != nil {
+		return RepoConfig{}, fmt.Errorf("invalid %s: %w", RepoConfigFile, err)
+	}
+
+	return config, nil
+}
+
+// restrictGates checks that the configuration file doesn't loosen the security gates of the central configuration:
+// the file is writable by the contributors of the repository.
+func restrictGates(config, base RepoConfig) error {
+	if config.GetMinReview() < base.GetMinReview() {
+		return fmt.Errorf("minReview can only be increased: the central configuration requires %d", base.GetMinReview())
+	}
+
+	if config.GetMinLightReview() < base.GetMinLightReview() {
+		return fmt.Errorf("minLightReview can only be increased: the central configuration requires %d", base.GetMinLightReview())
+	}
+
+	if base.GetNeedMilestone() && !config.GetNeedMilestone() {
+		return errors.New("needMilestone can only be disabled in the central configuration")
+	}
+
+	if base.GetUseProtectionChecks() && !config.GetUseProtectionChecks() {
+		return errors.New("useProtectionChecks can only be disabled in the central configuration")
+	}
+
+	for _, pattern := range config.GetIgnoredChecks() {
+		if !slices.Contains(base.GetIgnoredChecks(), pattern) {
+			return fmt.Errorf("ignoredChecks can only be defined in the central configuration: %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// union gets the values of a and the values of b not in a.
+func union(a, b []string) []string {
+	result := slices.Clone(a)
+
+	for _, value := range b {
+		if !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
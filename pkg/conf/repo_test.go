@@ -0,0 +1,137 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoConfig(t *testing.T) {
+	base := RepoConfig{
+		MergeMethod:         String(MergeMethodSquash),
+		MinLightReview:      Int(0),
+		MinReview:           Int(1),
+		NeedMilestone:       Bool(true),
+		CommitMessage:       String("empty"),
+		RequiredChecks:      []string{"Test"},
+		IgnoredChecks:       []string{"codecov/*"},
+		UseProtectionChecks: Bool(true),
+		MergeTrainSize:      Int(0),
+		ProtectedBranches:   []string{"main"},
+	}
+
+	testCases := []struct {
+		desc     string
+		data     string
+		expected RepoConfig
+		errorMsg string
+	}{
+		{
+			desc:     "empty file",
+			data:     "",
+			expected: base,
+		},
+		{
+			desc: "overrides",
+			data: `
+mergeMethod: merge
+minReview: 2
+needMilestone: true
+ignoredChecks: []
+`,
+			expected: RepoConfig{
+				MergeMethod:         String(MergeMethodMerge),
+				MinLightReview:      Int(0),
+				MinReview:           Int(2),
+				NeedMilestone:       Bool(true),
+				CommitMessage:       String("empty"),
+				RequiredChecks:      []string{"Test"},
+				IgnoredChecks:       []string{},
+				UseProtectionChecks: Bool(true),
+				MergeTrainSize:      Int(0),
+				ProtectedBranches:   []string{"main"},
+			},
+		},
+		{
+			desc: "additional required checks and protected branches",
+			data: `
+requiredChecks: [Lint]
+protectedBranches: [release/*]
+`,
+			expected: RepoConfig{
+				MergeMethod:         String(MergeMethodSquash),
+				MinLightReview:      Int(0),
+				MinReview:           Int(1),
+				NeedMilestone:       Bool(true),
+				CommitMessage:       String("empty"),
+				RequiredChecks:      []string{"Test", "Lint"},
+				IgnoredChecks:       []string{"codecov/*"},
+				UseProtectionChecks: Bool(true),
+				MergeTrainSize:      Int(0),
+				ProtectedBranches:   []string{"main", "release/*"},
+			},
+		},
+		{
+			desc:     "lower minReview",
+			data:     "minReview: 0",
+			errorMsg: "invalid .github/lobicornis.yml: minReview can only be increased: the central configuration requires 1",
+		},
+		{
+			desc:     "disable the protection checks",
+			data:     "useProtectionChecks: false",
+			errorMsg: "invalid .github/lobicornis.yml: useProtectionChecks can only be disabled in the central configuration",
+		},
+		{
+			desc:     "disable the milestone",
+			data:     "needMilestone: false",
+			errorMsg: "invalid .github/lobicornis.yml: needMilestone can only be disabled in the central configuration",
+		},
+		{
+			desc:     "additional ignored checks",
+			data:     "ignoredChecks: ['codecov/*', 'Test']",
+			errorMsg: `invalid .github/lobicornis.yml: ignoredChecks can only be defined in the central configuration: "Test"`,
+		},
+		{
+			desc:     "unknown field",
+			data:     "minReviews: 2",
+			errorMsg: "invalid .github/lobicornis.yml: yaml: unmarshal errors:\n  line 1: field minReviews not found in type conf.RepoConfig",
+		},
+		{
+			desc:     "invalid merge method",
+			data:     "mergeMethod: foo",
+			errorMsg: `invalid .github/lobicornis.yml: mergeMethod is invalid: "foo"`,
+		},
+		{
+			desc:     "merge train",
+			data:     "mergeTrainSize: 3",
+			errorMsg: "invalid .github/lobicornis.yml: mergeTrainSize can only be defined in the central configuration",
+		},
+		{
+			desc:     "negative review",
+			data:     "minReview: -1",
+			errorMsg: "invalid .github/lobicornis.yml: minReview is invalid",
+		},
+		{
+			desc:     "invalid check pattern",
+			data:     "requiredChecks: ['[']",
+			errorMsg: `invalid .github/lobicornis.yml: requiredChecks: invalid pattern "[": syntax error in pattern`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := ParseRepoConfig([]byte(test.data), base)
+
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
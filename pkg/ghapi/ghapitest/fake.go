@@ -0,0 +1,849 @@
+// Package ghapitest provides a stateful in-memory fake of the GitHub API services used by the bot.
+package ghapitest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+// Merge a merge made through the API.
+type Merge struct {
+	Number  int
+	Method  string
+	Title   string
+	Message string
+	SHA     string
+}
+
+// Fake an in-memory GitHub.
+// The fake is stateful: a change made through a service is visible through the other services
+// (ex: a label added through the issues API is visible in the search results).
+// The objects are copied in and out: a test never shares an object with the code under test.
+type Fake struct {
+	mu sync.Mutex
+
+	repos  map[string]*fakeRepository
+	errors map[string]error
+
+	// lastSHA the counter used to generate the commit SHAs.
+	lastSHA int
+}
+
+type fakeRepository struct {
+	repo *github.Repository
+
+	// git the bare repository backing the repository, nil if the branches are in memory.
+	git *gitRepository
+
+	branches map[string]*github.Branch
+	pulls    map[int]*github.PullRequest
+	issues   map[int]*github.Issue
+
+	reviews  map[int][]*github.PullRequestReview
+	commits  map[int][]*github.RepositoryCommit
+	comments map[int][]*github.IssueComment
+	events   map[int][]*github.IssueEvent
+	merges   []Merge
+	updates  []int
+
+	checkRuns   map[string][]*github.CheckRun
+	checkSuites map[string][]*github.CheckSuite
+	statuses    map[string][]*github.RepoStatus
+	protections map[string]*github.RequiredStatusChecks
+	contents    map[string]string
+}
+
+// New creates an empty fake.
+func New() *Fake {
+	return &Fake{
+		repos:  make(map[string]*fakeRepository),
+		errors: make(map[string]error),
+	}
+}
+
+// Client creates a client backed by the fake.
+func (f *Fake) Client() *ghapi.Client {
+	return &ghapi.Client{
+		PullRequests: &pullRequests{fake: f},
+		Issues:       &issues{fake: f},
+		Checks:       &checks{fake: f},
+		Repositories: &repositories{fake: f},
+		Search:       &search{fake: f},
+		Git:          &gitService{fake: f},
+	}
+}
+
+// SetError makes a method of a service fail (ex: "PullRequests.Merge").
+// A nil error removes the failure.
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+
+	f.errors[method] = err
+}
+
+// AddRepository adds a repository (owner and name are required).
+// The default branch (main if empty) is created.
+// A repository with a "file://" Git URL is backed by a local bare repository, created if it doesn't exist:
+// the code under test can clone it and push to it.
+func (f *Fake) AddRepository(repo *github.Repository) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.newRepository(repo)
+
+	if strings.HasPrefix(r.repo.GetGitURL(), gitScheme) {
+		g, err := newGitRepository(r.repo.GetGitURL(), r.repo.GetDefaultBranch())
+		if err != nil {
+			panic(fmt.Sprintf("ghapitest: %v", err))
+		}
+
+		r.git = g
+	} else {
+		r.branches[r.repo.GetDefaultBranch()] = newBranch(r.repo.GetDefaultBranch(), f.nextSHA(), "Initial commit")
+	}
+
+	f.repos[r.repo.GetFullName()] = r
+}
+
+// AddFork adds a fork of a repository, with a copy of its branches.
+// The fork of a repository backed by a bare repository must have a "file://" Git URL.
+func (f *Fake) AddFork(parent string, repo *github.Repository) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := f.mustRepo(parent)
+
+	repo = clone(repo)
+	repo.Fork = github.Ptr(true)
+	repo.Parent = clone(p.repo)
+
+	if repo.DefaultBranch == nil {
+		repo.DefaultBranch = p.repo.DefaultBranch
+	}
+
+	r := f.newRepository(repo)
+
+	if p.git != nil {
+		if !strings.HasPrefix(r.repo.GetGitURL(), gitScheme) {
+			panic(fmt.Sprintf("ghapitest: the fork of %s requires a %s URL", parent, gitScheme))
+		}
+
+		g, err := p.git.fork(r.repo.GetGitURL())
+		if err != nil {
+			panic(fmt.Sprintf("ghapitest: %v", err))
+		}
+
+		r.git = g
+	} else {
+		for name, branch := range p.branches {
+			r.branches[name] = clone(branch)
+		}
+	}
+
+	f.repos[r.repo.GetFullName()] = r
+}
+
+// newRepository creates a repository with the default values.
+func (f *Fake) newRepository(repo *github.Repository) *fakeRepository {
+	repo = clone(repo)
+	if repo.GetDefaultBranch() == "" {
+		repo.DefaultBranch = github.Ptr("main")
+	}
+
+	if repo.FullName == nil {
+		repo.FullName = github.Ptr(repo.GetOwner().GetLogin() + "/" + repo.GetName())
+	}
+
+	if repo.GitURL == nil {
+		repo.GitURL = github.Ptr("git://github.com/" + repo.GetFullName() + ".git")
+	}
+
+	return &fakeRepository{
+		repo:        repo,
+		branches:    make(map[string]*github.Branch),
+		pulls:       make(map[int]*github.PullRequest),
+		issues:      make(map[int]*github.Issue),
+		reviews:     make(map[int][]*github.PullRequestReview),
+		commits:     make(map[int][]*github.RepositoryCommit),
+		comments:    make(map[int][]*github.IssueComment),
+		events:      make(map[int][]*github.IssueEvent),
+		checkRuns:   make(map[string][]*github.CheckRun),
+		checkSuites: make(map[string][]*github.CheckSuite),
+		statuses:    make(map[string][]*github.RepoStatus),
+		protections: make(map[string]*github.RequiredStatusChecks),
+		contents:    make(map[string]string),
+	}
+}
+
+// AddBranch adds a commit on a branch (created from the default branch if it doesn't exist),
+// and returns the SHA of its head.
+func (f *Fake) AddBranch(fullName, branch, message string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	if r.git == nil {
+		sha := f.nextSHA()
+		r.branches[branch] = newBranch(branch, sha, message)
+
+		return sha
+	}
+
+	sha, err := r.git.commit(branch, r.head(r.repo.GetDefaultBranch()), message)
+	if err != nil {
+		panic(fmt.Sprintf("ghapitest: %v", err))
+	}
+
+	return sha
+}
+
+// AddFile adds a commit writing a file on a branch (created from the default branch if it doesn't exist),
+// and returns the SHA of its head.
+// The repository must be backed by a bare repository.
+func (f *Fake) AddFile(fullName, branch, path, content, message string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustGitRepo(fullName)
+
+	sha, err := r.git.commitFile(branch, r.head(r.repo.GetDefaultBranch()), message, path, content)
+	if err != nil {
+		panic(fmt.Sprintf("ghapitest: %v", err))
+	}
+
+	return sha
+}
+
+// Branch gets the SHA of the head of a branch, empty if the branch doesn't exist.
+func (f *Fake) Branch(fullName, branch string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.mustRepo(fullName).head(branch)
+}
+
+// Log gets the subjects of the commits of a branch (newest first).
+// The repository must be backed by a bare repository.
+func (f *Fake) Log(fullName, branch string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	subjects, err := f.mustGitRepo(fullName).git.log("refs/heads/" + branch)
+	if err != nil {
+		panic(fmt.Sprintf("ghapitest: %v", err))
+	}
+
+	return subjects
+}
+
+// AddPullRequest adds an open pull request, and returns its number.
+// The missing fields are defaulted: number, base (default branch), head (a branch of the repository),
+// mergeable state (clean), and dates.
+func (f *Fake) AddPullRequest(fullName string, pr *github.PullRequest) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	pr = clone(pr)
+
+	if pr.Base == nil {
+		pr.Base = &github.PullRequestBranch{}
+	}
+
+	if pr.Number == nil {
+		pr.Number = github.Ptr(r.nextNumber())
+	}
+
+	if pr.Base.Ref == nil {
+		pr.Base.Ref = r.repo.DefaultBranch
+	}
+
+	if pr.Base.SHA == nil {
+		pr.Base.SHA = github.Ptr(r.head(pr.Base.GetRef()))
+	}
+
+	if pr.Head == nil {
+		pr.Head = &github.PullRequestBranch{}
+	}
+
+	if pr.Head.Ref == nil {
+		pr.Head.Ref = github.Ptr("pr-" + strconv.Itoa(pr.GetNumber()))
+	}
+
+	if pr.Head.Repo == nil {
+		pr.Head.Repo = clone(r.repo)
+	} else if h, found := f.repos[pr.Head.Repo.GetFullName()]; found {
+		pr.Head.Repo = clone(h.repo)
+	}
+
+	if h := f.repos[pr.Head.Repo.GetFullName()]; h != nil && h.git != nil && h.head(pr.Head.GetRef()) == "" {
+		_, err := h.git.commit(pr.Head.GetRef(), h.head(h.repo.GetDefaultBranch()), cmp.Or(pr.GetTitle(), "Pull request #"+strconv.Itoa(pr.GetNumber())))
+		if err != nil {
+			panic(fmt.Sprintf("ghapitest: %v", err))
+		}
+	}
+
+	if pr.Head.SHA == nil {
+		pr.Head.SHA = github.Ptr(f.nextSHA())
+	}
+
+	if pr.Head.User == nil {
+		pr.Head.User = pr.Head.Repo.GetOwner()
+	}
+
+	pr.Base.Repo = clone(r.repo)
+
+	if pr.State == nil {
+		pr.State = github.Ptr("open")
+	}
+
+	if pr.Mergeable == nil {
+		pr.Mergeable = github.Ptr(true)
+	}
+
+	if pr.MergeableState == nil {
+		pr.MergeableState = github.Ptr("clean")
+	}
+
+	now := github.Timestamp{Time: time.Now()}
+
+	if pr.CreatedAt == nil {
+		pr.CreatedAt = &now
+	}
+
+	if pr.UpdatedAt == nil {
+		pr.UpdatedAt = &now
+	}
+
+	for _, label := range pr.Labels {
+		r.addEvent(pr.GetNumber(), "labeled", label.GetName(), pr.GetCreatedAt().Time)
+	}
+
+	r.pulls[pr.GetNumber()] = pr
+
+	err := f.refresh(r, pr)
+	if err != nil {
+		panic(fmt.Sprintf("ghapitest: %v", err))
+	}
+
+	return pr.GetNumber()
+}
+
+// PullRequest gets a copy of a pull request, nil if the pull request doesn't exist.
+func (f *Fake) PullRequest(fullName string, number int) *github.PullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	pr, found := r.pulls[number]
+	if !found {
+		return nil
+	}
+
+	err := f.refresh(r, pr)
+	if err != nil {
+		panic(fmt.Sprintf("ghapitest: %v", err))
+	}
+
+	return clone(pr)
+}
+
+// AddIssue adds an open issue.
+func (f *Fake) AddIssue(fullName string, issue *github.Issue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	issue = clone(issue)
+	if issue.State == nil {
+		issue.State = github.Ptr("open")
+	}
+
+	f.mustRepo(fullName).issues[issue.GetNumber()] = issue
+}
+
+// Issue gets a copy of an issue, nil if the issue doesn't exist.
+func (f *Fake) Issue(fullName string, number int) *github.Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return clone(f.mustRepo(fullName).issues[number])
+}
+
+// Labels gets the names of the labels of a pull request (or an issue).
+func (f *Fake) Labels(fullName string, number int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for _, label := range *f.mustRepo(fullName).labels(number) {
+		names = append(names, label.GetName())
+	}
+
+	return names
+}
+
+// Comments gets the bodies of the comments of a pull request (or an issue).
+func (f *Fake) Comments(fullName string, number int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var bodies []string
+	for _, comment := range f.mustRepo(fullName).comments[number] {
+		bodies = append(bodies, comment.GetBody())
+	}
+
+	return bodies
+}
+
+// AddReview adds a review to a pull request.
+func (f *Fake) AddReview(fullName string, number int, login, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	r.reviews[number] = append(r.reviews[number], &github.PullRequestReview{
+		ID:    github.Ptr(int64(len(r.reviews[number]) + 1)),
+		User:  &github.User{Login: github.Ptr(login)},
+		State: github.Ptr(state),
+	})
+}
+
+// AddCommits adds commits to a pull request.
+// By default, a pull request has a single commit: its head.
+func (f *Fake) AddCommits(fullName string, number int, commits ...*github.RepositoryCommit) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	r.commits[number] = append(r.commits[number], cloneAll(commits)...)
+}
+
+// AddCheckRun adds a check run to a commit.
+func (f *Fake) AddCheckRun(fullName, sha string, run *github.CheckRun) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	r.checkRuns[sha] = append(r.checkRuns[sha], clone(run))
+}
+
+// AddCheckSuite adds a check suite to a commit.
+func (f *Fake) AddCheckSuite(fullName, sha string, suite *github.CheckSuite) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	r.checkSuites[sha] = append(r.checkSuites[sha], clone(suite))
+}
+
+// AddStatus adds a commit status to a commit.
+func (f *Fake) AddStatus(fullName, sha string, status *github.RepoStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := f.mustRepo(fullName)
+
+	r.statuses[sha] = append(r.statuses[sha], clone(status))
+}
+
+// Statuses gets the latest commit status of each context of a commit.
+func (f *Fake) Statuses(fullName, sha string) []*github.RepoStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return cloneAll(f.mustRepo(fullName).latestStatuses(sha))
+}
+
+// SetRequiredStatusChecks protects a branch with required status checks.
+func (f *Fake) SetRequiredStatusChecks(fullName, branch string, contexts ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mustRepo(fullName).protections[branch] = &github.RequiredStatusChecks{
+		Strict:   true,
+		Contexts: &contexts,
+	}
+}
+
+// SetContent sets the content of a file of the default branch.
+func (f *Fake) SetContent(fullName, path, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mustRepo(fullName).contents[path] = content
+}
+
+// Merges gets the merges made through the API.
+func (f *Fake) Merges(fullName string) []Merge {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.mustRepo(fullName).merges)
+}
+
+// Updates gets the pull requests updated through the API (update button).
+func (f *Fake) Updates(fullName string) []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.mustRepo(fullName).updates)
+}
+
+// mustRepo gets a repository, panics if the repository doesn't exist: it's a mistake in the test setup.
+func (f *Fake) mustRepo(fullName string) *fakeRepository {
+	r, ok := f.repos[fullName]
+	if !ok {
+		panic(fmt.Sprintf("ghapitest: unknown repository %q", fullName))
+	}
+
+	return r
+}
+
+// mustGitRepo gets a repository backed by a bare repository, panics otherwise.
+func (f *Fake) mustGitRepo(fullName string) *fakeRepository {
+	r := f.mustRepo(fullName)
+	if r.git == nil {
+		panic(fmt.Sprintf("ghapitest: the repository %q is not backed by a git repository", fullName))
+	}
+
+	return r
+}
+
+// refresh synchronizes an open pull request with the bare repositories:
+// like GitHub, the head of the pull request follows its branch, and is available as "refs/pull/<number>/head".
+func (f *Fake) refresh(r *fakeRepository, pr *github.PullRequest) error {
+	h := f.repos[pr.Head.Repo.GetFullName()]
+	if r.git == nil || h == nil || h.git == nil || pr.GetState() != "open" {
+		return nil
+	}
+
+	ref := pullRef(pr.GetNumber())
+
+	err := r.git.fetch(h.git, pr.Head.GetRef(), ref)
+	if err != nil {
+		return err
+	}
+
+	if sha := r.git.head(ref); sha != pr.Head.GetSHA() {
+		pr.Head.SHA = github.Ptr(sha)
+		r.touch(pr.GetNumber())
+	}
+
+	return nil
+}
+
+// pull gets a pull request, synchronized with the bare repositories, for a service.
+func (f *Fake) pull(r *fakeRepository, number int) (*github.PullRequest, *github.Response, error) {
+	pr, found := r.pulls[number]
+	if !found {
+		resp, err := notFound()
+		return nil, resp, err
+	}
+
+	err := f.refresh(r, pr)
+	if err != nil {
+		resp, err := internalError(err)
+		return nil, resp, err
+	}
+
+	return pr, nil, nil
+}
+
+// compare compares the base branch with the head of a pull request in a bare repository.
+func (f *Fake) compare(r *fakeRepository, pr *github.PullRequest) (*github.CommitsComparison, *github.Response, error) {
+	err := f.refresh(r, pr)
+	if err != nil {
+		resp, err := internalError(err)
+		return nil, resp, err
+	}
+
+	base := "refs/heads/" + pr.Base.GetRef()
+	head := pullRef(pr.GetNumber())
+
+	behindBy, err := r.git.count(head, base)
+	if err != nil {
+		resp, err := internalError(err)
+		return nil, resp, err
+	}
+
+	aheadBy, err := r.git.count(base, head)
+	if err != nil {
+		resp, err := internalError(err)
+		return nil, resp, err
+	}
+
+	mergeBase, err := r.git.run("merge-base", base, head)
+	if err != nil {
+		resp, err := internalError(err)
+		return nil, resp, err
+	}
+
+	return &github.CommitsComparison{
+		BehindBy:        github.Ptr(behindBy),
+		AheadBy:         github.Ptr(aheadBy),
+		MergeBaseCommit: &github.RepositoryCommit{SHA: github.Ptr(mergeBase)},
+	}, ok(), nil
+}
+
+// getRepo gets a repository for a service.
+func (f *Fake) getRepo(owner, name string) (*fakeRepository, *github.Response, error) {
+	r, ok := f.repos[owner+"/"+name]
+	if !ok {
+		resp, err := notFound()
+		return nil, resp, err
+	}
+
+	return r, nil, nil
+}
+
+// fail gets the error injected for a method.
+func (f *Fake) fail(method string) error {
+	return f.errors[method]
+}
+
+func (f *Fake) nextSHA() string {
+	f.lastSHA++
+
+	return fmt.Sprintf("%040x", f.lastSHA)
+}
+
+// branch gets a branch, nil if the branch doesn't exist.
+func (r *fakeRepository) branch(name string) *github.Branch {
+	if r.git == nil {
+		return r.branches[name]
+	}
+
+	sha := r.git.head("refs/heads/" + name)
+	if sha == "" {
+		return nil
+	}
+
+	return newBranch(name, sha, r.git.message(sha))
+}
+
+// head gets the SHA of the head of a branch, empty if the branch doesn't exist.
+func (r *fakeRepository) head(name string) string {
+	if r.git == nil {
+		return r.branches[name].GetCommit().GetSHA()
+	}
+
+	return r.git.head("refs/heads/" + name)
+}
+
+// moveBranch moves (or creates) a branch.
+func (r *fakeRepository) moveBranch(name, sha, message string) error {
+	if r.git != nil {
+		return r.git.setHead(name, sha)
+	}
+
+	r.branches[name] = newBranch(name, sha, message)
+
+	return nil
+}
+
+// deleteBranch deletes a branch, returns false if the branch doesn't exist.
+func (r *fakeRepository) deleteBranch(name string) (bool, error) {
+	if r.head(name) == "" {
+		return false, nil
+	}
+
+	if r.git != nil {
+		return true, r.git.deleteBranch(name)
+	}
+
+	delete(r.branches, name)
+
+	return true, nil
+}
+
+func newBranch(name, sha, message string) *github.Branch {
+	return &github.Branch{
+		Name: github.Ptr(name),
+		Commit: &github.RepositoryCommit{
+			SHA:    github.Ptr(sha),
+			Commit: &github.Commit{SHA: github.Ptr(sha), Message: github.Ptr(message)},
+		},
+	}
+}
+
+// pullRef gets the reference of the head of a pull request in the bare repository of its base.
+func pullRef(number int) string {
+	return "refs/pull/" + strconv.Itoa(number) + "/head"
+}
+
+// nextNumber gets the next number shared by the pull requests and the issues.
+func (r *fakeRepository) nextNumber() int {
+	number := 1
+	for n := range r.pulls {
+		number = max(number, n+1)
+	}
+
+	for n := range r.issues {
+		number = max(number, n+1)
+	}
+
+	return number
+}
+
+// labels gets the labels of a pull request or an issue.
+func (r *fakeRepository) labels(number int) *[]*github.Label {
+	if pr, ok := r.pulls[number]; ok {
+		return &pr.Labels
+	}
+
+	if issue, ok := r.issues[number]; ok {
+		return &issue.Labels
+	}
+
+	return &[]*github.Label{}
+}
+
+// touch updates the date of the last update of a pull request or an issue.
+func (r *fakeRepository) touch(number int) {
+	now := &github.Timestamp{Time: time.Now()}
+
+	if pr, ok := r.pulls[number]; ok {
+		pr.UpdatedAt = now
+	}
+
+	if issue, ok := r.issues[number]; ok {
+		issue.UpdatedAt = now
+	}
+}
+
+func (r *fakeRepository) exists(number int) bool {
+	_, isPR := r.pulls[number]
+	_, isIssue := r.issues[number]
+
+	return isPR || isIssue
+}
+
+func (r *fakeRepository) addEvent(number int, event, label string, at time.Time) {
+	r.events[number] = append(r.events[number], &github.IssueEvent{
+		Event:     github.Ptr(event),
+		Label:     &github.Label{Name: github.Ptr(label)},
+		CreatedAt: &github.Timestamp{Time: at},
+	})
+}
+
+// latestStatuses gets the latest status of each context, in the order of the first publication.
+func (r *fakeRepository) latestStatuses(sha string) []*github.RepoStatus {
+	var result []*github.RepoStatus
+
+	index := make(map[string]int)
+	for _, status := range r.statuses[sha] {
+		if i, ok := index[status.GetContext()]; ok {
+			result[i] = status
+			continue
+		}
+
+		index[status.GetContext()] = len(result)
+		result = append(result, status)
+	}
+
+	return result
+}
+
+// toIssue converts a pull request into the issue of a search result.
+func (r *fakeRepository) toIssue(pr *github.PullRequest) *github.Issue {
+	return &github.Issue{
+		Number:        pr.Number,
+		Title:         pr.Title,
+		Body:          pr.Body,
+		State:         pr.State,
+		Labels:        cloneAll(pr.Labels),
+		Milestone:     clone(pr.Milestone),
+		User:          clone(pr.User),
+		CreatedAt:     pr.CreatedAt,
+		UpdatedAt:     pr.UpdatedAt,
+		RepositoryURL: github.Ptr("https://api.github.com/repos/" + r.repo.GetFullName()),
+		PullRequestLinks: &github.PullRequestLinks{
+			URL: github.Ptr("https://api.github.com/repos/" + r.repo.GetFullName() + "/pulls/" + strconv.Itoa(pr.GetNumber())),
+		},
+	}
+}
+
+func hasLabel(labels []*github.Label, name string) bool {
+	return slices.ContainsFunc(labels, func(label *github.Label) bool {
+		return strings.EqualFold(label.GetName(), name)
+	})
+}
+
+func ok() *github.Response {
+	return response(http.StatusOK)
+}
+
+func response(status int) *github.Response {
+	return &github.Response{Response: &http.Response{StatusCode: status, Header: http.Header{}}}
+}
+
+func notFound() (*github.Response, error) {
+	return errorResponse(http.StatusNotFound, "Not Found")
+}
+
+func internalError(err error) (*github.Response, error) {
+	return errorResponse(http.StatusInternalServerError, err.Error())
+}
+
+func errorResponse(status int, message string) (*github.Response, error) {
+	resp := response(status)
+
+	return resp, &github.ErrorResponse{Response: resp.Response, Message: message}
+}
+
+// clone deep copies a GitHub object.
+func clone[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	var result T
+
+	err = json.Unmarshal(raw, &result)
+	if err != nil {
+		panic(err)
+	}
+
+	return &result
+}
+
+func cloneAll[T any](values []*T) []*T {
+	if values == nil {
+		return nil
+	}
+
+	result := make([]*T, 0, len(values))
+	for _, value := range values {
+		result = append(result, clone(value))
+	}
+
+	return result
+}
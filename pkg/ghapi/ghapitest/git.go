@@ -0,0 +1,320 @@
+package ghapitest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// gitScheme the scheme of the URLs of the repositories backed by a local bare repository.
+const gitScheme = "file://"
+
+// errConflict the merge of two commits has conflicts.
+var errConflict = errors.New("merge conflict")
+
+// gitRepository a local bare repository.
+// The bare repository is the source of truth of the branches: the pushes made by the code under test are visible through the API.
+type gitRepository struct {
+	dir string
+}
+
+// newGitRepository opens a bare repository, or creates it with an initial commit on the default branch.
+func newGitRepository(url, defaultBranch string) (*gitRepository, error) {
+	g := &gitRepository{dir: strings.TrimPrefix(url, gitScheme)}
+
+	if _, err := os.Stat(filepath.Join(g.dir, "HEAD")); err == nil {
+		return g, nil
+	}
+
+	output, err := exec.Command("git", "init", "--bare", "--initial-branch="+defaultBranch, g.dir).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git init: %w: %s", err, output)
+	}
+
+	_, err = g.commit(defaultBranch, "", "Initial commit")
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// fork creates a bare copy of the repository.
+func (g *gitRepository) fork(url string) (*gitRepository, error) {
+	dir := strings.TrimPrefix(url, gitScheme)
+
+	output, err := exec.Command("git", "clone", "--bare", "--quiet", g.dir, dir).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git clone: %w: %s", err, output)
+	}
+
+	return &gitRepository{dir: dir}, nil
+}
+
+// run runs a git command in the bare repository.
+func (g *gitRepository) run(args ...string) (string, error) {
+	return g.input("", args...)
+}
+
+// input runs a git command, with an input, in the bare repository.
+func (g *gitRepository) input(stdin string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", g.dir}, args...)...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=ghapitest", "GIT_AUTHOR_EMAIL=ghapitest@example.com",
+		"GIT_COMMITTER_NAME=ghapitest", "GIT_COMMITTER_EMAIL=ghapitest@example.com",
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return string(output), fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, exitErr.Stderr)
+		}
+
+		return string(output), fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// head gets the SHA of a reference, empty if the reference doesn't exist.
+func (g *gitRepository) head(ref string) string {
+	sha, err := g.run("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return ""
+	}
+
+	return sha
+}
+
+// message gets the message of a commit.
+func (g *gitRepository) message(sha string) string {
+	message, err := g.run("log", "-1", "--format=%B", sha)
+	if err != nil {
+		return ""
+	}
+
+	return message
+}
+
+// branches gets the names of the branches.
+func (g *gitRepository) branches() []string {
+	output, err := g.run("for-each-ref", "--format=%(refname:lstrip=2)", "refs/heads/")
+	if err != nil || output == "" {
+		return nil
+	}
+
+	return strings.Split(output, "\n")
+}
+
+// commit adds a commit on the top of a branch (or of the parent if the branch doesn't exist).
+// The commit writes a file: if the path is empty, the file is named after its content (the message).
+func (g *gitRepository) commit(branch, parent, message string) (string, error) {
+	return g.commitFile(branch, parent, message, "", message)
+}
+
+func (g *gitRepository) commitFile(branch, parent, message, path, content string) (string, error) {
+	if sha := g.head("refs/heads/" + branch); sha != "" {
+		parent = sha
+	}
+
+	blob, err := g.input(content+"\n", "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+
+	if path == "" {
+		path = blob[:12] + ".txt"
+	}
+
+	// replaces the file in the tree of the parent.
+	var entries []string
+	if parent != "" {
+		output, errTree := g.run("ls-tree", parent)
+		if errTree != nil {
+			return "", errTree
+		}
+
+		for entry := range strings.SplitSeq(output, "\n") {
+			if entry != "" && !strings.HasSuffix(entry, "\t"+path) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	entries = append(entries, fmt.Sprintf("100644 blob %s\t%s", blob, path))
+
+	tree, err := g.input(strings.Join(entries, "\n")+"\n", "mktree")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+
+	sha, err := g.run(append(args, tree)...)
+	if err != nil {
+		return "", err
+	}
+
+	return sha, g.setHead(branch, sha)
+}
+
+// setHead moves (or creates) a branch.
+func (g *gitRepository) setHead(branch, sha string) error {
+	_, err := g.run("update-ref", "refs/heads/"+branch, sha)
+	return err
+}
+
+// deleteBranch deletes a branch.
+func (g *gitRepository) deleteBranch(branch string) error {
+	_, err := g.run("update-ref", "-d", "refs/heads/"+branch)
+	return err
+}
+
+// fetch fetches a branch of another bare repository into a reference.
+func (g *gitRepository) fetch(from *gitRepository, branch, ref string) error {
+	_, err := g.run("fetch", "--quiet", "--no-tags", from.dir, fmt.Sprintf("+refs/heads/%s:%s", branch, ref))
+	return err
+}
+
+// count counts the commits reachable from a reference but not from another one.
+func (g *gitRepository) count(from, to string) (int, error) {
+	output, err := g.run("rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(output)
+}
+
+// commits gets the SHA and the subject of the commits reachable from a reference but not from another one (oldest first).
+func (g *gitRepository) commits(from, to string) ([][2]string, error) {
+	output, err := g.run("log", "--reverse", "--format=%H %s", from+".."+to)
+	if err != nil || output == "" {
+		return nil, err
+	}
+
+	var result [][2]string
+	for line := range strings.SplitSeq(output, "\n") {
+		sha, subject, _ := strings.Cut(line, " ")
+		result = append(result, [2]string{sha, subject})
+	}
+
+	return result, nil
+}
+
+// mergeTree merges two commits without a working tree, and returns the resulting tree.
+func (g *gitRepository) mergeTree(ours, theirs string) (string, error) {
+	output, err := g.run("merge-tree", "--write-tree", "--no-messages", ours, theirs)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", errConflict
+		}
+
+		return "", err
+	}
+
+	tree, _, _ := strings.Cut(output, "\n")
+
+	return tree, nil
+}
+
+// merge merges a commit into a branch like the GitHub merge methods (merge, squash, and rebase),
+// and returns the new head of the branch.
+// The rebase method requires a branch up-to-date: the commits are replayed without a merge.
+func (g *gitRepository) merge(method, branch, head, title, message string) (string, error) {
+	base := g.head("refs/heads/" + branch)
+
+	var sha string
+
+	switch method {
+	case "rebase":
+		behindBy, err := g.count(head, base)
+		if err != nil {
+			return "", err
+		}
+
+		if behindBy > 0 {
+			return "", errConflict
+		}
+
+		commits, err := g.commits(base, head)
+		if err != nil {
+			return "", err
+		}
+
+		sha = base
+		for _, commit := range commits {
+			sha, err = g.run("commit-tree", "-p", sha, "-m", g.message(commit[0]), commit[0]+"^{tree}")
+			if err != nil {
+				return "", err
+			}
+		}
+
+	default:
+		tree, err := g.mergeTree(base, head)
+		if err != nil {
+			return "", err
+		}
+
+		args := []string{"commit-tree", "-p", base}
+		if method != "squash" {
+			args = append(args, "-p", head)
+		}
+
+		args = append(args, "-m", title)
+		if strings.TrimSpace(message) != "" {
+			args = append(args, "-m", message)
+		}
+
+		sha, err = g.run(append(args, tree)...)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return sha, g.setHead(branch, sha)
+}
+
+// update merges a branch of another bare repository into a branch (update button).
+func (g *gitRepository) update(branch string, from *gitRepository, fromBranch string) error {
+	ref := "refs/ghapitest/" + fromBranch
+
+	err := g.fetch(from, fromBranch, ref)
+	if err != nil {
+		return err
+	}
+
+	head := g.head("refs/heads/" + branch)
+
+	tree, err := g.mergeTree(head, ref)
+	if err != nil {
+		return err
+	}
+
+	sha, err := g.run("commit-tree", "-p", head, "-p", ref, "-m", fmt.Sprintf("Merge branch '%s' into %s", fromBranch, branch), tree)
+	if err != nil {
+		return err
+	}
+
+	return g.setHead(branch, sha)
+}
+
+// log gets the subjects of the commits reachable from a reference (newest first).
+func (g *gitRepository) log(ref string) ([]string, error) {
+	output, err := g.run("log", "--topo-order", "--format=%s", ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(output, "\n"), nil
+}
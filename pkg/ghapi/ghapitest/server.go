@@ -0,0 +1,261 @@
+package ghapitest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+// handlerFunc handles a request with the services of the fake.
+type handlerFunc func(req *http.Request) (any, *github.Response, error)
+
+// NewServer creates a server serving the GitHub REST API (the endpoints used by the bot) backed by the fake.
+// The server must be closed by the caller.
+func NewServer(fake *Fake) *httptest.Server {
+	client := fake.Client()
+
+	mux := http.NewServeMux()
+
+	handlePullRequests(mux, client)
+	handleIssues(mux, client)
+	handleRepositories(mux, client)
+	handleOthers(mux, client)
+
+	mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, http.StatusNotFound, "Not Found: "+req.Method+" "+req.URL.Path)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// NewClient creates a GitHub client for a server created by NewServer.
+func NewClient(server *httptest.Server) *github.Client {
+	client := github.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	return client
+}
+
+func handlePullRequests(mux *http.ServeMux, client *ghapi.Client) {
+	handle(mux, "GET /repos/{owner}/{repo}/pulls/{number}", func(req *http.Request) (any, *github.Response, error) {
+		return client.PullRequests.Get(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req))
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/pulls/{number}/commits", func(req *http.Request) (any, *github.Response, error) {
+		return client.PullRequests.ListCommits(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), nil)
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/pulls/{number}/reviews", func(req *http.Request) (any, *github.Response, error) {
+		return client.PullRequests.ListReviews(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), nil)
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/commits/{sha}/pulls", func(req *http.Request) (any, *github.Response, error) {
+		return client.PullRequests.ListPullRequestsWithCommit(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("sha"), nil)
+	})
+
+	handle(mux, "PUT /repos/{owner}/{repo}/pulls/{number}/merge", func(req *http.Request) (any, *github.Response, error) {
+		var body struct {
+			CommitMessage string `json:"commit_message"`
+			CommitTitle   string `json:"commit_title"`
+			MergeMethod   string `json:"merge_method"`
+		}
+
+		if err := decode(req, &body); err != nil {
+			return nil, nil, err
+		}
+
+		options := &github.PullRequestOptions{CommitTitle: body.CommitTitle, MergeMethod: body.MergeMethod}
+
+		return client.PullRequests.Merge(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), body.CommitMessage, options)
+	})
+
+	handle(mux, "PUT /repos/{owner}/{repo}/pulls/{number}/update-branch", func(req *http.Request) (any, *github.Response, error) {
+		return client.PullRequests.UpdateBranch(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), nil)
+	})
+}
+
+func handleIssues(mux *http.ServeMux, client *ghapi.Client) {
+	handle(mux, "GET /repos/{owner}/{repo}/issues/{number}", func(req *http.Request) (any, *github.Response, error) {
+		return client.Issues.Get(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req))
+	})
+
+	handle(mux, "PATCH /repos/{owner}/{repo}/issues/{number}", func(req *http.Request) (any, *github.Response, error) {
+		request := &github.IssueRequest{}
+		if err := decode(req, request); err != nil {
+			return nil, nil, err
+		}
+
+		return client.Issues.Edit(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), request)
+	})
+
+	handle(mux, "POST /repos/{owner}/{repo}/issues/{number}/labels", func(req *http.Request) (any, *github.Response, error) {
+		var names []string
+		if err := decode(req, &names); err != nil {
+			return nil, nil, err
+		}
+
+		return client.Issues.AddLabelsToIssue(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), names)
+	})
+
+	handle(mux, "PUT /repos/{owner}/{repo}/issues/{number}/labels", func(req *http.Request) (any, *github.Response, error) {
+		var names []string
+		if err := decode(req, &names); err != nil {
+			return nil, nil, err
+		}
+
+		return client.Issues.ReplaceLabelsForIssue(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), names)
+	})
+
+	handle(mux, "DELETE /repos/{owner}/{repo}/issues/{number}/labels/{name...}", func(req *http.Request) (any, *github.Response, error) {
+		resp, err := client.Issues.RemoveLabelForIssue(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), req.PathValue("name"))
+		return nil, resp, err
+	})
+
+	handle(mux, "POST /repos/{owner}/{repo}/issues/{number}/comments", func(req *http.Request) (any, *github.Response, error) {
+		comment := &github.IssueComment{}
+		if err := decode(req, comment); err != nil {
+			return nil, nil, err
+		}
+
+		return client.Issues.CreateComment(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), comment)
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/issues/{number}/events", func(req *http.Request) (any, *github.Response, error) {
+		return client.Issues.ListIssueEvents(req.Context(), req.PathValue("owner"), req.PathValue("repo"), number(req), nil)
+	})
+}
+
+func handleRepositories(mux *http.ServeMux, client *ghapi.Client) {
+	handle(mux, "GET /repos/{owner}/{repo}", func(req *http.Request) (any, *github.Response, error) {
+		return client.Repositories.Get(req.Context(), req.PathValue("owner"), req.PathValue("repo"))
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/branches/{branch}", func(req *http.Request) (any, *github.Response, error) {
+		return client.Repositories.GetBranch(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("branch"), 1)
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/branches/{branch}/protection/required_status_checks", func(req *http.Request) (any, *github.Response, error) {
+		return client.Repositories.GetRequiredStatusChecks(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("branch"))
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/contents/{path...}", func(req *http.Request) (any, *github.Response, error) {
+		content, _, resp, err := client.Repositories.GetContents(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("path"), nil)
+		return content, resp, err
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/compare/{basehead...}", func(req *http.Request) (any, *github.Response, error) {
+		base, head, _ := strings.Cut(req.PathValue("basehead"), "...")
+
+		return client.Repositories.CompareCommits(req.Context(), req.PathValue("owner"), req.PathValue("repo"), base, head, nil)
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/commits/{ref}/status", func(req *http.Request) (any, *github.Response, error) {
+		return client.Repositories.GetCombinedStatus(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("ref"), nil)
+	})
+
+	handle(mux, "POST /repos/{owner}/{repo}/statuses/{ref}", func(req *http.Request) (any, *github.Response, error) {
+		status := &github.RepoStatus{}
+		if err := decode(req, status); err != nil {
+			return nil, nil, err
+		}
+
+		return client.Repositories.CreateStatus(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("ref"), status)
+	})
+}
+
+func handleOthers(mux *http.ServeMux, client *ghapi.Client) {
+	handle(mux, "GET /repos/{owner}/{repo}/commits/{ref}/check-runs", func(req *http.Request) (any, *github.Response, error) {
+		return client.Checks.ListCheckRunsForRef(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("ref"), nil)
+	})
+
+	handle(mux, "GET /repos/{owner}/{repo}/commits/{ref}/check-suites", func(req *http.Request) (any, *github.Response, error) {
+		return client.Checks.ListCheckSuitesForRef(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("ref"), nil)
+	})
+
+	handle(mux, "GET /search/issues", func(req *http.Request) (any, *github.Response, error) {
+		return client.Search.Issues(req.Context(), req.URL.Query().Get("q"), nil)
+	})
+
+	handle(mux, "PATCH /repos/{owner}/{repo}/git/refs/{ref...}", func(req *http.Request) (any, *github.Response, error) {
+		var body struct {
+			SHA   string `json:"sha"`
+			Force bool   `json:"force"`
+		}
+
+		if err := decode(req, &body); err != nil {
+			return nil, nil, err
+		}
+
+		ref := &github.Reference{
+			Ref:    github.Ptr("refs/" + req.PathValue("ref")),
+			Object: &github.GitObject{SHA: github.Ptr(body.SHA)},
+		}
+
+		return client.Git.UpdateRef(req.Context(), req.PathValue("owner"), req.PathValue("repo"), ref, body.Force)
+	})
+
+	handle(mux, "DELETE /repos/{owner}/{repo}/git/refs/{ref...}", func(req *http.Request) (any, *github.Response, error) {
+		resp, err := client.Git.DeleteRef(req.Context(), req.PathValue("owner"), req.PathValue("repo"), req.PathValue("ref"))
+		return nil, resp, err
+	})
+}
+
+func handle(mux *http.ServeMux, pattern string, fn handlerFunc) {
+	mux.HandleFunc(pattern, func(rw http.ResponseWriter, req *http.Request) {
+		value, resp, err := fn(req)
+
+		var acceptedErr *github.AcceptedError
+		var errorResp *github.ErrorResponse
+
+		switch {
+		case errors.As(err, &acceptedErr):
+			writeJSON(rw, http.StatusAccepted, struct{}{})
+
+		case errors.As(err, &errorResp):
+			writeError(rw, errorResp.Response.StatusCode, errorResp.Message)
+
+		case err != nil:
+			writeError(rw, http.StatusInternalServerError, err.Error())
+
+		case value == nil || resp.StatusCode == http.StatusNoContent:
+			rw.WriteHeader(resp.StatusCode)
+
+		default:
+			writeJSON(rw, resp.StatusCode, value)
+		}
+	})
+}
+
+// number gets the number of a pull request or of an issue, 0 if the number is invalid.
+func number(req *http.Request) int {
+	n, _ := strconv.Atoi(req.PathValue("number"))
+	return n
+}
+
+func decode(req *http.Request, value any) error {
+	err := json.NewDecoder(req.Body).Decode(value)
+	if err != nil {
+		resp := response(http.StatusBadRequest)
+		return &github.ErrorResponse{Response: resp.Response, Message: "Problems parsing JSON"}
+	}
+
+	return nil
+}
+
+func writeError(rw http.ResponseWriter, status int, message string) {
+	writeJSON(rw, status, map[string]string{"message": message})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, value any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	_ = json.NewEncoder(rw).Encode(value)
+}
@@ -0,0 +1,133 @@
+package ghapitest
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_merge(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		method         string
+		conflict       bool
+		expectedStatus int
+		expectedLog    []string
+	}{
+		{
+			desc:           "merge",
+			method:         "merge",
+			expectedStatus: http.StatusOK,
+			expectedLog:    []string{"Fix the bug in the router", "Fix the tests", "Fix the bug", "Initial commit"},
+		},
+		{
+			desc:           "squash",
+			method:         "squash",
+			expectedStatus: http.StatusOK,
+			expectedLog:    []string{"Fix the bug in the router", "Initial commit"},
+		},
+		{
+			desc:           "rebase",
+			method:         "rebase",
+			expectedStatus: http.StatusOK,
+			expectedLog:    []string{"Fix the tests", "Fix the bug", "Initial commit"},
+		},
+		{
+			desc:           "conflict",
+			method:         "merge",
+			conflict:       true,
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedLog:    []string{"Another change", "Initial commit"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := New()
+
+			fake.AddRepository(&github.Repository{
+				Owner:  &github.User{Login: github.Ptr("traefik")},
+				Name:   github.Ptr("traefik"),
+				GitURL: github.Ptr("file://" + filepath.Join(t.TempDir(), "traefik.git")),
+			})
+
+			fake.AddFile("traefik/traefik", "feature", "README.md", "feature", "Fix the bug")
+			fake.AddBranch("traefik/traefik", "feature", "Fix the tests")
+
+			if test.conflict {
+				fake.AddFile("traefik/traefik", "main", "README.md", "main", "Another change")
+			}
+
+			fake.AddPullRequest("traefik/traefik", &github.PullRequest{
+				Title: github.Ptr("Fix the bug in the router"),
+				Head:  &github.PullRequestBranch{Ref: github.Ptr("feature")},
+			})
+
+			server := NewServer(fake)
+			t.Cleanup(server.Close)
+
+			client := NewClient(server)
+
+			options := &github.PullRequestOptions{MergeMethod: test.method}
+
+			_, resp, err := client.PullRequests.Merge(t.Context(), "traefik", "traefik", 1, "", options)
+			require.NotNil(t, resp)
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			assert.Equal(t, test.expectedLog, fake.Log("traefik/traefik", "main"))
+
+			if test.expectedStatus != http.StatusOK {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			pr, _, err := client.PullRequests.Get(t.Context(), "traefik", "traefik", 1)
+			require.NoError(t, err)
+
+			assert.True(t, pr.GetMerged())
+			assert.Equal(t, fake.Branch("traefik/traefik", "main"), pr.GetMergeCommitSHA())
+		})
+	}
+}
+
+func TestServer_labels(t *testing.T) {
+	fake := New()
+
+	fake.AddRepository(&github.Repository{
+		Owner: &github.User{Login: github.Ptr("traefik")},
+		Name:  github.Ptr("traefik"),
+	})
+
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{
+		Labels: []*github.Label{{Name: github.Ptr("status/3-needs-merge")}},
+	})
+
+	server := NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := NewClient(server)
+
+	_, _, err := client.Issues.AddLabelsToIssue(t.Context(), "traefik", "traefik", 1, []string{"status/4-merge-in-progress"})
+	require.NoError(t, err)
+
+	_, err = client.Issues.RemoveLabelForIssue(t.Context(), "traefik", "traefik", 1, "status/3-needs-merge")
+	require.NoError(t, err)
+
+	resp, err := client.Issues.RemoveLabelForIssue(t.Context(), "traefik", "traefik", 1, "status/3-needs-merge")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	result, _, err := client.Search.Issues(t.Context(), "user:traefik type:pr state:open label:status/4-merge-in-progress", nil)
+	require.NoError(t, err)
+
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, []string{"status/4-merge-in-progress"}, fake.Labels("traefik/traefik", 1))
+}
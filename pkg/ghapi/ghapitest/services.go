@@ -0,0 +1,875 @@
+package ghapitest
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+var (
+	_ ghapi.PullRequestsService = (*pullRequests)(nil)
+	_ ghapi.IssuesService       = (*issues)(nil)
+	_ ghapi.ChecksService       = (*checks)(nil)
+	_ ghapi.RepositoriesService = (*repositories)(nil)
+	_ ghapi.SearchService       = (*search)(nil)
+	_ ghapi.GitService          = (*gitService)(nil)
+)
+
+type pullRequests struct {
+	fake *Fake
+}
+
+func (s *pullRequests) Get(_ context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("PullRequests.Get"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	pr, resp, err := s.fake.pull(r, number)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return clone(pr), ok(), nil
+}
+
+func (s *pullRequests) ListCommits(_ context.Context, owner, repo string, number int, _ *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("PullRequests.ListCommits"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	pr, resp, err := s.fake.pull(r, number)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if commits, found := r.commits[number]; found {
+		return cloneAll(commits), ok(), nil
+	}
+
+	if r.git == nil {
+		return []*github.RepositoryCommit{{SHA: pr.Head.SHA}}, ok(), nil
+	}
+
+	commits, err := r.git.commits("refs/heads/"+pr.Base.GetRef(), pullRef(number))
+	if err != nil {
+		resp, err = internalError(err)
+		return nil, resp, err
+	}
+
+	var result []*github.RepositoryCommit
+	for _, commit := range commits {
+		result = append(result, &github.RepositoryCommit{
+			SHA:    github.Ptr(commit[0]),
+			Commit: &github.Commit{SHA: github.Ptr(commit[0]), Message: github.Ptr(commit[1])},
+		})
+	}
+
+	return result, ok(), nil
+}
+
+func (s *pullRequests) ListPullRequestsWithCommit(_ context.Context, owner, repo, sha string, _ *github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("PullRequests.ListPullRequestsWithCommit"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var result []*github.PullRequest
+	for _, pr := range r.pulls {
+		err = s.fake.refresh(r, pr)
+		if err != nil {
+			resp, err = internalError(err)
+			return nil, resp, err
+		}
+
+		if pr.Head.GetSHA() == sha {
+			result = append(result, clone(pr))
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *github.PullRequest) int { return cmp.Compare(a.GetNumber(), b.GetNumber()) })
+
+	return result, ok(), nil
+}
+
+func (s *pullRequests) ListReviews(_ context.Context, owner, repo string, number int, _ *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("PullRequests.ListReviews"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return cloneAll(r.reviews[number]), ok(), nil
+}
+
+// Merge merges an open and mergeable pull request: the base branch moves to a new commit.
+// With a bare repository, the merge is a real merge (a merge commit, a squashed commit, or the rebased commits);
+// the rebase requires an up-to-date branch.
+func (s *pullRequests) Merge(_ context.Context, owner, repo string, number int, commitMessage string, options *github.PullRequestOptions) (*github.PullRequestMergeResult, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("PullRequests.Merge"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	pr, resp, err := s.fake.pull(r, number)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if pr.GetState() != "open" || pr.GetMerged() || !pr.GetMergeable() {
+		resp, err = errorResponse(http.StatusMethodNotAllowed, "Pull Request is not mergeable")
+		return nil, resp, err
+	}
+
+	merge := Merge{Number: number, Message: commitMessage}
+	if options != nil {
+		merge.Method = options.MergeMethod
+		merge.Title = options.CommitTitle
+	}
+
+	title := cmp.Or(merge.Title, pr.GetTitle())
+
+	if r.git == nil {
+		merge.SHA = s.fake.nextSHA()
+		err = r.moveBranch(pr.Base.GetRef(), merge.SHA, title)
+	} else {
+		merge.SHA, err = r.git.merge(cmp.Or(merge.Method, "merge"), pr.Base.GetRef(), pullRef(number), title, commitMessage)
+	}
+
+	if errors.Is(err, errConflict) {
+		resp, err = errorResponse(http.StatusMethodNotAllowed, "Merge conflict")
+		return nil, resp, err
+	}
+
+	if err != nil {
+		resp, err = internalError(err)
+		return nil, resp, err
+	}
+
+	sha := merge.SHA
+
+	r.merges = append(r.merges, merge)
+
+	pr.Merged = github.Ptr(true)
+	pr.State = github.Ptr("closed")
+	pr.MergeCommitSHA = github.Ptr(sha)
+	pr.MergedAt = &github.Timestamp{Time: time.Now()}
+	r.touch(number)
+
+	return &github.PullRequestMergeResult{
+		SHA:     github.Ptr(sha),
+		Merged:  github.Ptr(true),
+		Message: github.Ptr("Pull Request successfully merged"),
+	}, ok(), nil
+}
+
+// UpdateBranch merges the base branch into the head branch of a pull request (update button).
+// Like GitHub, the update is accepted (202): go-github returns an AcceptedError.
+// With bare repositories, the base branch is merged into the head branch.
+func (s *pullRequests) UpdateBranch(_ context.Context, owner, repo string, number int, _ *github.PullRequestBranchUpdateOptions) (*github.PullRequestBranchUpdateResponse, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("PullRequests.UpdateBranch"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	pr, resp, err := s.fake.pull(r, number)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	pr.Base.SHA = github.Ptr(r.head(pr.Base.GetRef()))
+
+	h := s.fake.repos[pr.Head.Repo.GetFullName()]
+	if r.git == nil || h == nil || h.git == nil {
+		pr.Head.SHA = github.Ptr(s.fake.nextSHA())
+	} else {
+		err = h.git.update(pr.Head.GetRef(), r.git, pr.Base.GetRef())
+		if errors.Is(err, errConflict) {
+			resp, err = errorResponse(http.StatusUnprocessableEntity, "Merge conflict")
+			return nil, resp, err
+		}
+
+		if err == nil {
+			err = s.fake.refresh(r, pr)
+		}
+
+		if err != nil {
+			resp, err = internalError(err)
+			return nil, resp, err
+		}
+	}
+
+	r.touch(number)
+
+	r.updates = append(r.updates, number)
+
+	return nil, response(http.StatusAccepted), &github.AcceptedError{}
+}
+
+type issues struct {
+	fake *Fake
+}
+
+// Get gets an issue, or the issue of a pull request.
+func (s *issues) Get(_ context.Context, owner, repo string, number int) (*github.Issue, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.Get"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if pr, found := r.pulls[number]; found {
+		return r.toIssue(pr), ok(), nil
+	}
+
+	if issue, found := r.issues[number]; found {
+		return clone(issue), ok(), nil
+	}
+
+	resp, err = notFound()
+
+	return nil, resp, err
+}
+
+// Edit edits the state, the milestone, and the labels of an issue, or of a pull request.
+func (s *issues) Edit(_ context.Context, owner, repo string, number int, request *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.Edit"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if !r.exists(number) {
+		resp, err = notFound()
+		return nil, resp, err
+	}
+
+	var milestone *github.Milestone
+	if request.Milestone != nil {
+		milestone = &github.Milestone{Number: request.Milestone}
+	}
+
+	if pr, found := r.pulls[number]; found {
+		pr.State = cmp.Or(request.State, pr.State)
+		pr.Milestone = cmp.Or(milestone, pr.Milestone)
+	}
+
+	if issue, found := r.issues[number]; found {
+		issue.State = cmp.Or(request.State, issue.State)
+		issue.Milestone = cmp.Or(milestone, issue.Milestone)
+	}
+
+	if request.Labels != nil {
+		r.replaceLabels(number, *request.Labels)
+	}
+
+	r.touch(number)
+
+	if pr, found := r.pulls[number]; found {
+		return r.toIssue(pr), ok(), nil
+	}
+
+	return clone(r.issues[number]), ok(), nil
+}
+
+func (s *issues) AddLabelsToIssue(_ context.Context, owner, repo string, number int, names []string) ([]*github.Label, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.AddLabelsToIssue"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if !r.exists(number) {
+		resp, err = notFound()
+		return nil, resp, err
+	}
+
+	labels := r.labels(number)
+
+	for _, name := range names {
+		if hasLabel(*labels, name) {
+			continue
+		}
+
+		*labels = append(*labels, &github.Label{Name: github.Ptr(name)})
+		r.addEvent(number, "labeled", name, time.Now())
+	}
+
+	r.touch(number)
+
+	return cloneAll(*labels), ok(), nil
+}
+
+func (s *issues) RemoveLabelForIssue(_ context.Context, owner, repo string, number int, name string) (*github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.RemoveLabelForIssue"); err != nil {
+		return nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return resp, err
+	}
+
+	labels := r.labels(number)
+
+	if !hasLabel(*labels, name) {
+		return errorResponse(http.StatusNotFound, "Label does not exist")
+	}
+
+	*labels = slices.DeleteFunc(*labels, func(label *github.Label) bool {
+		return strings.EqualFold(label.GetName(), name)
+	})
+
+	r.addEvent(number, "unlabeled", name, time.Now())
+	r.touch(number)
+
+	return ok(), nil
+}
+
+func (s *issues) ReplaceLabelsForIssue(_ context.Context, owner, repo string, number int, names []string) ([]*github.Label, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.ReplaceLabelsForIssue"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if !r.exists(number) {
+		resp, err = notFound()
+		return nil, resp, err
+	}
+
+	r.replaceLabels(number, names)
+	r.touch(number)
+
+	return cloneAll(*r.labels(number)), ok(), nil
+}
+
+func (r *fakeRepository) replaceLabels(number int, names []string) {
+	labels := r.labels(number)
+
+	for _, label := range *labels {
+		if !slices.Contains(names, label.GetName()) {
+			r.addEvent(number, "unlabeled", label.GetName(), time.Now())
+		}
+	}
+
+	var result []*github.Label
+	for _, name := range names {
+		if !hasLabel(*labels, name) {
+			r.addEvent(number, "labeled", name, time.Now())
+		}
+
+		result = append(result, &github.Label{Name: github.Ptr(name)})
+	}
+
+	*labels = result
+}
+
+func (s *issues) CreateComment(_ context.Context, owner, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.CreateComment"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if !r.exists(number) {
+		resp, err = notFound()
+		return nil, resp, err
+	}
+
+	comment = clone(comment)
+	comment.ID = github.Ptr(int64(len(r.comments[number]) + 1))
+	comment.CreatedAt = &github.Timestamp{Time: time.Now()}
+
+	r.comments[number] = append(r.comments[number], comment)
+	r.touch(number)
+
+	return clone(comment), response(http.StatusCreated), nil
+}
+
+func (s *issues) ListIssueEvents(_ context.Context, owner, repo string, number int, _ *github.ListOptions) ([]*github.IssueEvent, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Issues.ListIssueEvents"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return cloneAll(r.events[number]), ok(), nil
+}
+
+type checks struct {
+	fake *Fake
+}
+
+func (s *checks) ListCheckRunsForRef(_ context.Context, owner, repo, ref string, _ *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Checks.ListCheckRunsForRef"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	runs := cloneAll(r.checkRuns[r.resolve(ref)])
+
+	return &github.ListCheckRunsResults{Total: github.Ptr(len(runs)), CheckRuns: runs}, ok(), nil
+}
+
+func (s *checks) ListCheckSuitesForRef(_ context.Context, owner, repo, ref string, _ *github.ListCheckSuiteOptions) (*github.ListCheckSuiteResults, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Checks.ListCheckSuitesForRef"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	suites := cloneAll(r.checkSuites[r.resolve(ref)])
+
+	return &github.ListCheckSuiteResults{Total: github.Ptr(len(suites)), CheckSuites: suites}, ok(), nil
+}
+
+// resolve gets the SHA of a reference (branch or SHA).
+func (r *fakeRepository) resolve(ref string) string {
+	if sha := r.head(strings.TrimPrefix(ref, "heads/")); sha != "" {
+		return sha
+	}
+
+	return ref
+}
+
+type repositories struct {
+	fake *Fake
+}
+
+func (s *repositories) Get(_ context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.Get"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return clone(r.repo), ok(), nil
+}
+
+func (s *repositories) GetBranch(_ context.Context, owner, repo, branch string, _ int) (*github.Branch, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.GetBranch"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	b := r.branch(branch)
+	if b == nil {
+		resp, err = errorResponse(http.StatusNotFound, "Branch not found")
+		return nil, resp, err
+	}
+
+	return clone(b), ok(), nil
+}
+
+func (s *repositories) GetContents(_ context.Context, owner, repo, path string, _ *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.GetContents"); err != nil {
+		return nil, nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, nil, resp, err
+	}
+
+	content, found := r.contents[path]
+	if !found {
+		resp, err = notFound()
+		return nil, nil, resp, err
+	}
+
+	return &github.RepositoryContent{
+		Type:     github.Ptr("file"),
+		Path:     github.Ptr(path),
+		Encoding: github.Ptr("base64"),
+		Content:  github.Ptr(base64.StdEncoding.EncodeToString([]byte(content))),
+	}, nil, ok(), nil
+}
+
+func (s *repositories) GetRequiredStatusChecks(_ context.Context, owner, repo, branch string) (*github.RequiredStatusChecks, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.GetRequiredStatusChecks"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	rcs, found := r.protections[branch]
+	if !found {
+		resp, err = errorResponse(http.StatusNotFound, "Branch not protected")
+		return nil, resp, err
+	}
+
+	return clone(rcs), ok(), nil
+}
+
+// CompareCommits compares the base branch with the head of a pull request ("user:ref" or "ref").
+// A pull request is behind when the base branch has moved since its creation or its last update.
+func (s *repositories) CompareCommits(_ context.Context, owner, repo, base, head string, _ *github.ListOptions) (*github.CommitsComparison, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.CompareCommits"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	login, ref, found := strings.Cut(head, ":")
+	if !found {
+		login, ref = "", head
+	}
+
+	for _, pr := range r.pulls {
+		if pr.Base.GetRef() != base || pr.Head.GetRef() != ref || (login != "" && !strings.EqualFold(pr.Head.User.GetLogin(), login)) {
+			continue
+		}
+
+		if r.git != nil {
+			return s.fake.compare(r, pr)
+		}
+
+		var behindBy int
+		if pr.Base.GetSHA() != r.head(base) {
+			behindBy = 1
+		}
+
+		return &github.CommitsComparison{
+			BehindBy:        github.Ptr(behindBy),
+			AheadBy:         github.Ptr(1),
+			MergeBaseCommit: &github.RepositoryCommit{SHA: pr.Base.SHA},
+		}, ok(), nil
+	}
+
+	resp, err = notFound()
+
+	return nil, resp, err
+}
+
+func (s *repositories) GetCombinedStatus(_ context.Context, owner, repo, ref string, _ *github.ListOptions) (*github.CombinedStatus, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.GetCombinedStatus"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	sha := r.resolve(ref)
+	statuses := cloneAll(r.latestStatuses(sha))
+
+	return &github.CombinedStatus{
+		SHA:        github.Ptr(sha),
+		TotalCount: github.Ptr(len(statuses)),
+		Statuses:   statuses,
+	}, ok(), nil
+}
+
+func (s *repositories) CreateStatus(_ context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Repositories.CreateStatus"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	status = clone(status)
+	status.CreatedAt = &github.Timestamp{Time: time.Now()}
+
+	sha := r.resolve(ref)
+	r.statuses[sha] = append(r.statuses[sha], status)
+
+	return clone(status), response(http.StatusCreated), nil
+}
+
+type search struct {
+	fake *Fake
+}
+
+// Issues searches the pull requests.
+// Supported qualifiers: user, repo, type:pr, state, label, -label, and review:approved.
+// The results are sorted by update date (oldest first) and are never paginated.
+func (s *search) Issues(_ context.Context, query string, _ *github.SearchOptions) (*github.IssuesSearchResult, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Search.Issues"); err != nil {
+		return nil, nil, err
+	}
+
+	var matches []*github.Issue
+
+	for fullName, r := range s.fake.repos {
+		for _, pr := range r.pulls {
+			if r.matchQuery(fullName, pr, query) {
+				matches = append(matches, r.toIssue(pr))
+			}
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b *github.Issue) int {
+		return cmp.Or(a.GetUpdatedAt().Compare(b.GetUpdatedAt().Time), cmp.Compare(a.GetNumber(), b.GetNumber()))
+	})
+
+	return &github.IssuesSearchResult{
+		Total:  github.Ptr(len(matches)),
+		Issues: matches,
+	}, ok(), nil
+}
+
+func (r *fakeRepository) matchQuery(fullName string, pr *github.PullRequest, query string) bool {
+	for qualifier := range strings.FieldsSeq(query) {
+		key, value, _ := strings.Cut(qualifier, ":")
+
+		var match bool
+
+		switch key {
+		case "user":
+			match = strings.EqualFold(r.repo.GetOwner().GetLogin(), value)
+		case "repo":
+			match = strings.EqualFold(fullName, value)
+		case "type", "is":
+			match = value == "pr" || value == pr.GetState()
+		case "state":
+			match = pr.GetState() == value
+		case "label":
+			match = hasLabel(pr.Labels, value)
+		case "-label":
+			match = !hasLabel(pr.Labels, value)
+		case "review":
+			match = value == "approved" && slices.ContainsFunc(r.reviews[pr.GetNumber()], func(review *github.PullRequestReview) bool {
+				return review.GetState() == "APPROVED"
+			})
+		default:
+			// the unknown qualifiers are ignored.
+			match = true
+		}
+
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+type gitService struct {
+	fake *Fake
+}
+
+// UpdateRef moves a branch ("refs/heads/name") to a commit of another branch.
+func (s *gitService) UpdateRef(_ context.Context, owner, repo string, ref *github.Reference, _ bool) (*github.Reference, *github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Git.UpdateRef"); err != nil {
+		return nil, nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	name := strings.TrimPrefix(ref.GetRef(), "refs/")
+	branch, found := strings.CutPrefix(name, "heads/")
+	if !found {
+		resp, err = errorResponse(http.StatusUnprocessableEntity, "Reference does not exist")
+		return nil, resp, err
+	}
+
+	sha := ref.GetObject().GetSHA()
+
+	var message string
+	for _, b := range r.branches {
+		if b.GetCommit().GetSHA() == sha {
+			message = b.GetCommit().GetCommit().GetMessage()
+		}
+	}
+
+	// a protected branch can only be updated to a commit with the required status checks.
+	if rcs, protected := r.protections[branch]; protected {
+		for _, required := range rcs.GetContexts() {
+			passed := slices.ContainsFunc(r.latestStatuses(sha), func(status *github.RepoStatus) bool {
+				return status.GetContext() == required && status.GetState() == "success"
+			})
+
+			if !passed {
+				resp, err = errorResponse(http.StatusUnprocessableEntity, fmt.Sprintf("Required status check %q is expected.", required))
+				return nil, resp, err
+			}
+		}
+	}
+
+	err = r.moveBranch(branch, sha, message)
+	if err != nil {
+		resp, err = errorResponse(http.StatusUnprocessableEntity, "Object does not exist")
+		return nil, resp, err
+	}
+
+	return clone(ref), ok(), nil
+}
+
+// DeleteRef deletes a branch ("heads/name").
+func (s *gitService) DeleteRef(_ context.Context, owner, repo, ref string) (*github.Response, error) {
+	s.fake.mu.Lock()
+	defer s.fake.mu.Unlock()
+
+	if err := s.fake.fail("Git.DeleteRef"); err != nil {
+		return nil, err
+	}
+
+	r, resp, err := s.fake.getRepo(owner, repo)
+	if err != nil {
+		return resp, err
+	}
+
+	branch := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/"), "heads/")
+
+	found, err := r.deleteBranch(branch)
+	if err != nil {
+		return internalError(err)
+	}
+
+	if !found {
+		return errorResponse(http.StatusUnprocessableEntity, "Reference does not exist")
+	}
+
+	return response(http.StatusNoContent), nil
+}
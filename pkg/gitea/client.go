@@ -0,0 +1,505 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageSize the number of items by page: the default maximum of Gitea.
+const pageSize = 50
+
+// Client a client of the Gitea (or Forgejo) REST API (v1), limited to the endpoints used by the bot.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewClient creates a new Gitea client.
+// The URL is the URL of the Gitea instance, the token can be empty.
+func NewClient(httpClient *http.Client, baseURL, token string) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/api/v1",
+		token:      token,
+	}
+}
+
+// ErrorResponse an error returned by the API.
+type ErrorResponse struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// User a Gitea user (or organization).
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// Repo a Gitea repository.
+type Repo struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Owner         *User  `json:"owner"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+}
+
+// Label a Gitea label.
+type Label struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Milestone a Gitea milestone.
+type Milestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// PullRequestMeta the pull request part of an issue.
+type PullRequestMeta struct {
+	Merged bool `json:"merged"`
+}
+
+// Issue a Gitea issue, or a pull request seen as an issue (search).
+type Issue struct {
+	Number      int              `json:"number"`
+	Title       string           `json:"title"`
+	State       string           `json:"state"`
+	Labels      []*Label         `json:"labels"`
+	Milestone   *Milestone       `json:"milestone"`
+	Repository  *Repo            `json:"repository"`
+	PullRequest *PullRequestMeta `json:"pull_request"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// HasLabel checks if the issue has a label.
+func (i *Issue) HasLabel(label string) bool {
+	return hasLabel(i.Labels, label)
+}
+
+// LabelNames gets the names of the labels of the issue.
+func (i *Issue) LabelNames() []string {
+	return labelNames(i.Labels)
+}
+
+// LastUpdate gets the date of the last update of the issue.
+func (i *Issue) LastUpdate() time.Time {
+	return i.UpdatedAt
+}
+
+// Branch the branch of a pull request.
+type Branch struct {
+	Ref    string `json:"ref"`
+	SHA    string `json:"sha"`
+	RepoID int64  `json:"repo_id"`
+	Repo   *Repo  `json:"repo"`
+}
+
+// PullRequest a Gitea pull request.
+type PullRequest struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	Draft     bool       `json:"draft"`
+	Labels    []*Label   `json:"labels"`
+	Milestone *Milestone `json:"milestone"`
+	Head      *Branch    `json:"head"`
+	// Base the target branch, its SHA is the head of the target branch.
+	Base      *Branch   `json:"base"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Mergeable the pull request can be merged without conflicts.
+	Mergeable bool `json:"mergeable"`
+	Merged    bool `json:"merged"`
+	// MergeBase the common ancestor of the head and the target branch.
+	MergeBase string `json:"merge_base"`
+	// AllowMaintainerEdit the maintainers can push to the head branch (fork).
+	AllowMaintainerEdit bool `json:"allow_maintainer_edit"`
+}
+
+// HasLabel checks if the pull request has a label.
+func (pr *PullRequest) HasLabel(label string) bool {
+	return hasLabel(pr.Labels, label)
+}
+
+// Review a review of a pull request.
+type Review struct {
+	ID        int64  `json:"id"`
+	User      *User  `json:"user"`
+	State     string `json:"state"`
+	Dismissed bool   `json:"dismissed"`
+}
+
+// CommitStatus a commit status: the result of a check (Gitea Actions, or an external CI).
+type CommitStatus struct {
+	Context     string `json:"context"`
+	State       string `json:"status"`
+	Description string `json:"description"`
+}
+
+// CombinedStatus the latest status of each context of a commit.
+type CombinedStatus struct {
+	State    string          `json:"state"`
+	Statuses []*CommitStatus `json:"statuses"`
+}
+
+// Commit a commit of a pull request.
+type Commit struct {
+	SHA     string       `json:"sha"`
+	Parents []*CommitRef `json:"parents"`
+}
+
+// CommitRef a reference to a commit.
+type CommitRef struct {
+	SHA string `json:"sha"`
+}
+
+// RepoBranch a branch of a repository, with its protection.
+type RepoBranch struct {
+	Name      string `json:"name"`
+	Protected bool   `json:"protected"`
+	// EnableStatusCheck the status checks of the protection are required.
+	EnableStatusCheck bool `json:"enable_status_check"`
+	// StatusCheckContexts the required contexts (or glob patterns) of the protection.
+	StatusCheckContexts []string `json:"status_check_contexts"`
+	// EffectiveBranchProtectionName the name of the protection rule applied to the branch.
+	EffectiveBranchProtectionName string `json:"effective_branch_protection_name"`
+}
+
+// BranchProtection a branch protection rule.
+type BranchProtection struct {
+	RuleName string `json:"rule_name"`
+	// BlockOnOutdatedBranch the pull requests must be up-to-date to be merged.
+	BlockOnOutdatedBranch bool `json:"block_on_outdated_branch"`
+}
+
+// Comment a comment.
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// SearchOptions the options of the pull requests search.
+type SearchOptions struct {
+	// Owner the owner (organization or user) of the repositories.
+	Owner string
+	// Labels the labels of the pull requests.
+	Labels []string
+}
+
+// MergeOptions the options of a merge.
+type MergeOptions struct {
+	// Do the merge style: merge, rebase, rebase-merge, squash, or fast-forward-only.
+	Do                string `json:"Do"`
+	MergeTitleField   string `json:"MergeTitleField,omitempty"`
+	MergeMessageField string `json:"MergeMessageField,omitempty"`
+	// HeadCommitID the merge is only accepted if the head of the pull request is this commit.
+	HeadCommitID string `json:"head_commit_id,omitempty"`
+}
+
+// CurrentUser gets the user of the token.
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	user := &User{}
+
+	_, err := c.do(ctx, http.MethodGet, "/user", nil, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SearchPullRequests searches the opened pull requests of the repositories of an owner.
+func (c *Client) SearchPullRequests(ctx context.Context, opts SearchOptions) ([]*Issue, error) {
+	query := url.Values{}
+	query.Set("type", "pulls")
+	query.Set("state", "open")
+	query.Set("limit", strconv.Itoa(pageSize))
+
+	if opts.Owner != "" {
+		query.Set("owner", opts.Owner)
+	}
+
+	if len(opts.Labels) > 0 {
+		query.Set("labels", strings.Join(opts.Labels, ","))
+	}
+
+	var result []*Issue
+
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		var issues []*Issue
+
+		_, err := c.do(ctx, http.MethodGet, "/repos/issues/search?"+query.Encode(), nil, &issues)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, issues...)
+
+		if len(issues) < pageSize {
+			return result, nil
+		}
+	}
+}
+
+// GetPullRequest gets a pull request.
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	pr := &PullRequest{}
+
+	_, err := c.do(ctx, http.MethodGet, pullPath(owner, repo, number), nil, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// ListReviews lists the reviews of a pull request.
+func (c *Client) ListReviews(ctx context.Context, owner, repo string, number int) ([]*Review, error) {
+	return list[*Review](ctx, c, pullPath(owner, repo, number)+"/reviews")
+}
+
+// ListCommits lists the commits of a pull request.
+func (c *Client) ListCommits(ctx context.Context, owner, repo string, number int) ([]*Commit, error) {
+	return list[*Commit](ctx, c, pullPath(owner, repo, number)+"/commits")
+}
+
+// ListStatuses lists the latest status of each context of a commit.
+func (c *Client) ListStatuses(ctx context.Context, owner, repo, ref string) ([]*CommitStatus, error) {
+	var result []*CommitStatus
+
+	for page := 1; ; page++ {
+		combined := &CombinedStatus{}
+
+		_, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/commits/%s/status?page=%d&limit=%d", repoPath(owner, repo), url.PathEscape(ref), page, pageSize), nil, combined)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, combined.Statuses...)
+
+		if len(combined.Statuses) < pageSize {
+			return result, nil
+		}
+	}
+}
+
+// GetBranch gets a branch, with its protection.
+func (c *Client) GetBranch(ctx context.Context, owner, repo, branch string) (*RepoBranch, error) {
+	result := &RepoBranch{}
+
+	_, err := c.do(ctx, http.MethodGet, repoPath(owner, repo)+"/branches/"+url.PathEscape(branch), nil, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetBranchProtection gets a branch protection rule by name.
+func (c *Client) GetBranchProtection(ctx context.Context, owner, repo, name string) (*BranchProtection, error) {
+	result := &BranchProtection{}
+
+	_, err := c.do(ctx, http.MethodGet, repoPath(owner, repo)+"/branch_protections/"+url.PathEscape(name), nil, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AddLabels adds labels (by name) to an issue or a pull request, and gets all the labels.
+func (c *Client) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) ([]*Label, error) {
+	var result []*Label
+
+	_, err := c.do(ctx, http.MethodPost, issuePath(owner, repo, number)+"/labels", map[string][]string{"labels": labels}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RemoveLabel removes a label (by ID) from an issue or a pull request.
+func (c *Client) RemoveLabel(ctx context.Context, owner, repo string, number int, labelID int64) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/labels/%d", issuePath(owner, repo, number), labelID), nil, nil)
+	return err
+}
+
+// CreateComment adds a comment to an issue or a pull request.
+func (c *Client) CreateComment(ctx context.Context, owner, repo string, number int, body string) (*Comment, error) {
+	comment := &Comment{}
+
+	_, err := c.do(ctx, http.MethodPost, issuePath(owner, repo, number)+"/comments", map[string]string{"body": body}, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// CloseIssue closes an issue, and sets its milestone (if not zero).
+func (c *Client) CloseIssue(ctx context.Context, owner, repo string, number int, milestoneID int64) (*Issue, error) {
+	body := map[string]any{"state": "closed"}
+	if milestoneID != 0 {
+		body["milestone"] = milestoneID
+	}
+
+	issue := &Issue{}
+
+	_, err := c.do(ctx, http.MethodPatch, issuePath(owner, repo, number), body, issue)
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// UpdatePullRequest updates the head branch of a pull request with its target branch.
+// The style is merge or rebase.
+func (c *Client) UpdatePullRequest(ctx context.Context, owner, repo string, number int, style string) error {
+	_, err := c.do(ctx, http.MethodPost, pullPath(owner, repo, number)+"/update?style="+url.QueryEscape(style), nil, nil)
+	return err
+}
+
+// Merge merges a pull request.
+func (c *Client) Merge(ctx context.Context, owner, repo string, number int, opts MergeOptions) error {
+	_, err := c.do(ctx, http.MethodPost, pullPath(owner, repo, number)+"/merge", opts, nil)
+	return err
+}
+
+// list gets all the pages of a list.
+func list[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	var result []T
+
+	for page := 1; ; page++ {
+		var items []T
+
+		_, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?page=%d&limit=%d", path, page, pageSize), nil, &items)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, items...)
+
+		if len(items) < pageSize {
+			return result, nil
+		}
+	}
+}
+
+// do sends a request, and decodes the response in the result (can be nil).
+func (c *Client) do(ctx context.Context, method, path string, body, result any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp, newErrorResponse(req, resp)
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return resp, nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return resp, fmt.Errorf("%s %s: unable to decode the response: %w", method, req.URL.Path, err)
+	}
+
+	return resp, nil
+}
+
+func newErrorResponse(req *http.Request, resp *http.Response) *ErrorResponse {
+	errResp := &ErrorResponse{Method: req.Method, URL: req.URL.Path, StatusCode: resp.StatusCode}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+
+	if json.Unmarshal(data, &body) != nil || body.Message == "" {
+		errResp.Message = strings.TrimSpace(string(data))
+		return errResp
+	}
+
+	errResp.Message = body.Message
+
+	return errResp
+}
+
+func hasLabel(labels []*Label, label string) bool {
+	for _, lbl := range labels {
+		if lbl.Name == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+func repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+func issuePath(owner, repo string, number int) string {
+	return repoPath(owner, repo) + "/issues/" + strconv.Itoa(number)
+}
+
+func pullPath(owner, repo string, number int) string {
+	return repoPath(owner, repo) + "/pulls/" + strconv.Itoa(number)
+}
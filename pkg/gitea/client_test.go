@@ -0,0 +1,54 @@
+package gitea
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SearchPullRequests(t *testing.T) {
+	fake := newFakeGitea()
+
+	fake.addRepo(&Repo{FullName: "traefik/traefik"})
+	fake.addRepo(&Repo{FullName: "containous/traefik"})
+
+	// more than one page.
+	for range pageSize + 2 {
+		fake.addPull("traefik/traefik", &PullRequest{Labels: []*Label{{Name: "status/3-needs-merge"}}})
+	}
+
+	fake.addPull("traefik/traefik", &PullRequest{})
+	fake.addPull("traefik/traefik", &PullRequest{Labels: []*Label{{Name: "status/3-needs-merge"}}, State: "closed"})
+	fake.addPull("containous/traefik", &PullRequest{Labels: []*Label{{Name: "status/3-needs-merge"}}})
+
+	client := fake.newServer(t)
+
+	issues, err := client.SearchPullRequests(t.Context(), SearchOptions{Owner: "traefik", Labels: []string{"status/3-needs-merge"}})
+	require.NoError(t, err)
+
+	require.Len(t, issues, pageSize+2)
+
+	for _, issue := range issues {
+		assert.Equal(t, "traefik/traefik", issue.Repository.FullName)
+		assert.NotNil(t, issue.PullRequest)
+		assert.True(t, issue.HasLabel("status/3-needs-merge"))
+	}
+}
+
+func TestClient_error(t *testing.T) {
+	fake := newFakeGitea()
+	fake.addRepo(&Repo{FullName: "traefik/traefik"})
+
+	client := fake.newServer(t)
+
+	_, err := client.GetPullRequest(t.Context(), "traefik", "traefik", 1)
+
+	var errResp *ErrorResponse
+	require.ErrorAs(t, err, &errResp)
+
+	assert.Equal(t, http.StatusNotFound, errResp.StatusCode)
+	assert.Equal(t, "The target couldn't be found.", errResp.Message)
+	assert.EqualError(t, err, "GET /api/v1/repos/traefik/traefik/pulls/1: 404 The target couldn't be found.")
+}
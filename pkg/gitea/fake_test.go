@@ -0,0 +1,531 @@
+package gitea
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// fakeGitea a stand-in of the Gitea REST API (the endpoints used by the bot), backed by an in-memory state.
+type fakeGitea struct {
+	mu sync.Mutex
+
+	repos       map[string]*Repo
+	pulls       map[string]map[int]*PullRequest
+	issues      map[string]map[int]*Issue
+	reviews     map[string][]*Review
+	commits     map[string][]*Commit
+	statuses    map[string][]*CommitStatus
+	branches    map[string]*RepoBranch
+	protections map[string]*BranchProtection
+	comments    map[string][]string
+	merges      map[string]MergeOptions
+	updates     map[string]string
+
+	// labels the labels by name (shared by all the repositories).
+	labels map[string]*Label
+}
+
+func newFakeGitea() *fakeGitea {
+	return &fakeGitea{
+		repos:       make(map[string]*Repo),
+		pulls:       make(map[string]map[int]*PullRequest),
+		issues:      make(map[string]map[int]*Issue),
+		reviews:     make(map[string][]*Review),
+		commits:     make(map[string][]*Commit),
+		statuses:    make(map[string][]*CommitStatus),
+		branches:    make(map[string]*RepoBranch),
+		protections: make(map[string]*BranchProtection),
+		comments:    make(map[string][]string),
+		merges:      make(map[string]MergeOptions),
+		updates:     make(map[string]string),
+		labels:      make(map[string]*Label),
+	}
+}
+
+// addRepo adds a repository (owner/name), the ID and the default branch are defined if empty.
+func (f *fakeGitea) addRepo(repo *Repo) *Repo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	owner, name, _ := strings.Cut(repo.FullName, "/")
+
+	repo.ID = cmp.Or(repo.ID, int64(len(f.repos)+1))
+	repo.Name = name
+	repo.Owner = &User{Login: owner}
+	repo.DefaultBranch = cmp.Or(repo.DefaultBranch, "main")
+
+	f.repos[repo.FullName] = repo
+	f.pulls[repo.FullName] = make(map[int]*PullRequest)
+	f.issues[repo.FullName] = make(map[int]*Issue)
+
+	return repo
+}
+
+// addPull adds an opened and mergeable pull request from a branch of the repository, up-to-date with the default branch.
+// The labels are defined by name.
+func (f *fakeGitea) addPull(fullName string, pr *PullRequest) *PullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	repo := f.repos[fullName]
+
+	pr.Number = cmp.Or(pr.Number, len(f.pulls[fullName])+len(f.issues[fullName])+1)
+	pr.Title = cmp.Or(pr.Title, "Fix the bug")
+	pr.State = cmp.Or(pr.State, repository.StateOpen)
+	pr.Mergeable = true
+
+	if pr.Head == nil {
+		pr.Head = &Branch{Ref: "feature", RepoID: repo.ID, Repo: repo}
+	}
+
+	pr.Head.SHA = cmp.Or(pr.Head.SHA, fmt.Sprintf("sha-%d", pr.Number))
+
+	pr.Base = &Branch{Ref: repo.DefaultBranch, SHA: "base-sha", RepoID: repo.ID, Repo: repo}
+	pr.MergeBase = cmp.Or(pr.MergeBase, pr.Base.SHA)
+
+	for i, lbl := range pr.Labels {
+		pr.Labels[i] = f.label(lbl.Name)
+	}
+
+	if pr.UpdatedAt.IsZero() {
+		pr.UpdatedAt = time.Now()
+	}
+
+	f.pulls[fullName][pr.Number] = pr
+
+	return pr
+}
+
+// addIssue adds an opened issue.
+func (f *fakeGitea) addIssue(fullName string, number int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.issues[fullName][number] = &Issue{Number: number, State: repository.StateOpen}
+}
+
+// setConflicts marks a pull request as not mergeable.
+func (f *fakeGitea) setConflicts(fullName string, number int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pulls[fullName][number].Mergeable = false
+}
+
+// review adds a review to a pull request.
+func (f *fakeGitea) review(fullName string, number int, login, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := pullKey(fullName, number)
+
+	f.reviews[key] = append(f.reviews[key], &Review{ID: int64(len(f.reviews[key]) + 1), User: &User{Login: login}, State: state})
+}
+
+// setStatus sets the status of a context of a commit.
+func (f *fakeGitea) setStatus(sha, context, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.statuses[sha] = slices.DeleteFunc(f.statuses[sha], func(status *CommitStatus) bool { return status.Context == context })
+	f.statuses[sha] = append(f.statuses[sha], &CommitStatus{Context: context, State: state})
+}
+
+// setCommits sets the commits of a pull request.
+func (f *fakeGitea) setCommits(fullName string, number int, commits ...*Commit) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.commits[pullKey(fullName, number)] = commits
+}
+
+// protect protects a branch with a rule.
+func (f *fakeGitea) protect(fullName string, branch *RepoBranch, protection *BranchProtection) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	branch.Protected = true
+	branch.EffectiveBranchProtectionName = protection.RuleName
+
+	f.branches[fullName+":"+branch.Name] = branch
+	f.protections[fullName+":"+protection.RuleName] = protection
+}
+
+// pull gets a copy of a pull request.
+func (f *fakeGitea) pull(fullName string, number int) PullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return *f.pulls[fullName][number]
+}
+
+// labelNames gets the names of the labels of a pull request.
+func (f *fakeGitea) labelNames(fullName string, number int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return labelNames(f.pulls[fullName][number].Labels)
+}
+
+// issue gets a copy of an issue.
+func (f *fakeGitea) issue(fullName string, number int) Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return *f.issues[fullName][number]
+}
+
+// commentBodies gets the comments of a pull request or of an issue.
+func (f *fakeGitea) commentBodies(fullName string, number int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.comments[pullKey(fullName, number)]
+}
+
+// mergeOptions gets the options of the merge of a pull request.
+func (f *fakeGitea) mergeOptions(fullName string, number int) (MergeOptions, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	options, ok := f.merges[pullKey(fullName, number)]
+
+	return options, ok
+}
+
+// updateStyle gets the style of the update of a pull request.
+func (f *fakeGitea) updateStyle(fullName string, number int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.updates[pullKey(fullName, number)]
+}
+
+// newServer creates a server serving the fake, and a client of the server.
+func (f *fakeGitea) newServer(t *testing.T) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/user", func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(rw, http.StatusOK, &User{ID: 1, Login: "lobicornis"})
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/issues/search", f.search)
+
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{index}", f.withPull(func(rw http.ResponseWriter, _ *http.Request, _ *Repo, pr *PullRequest) {
+		writeJSON(rw, http.StatusOK, pr)
+	}))
+
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{index}/reviews", f.withPull(func(rw http.ResponseWriter, req *http.Request, repo *Repo, pr *PullRequest) {
+		writePage(rw, req, f.reviews[pullKey(repo.FullName, pr.Number)])
+	}))
+
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{index}/commits", f.withPull(func(rw http.ResponseWriter, req *http.Request, repo *Repo, pr *PullRequest) {
+		writePage(rw, req, f.commits[pullKey(repo.FullName, pr.Number)])
+	}))
+
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/pulls/{index}/update", f.withPull(f.update))
+
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/pulls/{index}/merge", f.withPull(f.merge))
+
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/commits/{ref}/status", f.withRepo(func(rw http.ResponseWriter, req *http.Request, _ *Repo) {
+		page, limit := pagination(req)
+
+		statuses := f.statuses[req.PathValue("ref")]
+		start := min((page-1)*limit, len(statuses))
+		end := min(start+limit, len(statuses))
+
+		writeJSON(rw, http.StatusOK, &CombinedStatus{Statuses: statuses[start:end]})
+	}))
+
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/branches/{branch}", f.withRepo(func(rw http.ResponseWriter, req *http.Request, repo *Repo) {
+		branch, ok := f.branches[repo.FullName+":"+req.PathValue("branch")]
+		if !ok {
+			branch = &RepoBranch{Name: req.PathValue("branch")}
+		}
+
+		writeJSON(rw, http.StatusOK, branch)
+	}))
+
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/branch_protections/{name}", f.withRepo(func(rw http.ResponseWriter, req *http.Request, repo *Repo) {
+		protection, ok := f.protections[repo.FullName+":"+req.PathValue("name")]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "The target couldn't be found.")
+			return
+		}
+
+		writeJSON(rw, http.StatusOK, protection)
+	}))
+
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/issues/{index}/labels", f.withPull(f.addLabels))
+
+	mux.HandleFunc("DELETE /api/v1/repos/{owner}/{repo}/issues/{index}/labels/{id}", f.withPull(func(rw http.ResponseWriter, req *http.Request, _ *Repo, pr *PullRequest) {
+		id, _ := strconv.ParseInt(req.PathValue("id"), 10, 64)
+
+		pr.Labels = slices.DeleteFunc(pr.Labels, func(lbl *Label) bool { return lbl.ID == id })
+		pr.UpdatedAt = time.Now()
+
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/issues/{index}/comments", f.withRepo(func(rw http.ResponseWriter, req *http.Request, repo *Repo) {
+		var comment Comment
+		if !decode(rw, req, &comment) {
+			return
+		}
+
+		key := repo.FullName + "#" + req.PathValue("index")
+
+		f.comments[key] = append(f.comments[key], comment.Body)
+		comment.ID = int64(len(f.comments[key]))
+
+		writeJSON(rw, http.StatusCreated, comment)
+	}))
+
+	mux.HandleFunc("PATCH /api/v1/repos/{owner}/{repo}/issues/{index}", f.withRepo(func(rw http.ResponseWriter, req *http.Request, repo *Repo) {
+		number, _ := strconv.Atoi(req.PathValue("index"))
+
+		issue, ok := f.issues[repo.FullName][number]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "The target couldn't be found.")
+			return
+		}
+
+		var body struct {
+			State     string `json:"state"`
+			Milestone int64  `json:"milestone"`
+		}
+
+		if !decode(rw, req, &body) {
+			return
+		}
+
+		issue.State = cmp.Or(body.State, issue.State)
+
+		if body.Milestone != 0 {
+			issue.Milestone = &Milestone{ID: body.Milestone}
+		}
+
+		writeJSON(rw, http.StatusCreated, issue)
+	}))
+
+	mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, http.StatusNotFound, "not found: "+req.Method+" "+req.URL.Path)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return NewClient(server.Client(), server.URL, "secret")
+}
+
+// search searches the opened pull requests with any of the labels, the latest update first (as Gitea).
+func (f *fakeGitea) search(rw http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := req.URL.Query()
+
+	if query.Get("type") != "pulls" || query.Get("state") != "open" {
+		writeError(rw, http.StatusBadRequest, "unexpected search")
+		return
+	}
+
+	var labels []string
+	if raw := query.Get("labels"); raw != "" {
+		labels = strings.Split(raw, ",")
+	}
+
+	var result []*Issue
+	for fullName, pulls := range f.pulls {
+		repo := f.repos[fullName]
+
+		if owner := query.Get("owner"); owner != "" && repo.Owner.Login != owner {
+			continue
+		}
+
+		for _, pr := range pulls {
+			if pr.State != repository.StateOpen || (len(labels) > 0 && !slices.ContainsFunc(labels, pr.HasLabel)) {
+				continue
+			}
+
+			result = append(result, &Issue{
+				Number:      pr.Number,
+				Title:       pr.Title,
+				State:       pr.State,
+				Labels:      pr.Labels,
+				Milestone:   pr.Milestone,
+				Repository:  &Repo{ID: repo.ID, Name: repo.Name, FullName: repo.FullName, Owner: repo.Owner},
+				PullRequest: &PullRequestMeta{Merged: pr.Merged},
+				UpdatedAt:   pr.UpdatedAt,
+			})
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *Issue) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), strings.Compare(a.Repository.FullName, b.Repository.FullName), cmp.Compare(a.Number, b.Number))
+	})
+
+	writePage(rw, req, result)
+}
+
+func (f *fakeGitea) addLabels(rw http.ResponseWriter, req *http.Request, _ *Repo, pr *PullRequest) {
+	var body struct {
+		Labels []string `json:"labels"`
+	}
+
+	if !decode(rw, req, &body) {
+		return
+	}
+
+	for _, name := range body.Labels {
+		if !pr.HasLabel(name) {
+			pr.Labels = append(pr.Labels, f.label(name))
+		}
+	}
+
+	pr.UpdatedAt = time.Now()
+
+	writeJSON(rw, http.StatusOK, pr.Labels)
+}
+
+func (f *fakeGitea) update(rw http.ResponseWriter, req *http.Request, repo *Repo, pr *PullRequest) {
+	style := req.URL.Query().Get("style")
+	if style != MergeStyleMerge && style != MergeStyleRebase {
+		writeError(rw, http.StatusBadRequest, "invalid style: "+style)
+		return
+	}
+
+	f.updates[pullKey(repo.FullName, pr.Number)] = style
+
+	pr.Head.SHA += "-" + style
+	pr.MergeBase = pr.Base.SHA
+	pr.UpdatedAt = time.Now()
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (f *fakeGitea) merge(rw http.ResponseWriter, req *http.Request, repo *Repo, pr *PullRequest) {
+	var options MergeOptions
+	if !decode(rw, req, &options) {
+		return
+	}
+
+	switch {
+	case pr.State != repository.StateOpen || pr.Merged:
+		writeError(rw, http.StatusMethodNotAllowed, "The PR is already merged or closed")
+		return
+	case !pr.Mergeable:
+		writeError(rw, http.StatusMethodNotAllowed, "Please try again later")
+		return
+	case options.HeadCommitID != "" && options.HeadCommitID != pr.Head.SHA:
+		writeError(rw, http.StatusConflict, "head out of date")
+		return
+	case options.Do == MergeStyleFastForwardOnly && pr.MergeBase != pr.Base.SHA:
+		writeError(rw, http.StatusMethodNotAllowed, "the branch is not up-to-date")
+		return
+	}
+
+	pr.State = "closed"
+	pr.Merged = true
+	pr.UpdatedAt = time.Now()
+
+	f.merges[pullKey(repo.FullName, pr.Number)] = options
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// label gets a label by name, the label is created if needed.
+func (f *fakeGitea) label(name string) *Label {
+	lbl, ok := f.labels[name]
+	if !ok {
+		lbl = &Label{ID: int64(len(f.labels) + 1), Name: name}
+		f.labels[name] = lbl
+	}
+
+	return lbl
+}
+
+// withRepo resolves the repository of a request.
+func (f *fakeGitea) withRepo(fn func(rw http.ResponseWriter, req *http.Request, repo *Repo)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		repo, ok := f.repos[req.PathValue("owner")+"/"+req.PathValue("repo")]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "The target couldn't be found.")
+			return
+		}
+
+		fn(rw, req, repo)
+	}
+}
+
+// withPull resolves the pull request of a request.
+func (f *fakeGitea) withPull(fn func(rw http.ResponseWriter, req *http.Request, repo *Repo, pr *PullRequest)) http.HandlerFunc {
+	return f.withRepo(func(rw http.ResponseWriter, req *http.Request, repo *Repo) {
+		number, _ := strconv.Atoi(req.PathValue("index"))
+
+		pr, ok := f.pulls[repo.FullName][number]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "The target couldn't be found.")
+			return
+		}
+
+		fn(rw, req, repo, pr)
+	})
+}
+
+func pullKey(fullName string, number int) string {
+	return fullName + "#" + strconv.Itoa(number)
+}
+
+func pagination(req *http.Request) (int, int) {
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+
+	return max(page, 1), min(cmp.Or(limit, pageSize), pageSize)
+}
+
+func writePage[T any](rw http.ResponseWriter, req *http.Request, items []T) {
+	page, limit := pagination(req)
+
+	start := min((page-1)*limit, len(items))
+	end := min(start+limit, len(items))
+
+	writeJSON(rw, http.StatusOK, items[start:end])
+}
+
+func decode(rw http.ResponseWriter, req *http.Request, value any) bool {
+	err := json.NewDecoder(req.Body).Decode(value)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeError(rw http.ResponseWriter, status int, message string) {
+	writeJSON(rw, status, map[string]string{"message": message})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, value any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	_ = json.NewEncoder(rw).Encode(value)
+}
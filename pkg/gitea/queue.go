@@ -0,0 +1,73 @@
+package gitea
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// Finder a pull request search manager.
+type Finder struct {
+	client  *Client
+	markers conf.Markers
+}
+
+// NewFinder creates a new finder.
+func NewFinder(client *Client, markers conf.Markers) Finder {
+	return Finder{
+		client:  client,
+		markers: markers,
+	}
+}
+
+// Search searches the opened pull requests of the repositories of an owner with the labels, and without the excluded labels.
+// The pull requests are grouped by repository, sorted by priority (the highest first) and then by update (the oldest first).
+func (f Finder) Search(ctx context.Context, owner string, labels, excludedLabels []string) (map[string][]*Issue, error) {
+	issues, err := f.client.SearchPullRequests(ctx, SearchOptions{Owner: owner, Labels: labels})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search the pull requests: %w", err)
+	}
+
+	// the labels filter of the search matches any of the labels, and the search has no sort option.
+	slices.SortStableFunc(issues, func(a, b *Issue) int {
+		return cmp.Or(
+			cmp.Compare(f.GetPriority(b), f.GetPriority(a)),
+			a.UpdatedAt.Compare(b.UpdatedAt),
+			cmp.Compare(a.Number, b.Number),
+		)
+	})
+
+	overview := make(map[string][]*Issue)
+
+	for _, issue := range issues {
+		if issue.Repository == nil || issue.PullRequest == nil {
+			continue
+		}
+
+		if !allLabels(issue, labels) || slices.ContainsFunc(excludedLabels, issue.HasLabel) {
+			continue
+		}
+
+		overview[issue.Repository.FullName] = append(overview[issue.Repository.FullName], issue)
+	}
+
+	return overview, nil
+}
+
+// GetPriority gets the priority of a pull request from its priority labels.
+func (f Finder) GetPriority(issue *Issue) int {
+	return f.markers.GetPriority(issue.LabelNames()...)
+}
+
+func allLabels(issue *Issue, labels []string) bool {
+	for _, label := range labels {
+		if !issue.HasLabel(label) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package gitea
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+var fakeMarkers = conf.Markers{
+	LightReview:       "bot/light-review",
+	NeedMerge:         "status/3-needs-merge",
+	MergeInProgress:   "status/4-merge-in-progress",
+	MergeMethodPrefix: "bot/merge-method-",
+	MergeRetryPrefix:  "bot/merge-retry-",
+	NeedHumanMerge:    "bot/need-human-merge",
+	MergeNoRebase:     "bot/merge-no-rebase",
+	NoMerge:           "bot/no-merge",
+	Priorities: map[string]int{
+		"bot/priority-high": 10,
+		"bot/priority-low":  -10,
+	},
+}
+
+func TestFinder_Search(t *testing.T) {
+	fake := newFakeGitea()
+
+	fake.addRepo(&Repo{FullName: "traefik/traefik"})
+	fake.addRepo(&Repo{FullName: "traefik/lobicornis"})
+	fake.addRepo(&Repo{FullName: "containous/traefik"})
+
+	fake.addPull("traefik/traefik", &PullRequest{Labels: labels(fakeMarkers.NeedMerge), UpdatedAt: time.Now().Add(-time.Hour)})
+	fake.addPull("traefik/traefik", &PullRequest{Labels: labels(fakeMarkers.NeedMerge), UpdatedAt: time.Now().Add(-2 * time.Hour)})
+	fake.addPull("traefik/traefik", &PullRequest{Labels: labels(fakeMarkers.NeedMerge, fakeMarkers.NoMerge)})
+	fake.addPull("traefik/traefik", &PullRequest{Labels: labels(fakeMarkers.LightReview)})
+	fake.addPull("traefik/traefik", &PullRequest{Labels: labels(fakeMarkers.NeedMerge, "bot/priority-low"), UpdatedAt: time.Now().Add(-3 * time.Hour)})
+	fake.addPull("traefik/traefik", &PullRequest{Labels: labels(fakeMarkers.NeedMerge, "bot/priority-high")})
+	fake.addPull("traefik/lobicornis", &PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+	fake.addPull("containous/traefik", &PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+
+	finder := NewFinder(fake.newServer(t), fakeMarkers)
+
+	overview, err := finder.Search(t.Context(), "traefik", []string{fakeMarkers.NeedMerge}, []string{fakeMarkers.NoMerge})
+	require.NoError(t, err)
+
+	numbers := make(map[string][]int)
+	for fullName, issues := range overview {
+		for _, issue := range issues {
+			numbers[fullName] = append(numbers[fullName], issue.Number)
+		}
+	}
+
+	expected := map[string][]int{
+		"traefik/traefik":    {6, 2, 1, 5},
+		"traefik/lobicornis": {1},
+	}
+
+	assert.Equal(t, expected, numbers)
+}
+
+// labels creates labels by name.
+func labels(names ...string) []*Label {
+	var result []*Label
+	for _, name := range names {
+		result = append(result, &Label{Name: name})
+	}
+
+	return result
+}
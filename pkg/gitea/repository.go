@@ -0,0 +1,109 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// Repository a Gitea repository: the pull requests of the repository for the pipeline of the pull requests.
+type Repository struct {
+	client *Client
+
+	owner string
+	name  string
+
+	config conf.RepoConfig
+
+	metrics *metrics.Metrics
+}
+
+// NewRepository creates a new Gitea repository.
+func NewRepository(client *Client, fullName string, config conf.RepoConfig, recorder *metrics.Metrics) *Repository {
+	owner, name, _ := strings.Cut(fullName, "/")
+
+	return &Repository{
+		client:  client,
+		owner:   owner,
+		name:    name,
+		config:  config,
+		metrics: recorder,
+	}
+}
+
+// GetPullRequest gets a pull request.
+func (r *Repository) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	return r.client.GetPullRequest(ctx, r.owner, r.name, number)
+}
+
+// Describe gets the information of a pull request used by the pipeline.
+func (r *Repository) Describe(pr *PullRequest) repository.PullRequest {
+	return repository.PullRequest{
+		Number:       pr.Number,
+		Title:        pr.Title,
+		Body:         pr.Body,
+		Labels:       labelNames(pr.Labels),
+		State:        pr.State,
+		Merged:       pr.Merged,
+		Draft:        isDraft(pr),
+		HasMilestone: pr.Milestone != nil,
+		Conflicts:    !pr.Mergeable,
+		Private:      pr.Base != nil && pr.Base.Repo != nil && pr.Base.Repo.Private,
+	}
+}
+
+// IsUpToDate checks if the head branch of a pull request contains the head of the target branch.
+func (r *Repository) IsUpToDate(_ context.Context, pr *PullRequest) (bool, error) {
+	return isUpToDateBranch(pr), nil
+}
+
+// NeedUpdate checks if the branch protection of the target branch blocks the outdated branches.
+func (r *Repository) NeedUpdate(ctx context.Context, pr *PullRequest) (bool, error) {
+	branch, err := r.client.GetBranch(ctx, r.owner, r.name, pr.Base.Ref)
+	if err != nil {
+		return false, fmt.Errorf("unable to get the branch %s: %w", pr.Base.Ref, err)
+	}
+
+	if branch.EffectiveBranchProtectionName == "" {
+		return false, nil
+	}
+
+	protection, err := r.client.GetBranchProtection(ctx, r.owner, r.name, branch.EffectiveBranchProtectionName)
+	if err != nil {
+		return false, fmt.Errorf("unable to get the branch protection %s: %w", branch.EffectiveBranchProtectionName, err)
+	}
+
+	return protection.BlockOnOutdatedBranch, nil
+}
+
+// AddComment adds a comment on a pull request.
+func (r *Repository) AddComment(ctx context.Context, pr *PullRequest, message string) error {
+	_, err := r.client.CreateComment(ctx, r.owner, r.name, pr.Number, message)
+
+	return err
+}
+
+func (r *Repository) fullName() string {
+	return r.owner + "/" + r.name
+}
+
+// isDraft checks if a pull request is a draft: the draft flag, or the default work in progress prefixes of the title.
+func isDraft(pr *PullRequest) bool {
+	title := strings.ToUpper(pr.Title)
+
+	return pr.Draft || strings.HasPrefix(title, "WIP:") || strings.HasPrefix(title, "[WIP]")
+}
+
+// isUpToDateBranch checks if the head branch of a pull request contains the head of the target branch.
+func isUpToDateBranch(pr *PullRequest) bool {
+	return pr.MergeBase != "" && pr.MergeBase == pr.Base.SHA
+}
+
+// isOnMainRepository checks if the head branch of a pull request is on the main repository.
+func isOnMainRepository(pr *PullRequest) bool {
+	return pr.Head.RepoID == pr.Base.RepoID
+}
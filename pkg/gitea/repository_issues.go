@@ -0,0 +1,77 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	closingRE = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?((?:\s*(?:,|and)?\s*#\d+\b)+)`)
+	issueRE   = regexp.MustCompile(`#(\d+)`)
+)
+
+// closeRelatedIssues closes the issues referenced by the closing keywords of the description of a pull request.
+// Gitea closes these issues only when a pull request is merged into the default branch:
+// the issues closed for another branch get a comment.
+func (r *Repository) closeRelatedIssues(ctx context.Context, pr *PullRequest) error {
+	logger := log.Ctx(ctx)
+
+	issues := parseClosingIssues(pr.Body)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	var milestoneID int64
+	if pr.Milestone != nil {
+		milestoneID = pr.Milestone.ID
+	}
+
+	for _, number := range issues {
+		logger.Info().Msgf("closes issue #%d", number)
+
+		_, err := r.client.CloseIssue(ctx, r.owner, r.name, number, milestoneID)
+		if err != nil {
+			return fmt.Errorf("unable to close issue #%d: %w", number, err)
+		}
+
+		if pr.Base.Repo != nil && pr.Base.Ref == pr.Base.Repo.DefaultBranch {
+			continue
+		}
+
+		_, err = r.client.CreateComment(ctx, r.owner, r.name, number, fmt.Sprintf("Closed by #%d.", pr.Number))
+		if err != nil {
+			return fmt.Errorf("unable to add comment on issue #%d: %w", number, err)
+		}
+	}
+
+	return nil
+}
+
+// parseClosingIssues gets the issues referenced by the closing keywords of a text: "Closes #1, #2 and #3".
+func parseClosingIssues(text string) []int {
+	var issues []int
+
+	seen := make(map[int]struct{})
+
+	for _, match := range closingRE.FindAllStringSubmatch(text, -1) {
+		for _, ref := range issueRE.FindAllStringSubmatch(match[1], -1) {
+			number, err := strconv.Atoi(ref[1])
+			if err != nil {
+				continue
+			}
+
+			if _, ok := seen[number]; ok {
+				continue
+			}
+
+			seen[number] = struct{}{}
+			issues = append(issues, number)
+		}
+	}
+
+	return issues
+}
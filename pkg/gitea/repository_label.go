@@ -0,0 +1,51 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// AddLabels adds some labels (by name) on a pull request.
+// The labels of the pull request are refreshed by the response.
+func (r *Repository) AddLabels(ctx context.Context, pr *PullRequest, labels ...string) error {
+	updated, err := r.client.AddLabels(ctx, r.owner, r.name, pr.Number, labels)
+	if err != nil {
+		return err
+	}
+
+	pr.Labels = updated
+
+	return nil
+}
+
+// RemoveLabels removes some labels of a pull request.
+// The labels are removed by ID: the labels of the pull request are used to find the IDs.
+func (r *Repository) RemoveLabels(ctx context.Context, pr *PullRequest, labels ...string) error {
+	var toRemove []*Label
+	for _, lbl := range pr.Labels {
+		if slices.Contains(labels, lbl.Name) {
+			toRemove = append(toRemove, lbl)
+		}
+	}
+
+	for _, lbl := range toRemove {
+		err := r.client.RemoveLabel(ctx, r.owner, r.name, pr.Number, lbl.ID)
+		if err != nil {
+			return fmt.Errorf("unable to remove the label %s: %w", lbl.Name, err)
+		}
+
+		pr.Labels = slices.DeleteFunc(pr.Labels, func(l *Label) bool { return l.ID == lbl.ID })
+	}
+
+	return nil
+}
+
+func labelNames(labels []*Label) []string {
+	var names []string
+	for _, lbl := range labels {
+		names = append(names, lbl.Name)
+	}
+
+	return names
+}
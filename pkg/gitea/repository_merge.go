@@ -0,0 +1,60 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// Merge styles.
+const (
+	MergeStyleMerge           = "merge"
+	MergeStyleRebase          = "rebase"
+	MergeStyleSquash          = "squash"
+	MergeStyleFastForwardOnly = "fast-forward-only"
+)
+
+// MergeMethods gets the merge methods supported by Gitea.
+func (*Repository) MergeMethods() []string {
+	return []string{conf.MergeMethodSquash, conf.MergeMethodMerge, conf.MergeMethodRebase, conf.MergeMethodFastForward}
+}
+
+// Merge merges a pull request with the API, and closes the related issues.
+// The title of a squash commit is suffixed by the number of the pull request, an empty message means the default message of Gitea.
+func (r *Repository) Merge(ctx context.Context, pr *PullRequest, mergeMethod string, message repository.CommitMessage) error {
+	options := MergeOptions{
+		Do:           getMergeStyle(mergeMethod),
+		HeadCommitID: pr.Head.SHA,
+	}
+
+	if mergeMethod == conf.MergeMethodSquash && message.Title != "" {
+		options.MergeTitleField = fmt.Sprintf("%s (#%d)", message.Title, pr.Number)
+		options.MergeMessageField = message.Body
+	}
+
+	err := r.client.Merge(ctx, r.owner, r.name, pr.Number, options)
+	if err != nil {
+		return err
+	}
+
+	err = r.closeRelatedIssues(ctx, pr)
+	repository.IgnoreError(ctx, err)
+
+	return nil
+}
+
+// getMergeStyle gets the merge style of Gitea for a merge method.
+func getMergeStyle(mergeMethod string) string {
+	switch mergeMethod {
+	case conf.MergeMethodSquash:
+		return MergeStyleSquash
+	case conf.MergeMethodRebase:
+		return MergeStyleRebase
+	case conf.MergeMethodFastForward:
+		return MergeStyleFastForwardOnly
+	default:
+		return MergeStyleMerge
+	}
+}
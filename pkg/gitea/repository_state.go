@@ -0,0 +1,170 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// Review states.
+const (
+	ReviewApproved      = "APPROVED"
+	ReviewComment       = "COMMENT"
+	ReviewPending       = "PENDING"
+	ReviewRequestReview = "REQUEST_REVIEW"
+)
+
+// Commit status states.
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusWarning = "warning"
+)
+
+// CheckReviews checks if a pull request has the required number of reviews, and only approvals.
+func (r *Repository) CheckReviews(ctx context.Context, pr *PullRequest, minReview int) error {
+	if minReview == 0 {
+		return nil
+	}
+
+	reviews, err := r.client.ListReviews(ctx, r.owner, r.name, pr.Number)
+	if err != nil {
+		return err
+	}
+
+	reviewsState := make(map[string]string)
+	for _, review := range reviews {
+		switch {
+		case review.Dismissed:
+			delete(reviewsState, review.User.Login)
+		case review.State == ReviewComment, review.State == ReviewPending, review.State == ReviewRequestReview:
+			continue
+		default:
+			reviewsState[review.User.Login] = review.State
+		}
+	}
+
+	if len(reviewsState) < minReview {
+		return fmt.Errorf("need more review [%d/%d]", len(reviewsState), minReview)
+	}
+
+	for login, state := range reviewsState {
+		if state != ReviewApproved {
+			return fmt.Errorf("%s by %s", state, login)
+		}
+	}
+
+	return nil
+}
+
+// GetChecksState gets the aggregated state of the commit statuses of the head of a pull request: success or pending.
+// The failed state is an error.
+func (r *Repository) GetChecksState(ctx context.Context, pr *PullRequest) (string, error) {
+	statuses, err := r.client.ListStatuses(ctx, r.owner, r.name, pr.Head.SHA)
+	if err != nil {
+		return "", fmt.Errorf("failed to list statuses: %w", err)
+	}
+
+	required := slices.Clone(r.config.GetRequiredChecks())
+
+	if r.config.GetUseProtectionChecks() {
+		branch, errBranch := r.client.GetBranch(ctx, r.owner, r.name, pr.Base.Ref)
+		if errBranch != nil {
+			return "", fmt.Errorf("unable to get the branch %s: %w", pr.Base.Ref, errBranch)
+		}
+
+		if branch.EnableStatusCheck {
+			required = append(required, branch.StatusCheckContexts...)
+		}
+	}
+
+	state, notSuccessful := aggregateStatuses(statuses, required, r.config.GetIgnoredChecks())
+
+	r.metrics.CheckState(r.fullName(), state)
+
+	if state == repository.Pending || state == repository.Success {
+		return state, nil
+	}
+
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(notSuccessful)) {
+		lines = append(lines, fmt.Sprintf("%s (%s)", name, notSuccessful[name]))
+	}
+
+	log.Ctx(ctx).Debug().Strs("checks", lines).Msg("Checks not successful.")
+
+	return "", fmt.Errorf("PR status: %s\n%s", state, strings.Join(lines, "\n"))
+}
+
+// aggregateStatuses aggregates the commit statuses.
+// The aggregated state is failed if at least one status is failed, pending if at least one status is pending, otherwise successful.
+// Only the required contexts (all if empty) not ignored are taken into account, and a required context without status is pending.
+func aggregateStatuses(statuses []*CommitStatus, required, ignored []string) (string, map[string]string) {
+	states := make(map[string]string)
+
+	for _, status := range statuses {
+		if matchAny(ignored, status.Context) || (len(required) > 0 && !matchAny(required, status.Context)) {
+			continue
+		}
+
+		switch status.State {
+		case StatusSuccess, StatusWarning:
+			states[status.Context] = repository.Success
+		case StatusPending:
+			states[status.Context] = repository.Pending
+		default:
+			states[status.Context] = status.State
+		}
+	}
+
+	for _, pattern := range required {
+		if !slices.ContainsFunc(statuses, func(status *CommitStatus) bool { return matchAny([]string{pattern}, status.Context) }) {
+			states[pattern] = repository.Pending
+		}
+	}
+
+	result := repository.Success
+	notSuccessful := make(map[string]string)
+
+	for name, state := range states {
+		if state == repository.Success {
+			continue
+		}
+
+		notSuccessful[name] = state
+
+		if severity(state) > severity(result) {
+			result = state
+		}
+	}
+
+	return result, notSuccessful
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// severity the order of the states: a failure is more severe than a pending state, which is more severe than a success.
+func severity(state string) int {
+	switch state {
+	case repository.Success:
+		return 0
+	case repository.Pending:
+		return 1
+	default:
+		return 2
+	}
+}
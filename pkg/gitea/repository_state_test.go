@@ -0,0 +1,92 @@
+package gitea
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+func Test_aggregateStatuses(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		statuses      []*CommitStatus
+		required      []string
+		ignored       []string
+		expected      string
+		notSuccessful map[string]string
+	}{
+		{
+			desc:          "no status",
+			expected:      repository.Success,
+			notSuccessful: map[string]string{},
+		},
+		{
+			desc: "success and warning",
+			statuses: []*CommitStatus{
+				{Context: "ci/test", State: "success"},
+				{Context: "ci/lint", State: "warning"},
+			},
+			expected:      repository.Success,
+			notSuccessful: map[string]string{},
+		},
+		{
+			desc: "pending",
+			statuses: []*CommitStatus{
+				{Context: "ci/test", State: "success"},
+				{Context: "ci/lint", State: "pending"},
+			},
+			expected:      repository.Pending,
+			notSuccessful: map[string]string{"ci/lint": repository.Pending},
+		},
+		{
+			desc: "failure before pending",
+			statuses: []*CommitStatus{
+				{Context: "ci/test", State: "failure"},
+				{Context: "ci/lint", State: "pending"},
+			},
+			expected:      "failure",
+			notSuccessful: map[string]string{"ci/test": "failure", "ci/lint": repository.Pending},
+		},
+		{
+			desc: "ignored failure",
+			statuses: []*CommitStatus{
+				{Context: "ci/test", State: "success"},
+				{Context: "ci/flaky", State: "error"},
+			},
+			ignored:       []string{"ci/flaky"},
+			expected:      repository.Success,
+			notSuccessful: map[string]string{},
+		},
+		{
+			desc: "not required failure",
+			statuses: []*CommitStatus{
+				{Context: "ci/test", State: "success"},
+				{Context: "ci/lint", State: "failure"},
+			},
+			required:      []string{"ci/test"},
+			expected:      repository.Success,
+			notSuccessful: map[string]string{},
+		},
+		{
+			desc: "required without status",
+			statuses: []*CommitStatus{
+				{Context: "ci/test", State: "success"},
+			},
+			required:      []string{"ci/test", "deploy/*"},
+			expected:      repository.Pending,
+			notSuccessful: map[string]string{"deploy/*": repository.Pending},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			state, notSuccessful := aggregateStatuses(test.statuses, test.required, test.ignored)
+
+			assert.Equal(t, test.expected, state)
+			assert.Equal(t, test.notSuccessful, notSuccessful)
+		})
+	}
+}
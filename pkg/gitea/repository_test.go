@@ -0,0 +1,338 @@
+package gitea
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+const fakeFullName = "traefik/traefik"
+
+func TestRepository_Describe(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		pr       *PullRequest
+		expected repository.PullRequest
+	}{
+		{
+			desc: "open",
+			pr: &PullRequest{
+				Number:    1,
+				Title:     "Fix the bug",
+				Body:      "Fixes #3",
+				State:     repository.StateOpen,
+				Labels:    labels(fakeMarkers.NeedMerge),
+				Milestone: &Milestone{ID: 7},
+				Mergeable: true,
+				MergeBase: "sha-main",
+				Base:      &Branch{SHA: "sha-main", Repo: &Repo{}},
+			},
+			expected: repository.PullRequest{
+				Number:       1,
+				Title:        "Fix the bug",
+				Body:         "Fixes #3",
+				State:        repository.StateOpen,
+				Labels:       []string{fakeMarkers.NeedMerge},
+				HasMilestone: true,
+			},
+		},
+		{
+			desc:     "not mergeable",
+			pr:       &PullRequest{State: repository.StateOpen, Base: &Branch{SHA: "sha-main"}},
+			expected: repository.PullRequest{State: repository.StateOpen, Conflicts: true},
+		},
+		{
+			desc:     "merged",
+			pr:       &PullRequest{State: "closed", Merged: true, Mergeable: true, Base: &Branch{SHA: "sha-main"}},
+			expected: repository.PullRequest{State: "closed", Merged: true},
+		},
+		{
+			desc:     "draft",
+			pr:       &PullRequest{State: repository.StateOpen, Draft: true, Mergeable: true, Base: &Branch{SHA: "sha-main"}},
+			expected: repository.PullRequest{State: repository.StateOpen, Draft: true},
+		},
+		{
+			desc:     "work in progress title",
+			pr:       &PullRequest{Title: "WIP: Fix the bug", State: repository.StateOpen, Mergeable: true, Base: &Branch{SHA: "sha-main"}},
+			expected: repository.PullRequest{Title: "WIP: Fix the bug", State: repository.StateOpen, Draft: true},
+		},
+		{
+			desc:     "work in progress title with brackets",
+			pr:       &PullRequest{Title: "[wip] Fix the bug", State: repository.StateOpen, Mergeable: true, Base: &Branch{SHA: "sha-main"}},
+			expected: repository.PullRequest{Title: "[wip] Fix the bug", State: repository.StateOpen, Draft: true},
+		},
+		{
+			desc:     "private repository",
+			pr:       &PullRequest{State: repository.StateOpen, Mergeable: true, Base: &Branch{SHA: "sha-main", Repo: &Repo{Private: true}}},
+			expected: repository.PullRequest{State: repository.StateOpen, Private: true},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &Repository{}
+
+			assert.Equal(t, test.expected, repo.Describe(test.pr))
+		})
+	}
+}
+
+func TestRepository_IsUpToDate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		pr       *PullRequest
+		expected bool
+	}{
+		{
+			desc:     "up-to-date",
+			pr:       &PullRequest{MergeBase: "sha-main", Base: &Branch{SHA: "sha-main"}},
+			expected: true,
+		},
+		{
+			desc: "not up-to-date",
+			pr:   &PullRequest{MergeBase: "old-sha", Base: &Branch{SHA: "sha-main"}},
+		},
+		{
+			desc: "unknown merge base",
+			pr:   &PullRequest{Base: &Branch{SHA: "sha-main"}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &Repository{}
+
+			upToDate, err := repo.IsUpToDate(t.Context(), test.pr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, upToDate)
+		})
+	}
+}
+
+func TestRepository_CheckReviews(t *testing.T) {
+	type review struct {
+		login string
+		state string
+	}
+
+	testCases := []struct {
+		desc      string
+		reviews   []review
+		minReview int
+		expected  string
+	}{
+		{
+			desc:      "approved",
+			reviews:   []review{{login: "ldez", state: ReviewApproved}},
+			minReview: 1,
+		},
+		{
+			desc:      "comment",
+			reviews:   []review{{login: "ldez", state: ReviewComment}},
+			minReview: 1,
+			expected:  "need more review [0/1]",
+		},
+		{
+			desc:      "changes requested",
+			reviews:   []review{{login: "ldez", state: ReviewApproved}, {login: "juju", state: "REQUEST_CHANGES"}},
+			minReview: 1,
+			expected:  "REQUEST_CHANGES by juju",
+		},
+		{
+			desc:      "changes requested then approved",
+			reviews:   []review{{login: "juju", state: "REQUEST_CHANGES"}, {login: "juju", state: ReviewApproved}},
+			minReview: 1,
+		},
+		{
+			desc:    "no review required",
+			reviews: []review{{login: "juju", state: "REQUEST_CHANGES"}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitea()
+			fake.addRepo(&Repo{FullName: fakeFullName})
+
+			pr := fake.addPull(fakeFullName, &PullRequest{})
+
+			for _, r := range test.reviews {
+				fake.review(fakeFullName, pr.Number, r.login, r.state)
+			}
+
+			repo := NewRepository(fake.newServer(t), fakeFullName, conf.RepoConfig{}, nil)
+
+			err := repo.CheckReviews(t.Context(), pr, test.minReview)
+
+			if test.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestRepository_CheckReviews_pagination(t *testing.T) {
+	fake := newFakeGitea()
+	fake.addRepo(&Repo{FullName: fakeFullName})
+
+	pr := fake.addPull(fakeFullName, &PullRequest{})
+
+	// more than one page.
+	for i := range pageSize + 2 {
+		fake.review(fakeFullName, pr.Number, fmt.Sprintf("user%d", i), ReviewApproved)
+	}
+
+	repo := NewRepository(fake.newServer(t), fakeFullName, conf.RepoConfig{}, nil)
+
+	err := repo.CheckReviews(t.Context(), pr, pageSize+2)
+	require.NoError(t, err)
+}
+
+func TestRepository_Merge(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		mergeMethod string
+		message     repository.CommitMessage
+		expected    MergeOptions
+	}{
+		{
+			desc:        "squash",
+			mergeMethod: conf.MergeMethodSquash,
+			message:     repository.CommitMessage{Title: "Fix the bug", Body: "Co-authored-by: ldez <ldez@example.com>"},
+			expected: MergeOptions{
+				Do:                MergeStyleSquash,
+				MergeTitleField:   "Fix the bug (#1)",
+				MergeMessageField: "Co-authored-by: ldez <ldez@example.com>",
+				HeadCommitID:      "sha-1",
+			},
+		},
+		{
+			desc:        "squash with the message of Gitea",
+			mergeMethod: conf.MergeMethodSquash,
+			expected:    MergeOptions{Do: MergeStyleSquash, HeadCommitID: "sha-1"},
+		},
+		{
+			desc:        "merge",
+			mergeMethod: conf.MergeMethodMerge,
+			expected:    MergeOptions{Do: MergeStyleMerge, HeadCommitID: "sha-1"},
+		},
+		{
+			desc:        "rebase",
+			mergeMethod: conf.MergeMethodRebase,
+			expected:    MergeOptions{Do: MergeStyleRebase, HeadCommitID: "sha-1"},
+		},
+		{
+			desc:        "fast-forward",
+			mergeMethod: conf.MergeMethodFastForward,
+			expected:    MergeOptions{Do: MergeStyleFastForwardOnly, HeadCommitID: "sha-1"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitea()
+			fake.addRepo(&Repo{FullName: fakeFullName})
+
+			pr := fake.addPull(fakeFullName, &PullRequest{})
+
+			repo := NewRepository(fake.newServer(t), fakeFullName, conf.RepoConfig{}, nil)
+
+			err := repo.Merge(t.Context(), pr, test.mergeMethod, test.message)
+			require.NoError(t, err)
+
+			options, merged := fake.mergeOptions(fakeFullName, pr.Number)
+			require.True(t, merged)
+
+			assert.Equal(t, test.expected, options)
+			assert.True(t, fake.pull(fakeFullName, pr.Number).Merged)
+		})
+	}
+}
+
+func TestRepository_Merge_closeIssues(t *testing.T) {
+	fake := newFakeGitea()
+	fake.addRepo(&Repo{FullName: fakeFullName})
+
+	pr := fake.addPull(fakeFullName, &PullRequest{
+		Body:      "Fixes #3, #4",
+		Milestone: &Milestone{ID: 7, Title: "v1.0.1"},
+	})
+
+	// a pull request targeting another branch than the default branch.
+	pr.Base.Ref = "v1.0"
+
+	fake.addIssue(fakeFullName, 3)
+	fake.addIssue(fakeFullName, 4)
+
+	repo := NewRepository(fake.newServer(t), fakeFullName, conf.RepoConfig{}, nil)
+
+	err := repo.Merge(t.Context(), pr, conf.MergeMethodMerge, repository.CommitMessage{})
+	require.NoError(t, err)
+
+	for _, number := range []int{3, 4} {
+		issue := fake.issue(fakeFullName, number)
+
+		assert.Equal(t, "closed", issue.State)
+		assert.Equal(t, &Milestone{ID: 7}, issue.Milestone)
+	}
+
+	assert.Equal(t, []string{"Closed by #1."}, fake.commentBodies(fakeFullName, 3))
+}
+
+func TestRepository_Update(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		commits  []*Commit
+		expected string
+	}{
+		{
+			desc:     "rebase",
+			commits:  []*Commit{{SHA: "a", Parents: []*CommitRef{{SHA: "old-sha"}}}},
+			expected: repository.ActionRebase,
+		},
+		{
+			desc: "merge commits",
+			commits: []*Commit{
+				{SHA: "a", Parents: []*CommitRef{{SHA: "old-sha"}}},
+				{SHA: "b", Parents: []*CommitRef{{SHA: "a"}, {SHA: "c"}}},
+			},
+			expected: repository.ActionMerge,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitea()
+			fake.addRepo(&Repo{FullName: fakeFullName})
+
+			pr := fake.addPull(fakeFullName, &PullRequest{MergeBase: "old-sha"})
+
+			fake.setCommits(fakeFullName, pr.Number, test.commits...)
+
+			repo := NewRepository(fake.newServer(t), fakeFullName, conf.RepoConfig{}, nil)
+
+			action, err := repo.Update(t.Context(), pr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, action)
+			assert.Equal(t, test.expected, fake.updateStyle(fakeFullName, pr.Number))
+		})
+	}
+}
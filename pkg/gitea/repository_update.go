@@ -0,0 +1,63 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// CanUpdate checks if the head branch of a pull request can be updated.
+func (r *Repository) CanUpdate(_ context.Context, pr *PullRequest) error {
+	if isOnMainRepository(pr) && r.isProtectedBranch(pr, pr.Head.Ref) {
+		return fmt.Errorf("the branch %s on the main repository is protected and cannot be updated", pr.Head.Ref)
+	}
+
+	if !isOnMainRepository(pr) && !pr.AllowMaintainerEdit {
+		return fmt.Errorf("the branch %s of the fork cannot be updated: the maintainers are not allowed to edit the pull request", pr.Head.Ref)
+	}
+
+	return nil
+}
+
+// Update updates the head branch of a pull request with its target branch, with the API.
+// The pull requests with merge commits are updated with a merge, the others with a rebase.
+func (r *Repository) Update(ctx context.Context, pr *PullRequest) (string, error) {
+	action, err := r.getUpdateAction(ctx, pr)
+	if err != nil {
+		return "", err
+	}
+
+	err = r.client.UpdatePullRequest(ctx, r.owner, r.name, pr.Number, action)
+	if err != nil {
+		return "", fmt.Errorf("update branch: %w", err)
+	}
+
+	return action, nil
+}
+
+// getUpdateAction gets the update action: a merge if the pull request contains merge commits, otherwise a rebase.
+func (r *Repository) getUpdateAction(ctx context.Context, pr *PullRequest) (string, error) {
+	commits, err := r.client.ListCommits(ctx, r.owner, r.name, pr.Number)
+	if err != nil {
+		return "", fmt.Errorf("unable to list the commits: %w", err)
+	}
+
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			return repository.ActionMerge, nil
+		}
+	}
+
+	return repository.ActionRebase, nil
+}
+
+// isProtectedBranch checks if a branch of the repository must never be updated.
+// The default branch is always protected.
+func (r *Repository) isProtectedBranch(pr *PullRequest, branch string) bool {
+	if matchAny(r.config.GetProtectedBranches(), branch) {
+		return true
+	}
+
+	return pr.Base.Repo != nil && branch == pr.Base.Repo.DefaultBranch
+}
@@ -0,0 +1,442 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL the URL of gitlab.com.
+const DefaultURL = "https://gitlab.com"
+
+// Client a client of the GitLab REST API (v4), limited to the endpoints used by the bot.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewClient creates a new GitLab client.
+// The URL is the URL of the GitLab instance (gitlab.com if empty), the token can be empty.
+func NewClient(httpClient *http.Client, baseURL, token string) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/api/v4",
+		token:      token,
+	}
+}
+
+// ErrorResponse an error returned by the API.
+type ErrorResponse struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// User a GitLab user.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// Project a GitLab project.
+type Project struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	SSHURLToRepo      string `json:"ssh_url_to_repo"`
+	Visibility        string `json:"visibility"`
+	// MergeMethod the merge method of the project: merge, rebase_merge (semi-linear history), or ff.
+	MergeMethod string `json:"merge_method"`
+}
+
+// Milestone a GitLab milestone.
+type Milestone struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// Pipeline a GitLab pipeline.
+type Pipeline struct {
+	ID     int    `json:"id"`
+	SHA    string `json:"sha"`
+	Status string `json:"status"`
+}
+
+// References the references of a merge request.
+type References struct {
+	Short string `json:"short"`
+	Full  string `json:"full"`
+}
+
+// MergeRequest a GitLab merge request.
+type MergeRequest struct {
+	IID             int        `json:"iid"`
+	ProjectID       int        `json:"project_id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	State           string     `json:"state"`
+	Draft           bool       `json:"draft"`
+	Labels          []string   `json:"labels"`
+	SourceBranch    string     `json:"source_branch"`
+	TargetBranch    string     `json:"target_branch"`
+	SourceProjectID int        `json:"source_project_id"`
+	TargetProjectID int        `json:"target_project_id"`
+	SHA             string     `json:"sha"`
+	MergeCommitSHA  string     `json:"merge_commit_sha,omitempty"`
+	SquashCommitSHA string     `json:"squash_commit_sha,omitempty"`
+	Milestone       *Milestone `json:"milestone"`
+	HeadPipeline    *Pipeline  `json:"head_pipeline"`
+	References      References `json:"references"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// HasConflicts the merge request has conflicts with the target branch.
+	HasConflicts bool `json:"has_conflicts"`
+	// DetailedMergeStatus the mergeability of the merge request: mergeable, need_rebase, discussions_not_resolved, etc.
+	DetailedMergeStatus string `json:"detailed_merge_status"`
+	// AllowCollaboration the members of the target project can push to the source branch (fork).
+	AllowCollaboration bool `json:"allow_collaboration"`
+	// DivergedCommitsCount the number of commits of the target branch missing in the source branch.
+	DivergedCommitsCount int `json:"diverged_commits_count"`
+	// RebaseInProgress a rebase started with the API is in progress.
+	RebaseInProgress bool `json:"rebase_in_progress"`
+}
+
+// ProjectPath gets the path of the project of the merge request (group/project).
+func (mr *MergeRequest) ProjectPath() string {
+	path, _, _ := strings.Cut(mr.References.Full, "!")
+	return path
+}
+
+// HasLabel checks if the merge request has a label.
+func (mr *MergeRequest) HasLabel(label string) bool {
+	for _, lbl := range mr.Labels {
+		if strings.EqualFold(lbl, label) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LabelNames gets the names of the labels of the merge request.
+func (mr *MergeRequest) LabelNames() []string {
+	return mr.Labels
+}
+
+// LastUpdate gets the date of the last update of the merge request.
+func (mr *MergeRequest) LastUpdate() time.Time {
+	return mr.UpdatedAt
+}
+
+// Approvals the approvals of a merge request.
+type Approvals struct {
+	// Approved the approval rules of the project are satisfied.
+	Approved      bool       `json:"approved"`
+	ApprovalsLeft int        `json:"approvals_left"`
+	ApprovedBy    []Approver `json:"approved_by"`
+}
+
+// Approver an approval of a merge request.
+type Approver struct {
+	User User `json:"user"`
+}
+
+// Note a comment.
+type Note struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+// Issue a GitLab issue.
+type Issue struct {
+	IID       int        `json:"iid"`
+	State     string     `json:"state"`
+	Milestone *Milestone `json:"milestone"`
+}
+
+// ListOptions the options of the merge requests search.
+type ListOptions struct {
+	// Labels the labels of the merge requests (all the labels are required).
+	Labels []string
+}
+
+// MergeOptions the options of a merge.
+type MergeOptions struct {
+	// SHA the merge is only accepted if the head of the source branch is this commit.
+	SHA                 string `json:"sha,omitempty"`
+	Squash              bool   `json:"squash"`
+	SquashCommitMessage string `json:"squash_commit_message,omitempty"`
+	MergeCommitMessage  string `json:"merge_commit_message,omitempty"`
+}
+
+// CurrentUser gets the user of the token.
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	user := &User{}
+
+	_, err := c.do(ctx, http.MethodGet, "/user", nil, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetProject gets a project by path (group/project) or ID.
+func (c *Client) GetProject(ctx context.Context, project string) (*Project, error) {
+	result := &Project{}
+
+	_, err := c.do(ctx, http.MethodGet, projectPath(project), nil, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListGroupMergeRequests lists the opened merge requests of a group (and of its subgroups), the oldest update first.
+func (c *Client) ListGroupMergeRequests(ctx context.Context, group string, opts ListOptions) ([]*MergeRequest, error) {
+	query := url.Values{}
+	query.Set("state", "opened")
+	query.Set("order_by", "updated_at")
+	query.Set("sort", "asc")
+	query.Set("per_page", "100")
+
+	if len(opts.Labels) > 0 {
+		query.Set("labels", strings.Join(opts.Labels, ","))
+	}
+
+	var result []*MergeRequest
+
+	page := "1"
+	for page != "" {
+		query.Set("page", page)
+
+		var mrs []*MergeRequest
+
+		resp, err := c.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(group)+"/merge_requests?"+query.Encode(), nil, &mrs)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, mrs...)
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return result, nil
+}
+
+// GetMergeRequest gets a merge request, with the number of diverged commits.
+func (c *Client) GetMergeRequest(ctx context.Context, project string, iid int) (*MergeRequest, error) {
+	mr := &MergeRequest{}
+
+	_, err := c.do(ctx, http.MethodGet, mergeRequestPath(project, iid)+"?include_diverged_commits_count=true&include_rebase_in_progress=true", nil, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	return mr, nil
+}
+
+// GetApprovals gets the approvals of a merge request.
+func (c *Client) GetApprovals(ctx context.Context, project string, iid int) (*Approvals, error) {
+	approvals := &Approvals{}
+
+	_, err := c.do(ctx, http.MethodGet, mergeRequestPath(project, iid)+"/approvals", nil, approvals)
+	if err != nil {
+		return nil, err
+	}
+
+	return approvals, nil
+}
+
+// UpdateLabels adds and removes labels of a merge request.
+func (c *Client) UpdateLabels(ctx context.Context, project string, iid int, add, remove []string) (*MergeRequest, error) {
+	body := map[string]string{}
+
+	if len(add) > 0 {
+		body["add_labels"] = strings.Join(add, ",")
+	}
+
+	if len(remove) > 0 {
+		body["remove_labels"] = strings.Join(remove, ",")
+	}
+
+	mr := &MergeRequest{}
+
+	_, err := c.do(ctx, http.MethodPut, mergeRequestPath(project, iid), body, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	return mr, nil
+}
+
+// CreateNote adds a comment to a merge request.
+func (c *Client) CreateNote(ctx context.Context, project string, iid int, body string) (*Note, error) {
+	note := &Note{}
+
+	_, err := c.do(ctx, http.MethodPost, mergeRequestPath(project, iid)+"/notes", map[string]string{"body": body}, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// Rebase rebases the source branch of a merge request on its target branch.
+// The rebase is asynchronous: the merge request shows the rebase in progress.
+func (c *Client) Rebase(ctx context.Context, project string, iid int) error {
+	_, err := c.do(ctx, http.MethodPut, mergeRequestPath(project, iid)+"/rebase", nil, nil)
+	return err
+}
+
+// Merge merges a merge request.
+func (c *Client) Merge(ctx context.Context, project string, iid int, opts MergeOptions) (*MergeRequest, error) {
+	mr := &MergeRequest{}
+
+	_, err := c.do(ctx, http.MethodPut, mergeRequestPath(project, iid)+"/merge", opts, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	return mr, nil
+}
+
+// CloseIssue closes an issue, and sets its milestone (if not zero).
+func (c *Client) CloseIssue(ctx context.Context, project string, iid, milestoneID int) (*Issue, error) {
+	body := map[string]any{"state_event": "close"}
+	if milestoneID != 0 {
+		body["milestone_id"] = milestoneID
+	}
+
+	issue := &Issue{}
+
+	_, err := c.do(ctx, http.MethodPut, projectPath(project)+"/issues/"+strconv.Itoa(iid), body, issue)
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// CreateIssueNote adds a comment to an issue.
+func (c *Client) CreateIssueNote(ctx context.Context, project string, iid int, body string) (*Note, error) {
+	note := &Note{}
+
+	_, err := c.do(ctx, http.MethodPost, projectPath(project)+"/issues/"+strconv.Itoa(iid)+"/notes", map[string]string{"body": body}, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// do sends a request, and decodes the response in the result (can be nil).
+func (c *Client) do(ctx context.Context, method, path string, body, result any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Private-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp, newErrorResponse(req, resp)
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return resp, nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return resp, fmt.Errorf("%s %s: unable to decode the response: %w", method, req.URL.Path, err)
+	}
+
+	return resp, nil
+}
+
+func newErrorResponse(req *http.Request, resp *http.Response) *ErrorResponse {
+	errResp := &ErrorResponse{Method: req.Method, URL: req.URL.Path, StatusCode: resp.StatusCode}
+
+	var body struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+
+	if json.Unmarshal(data, &body) != nil {
+		errResp.Message = strings.TrimSpace(string(data))
+		return errResp
+	}
+
+	switch msg := body.Message.(type) {
+	case string:
+		errResp.Message = msg
+	case nil:
+		errResp.Message = body.Error
+	default:
+		// the validation errors are objects.
+		raw, _ := json.Marshal(msg)
+		errResp.Message = string(raw)
+	}
+
+	return errResp
+}
+
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+func mergeRequestPath(project string, iid int) string {
+	return projectPath(project) + "/merge_requests/" + strconv.Itoa(iid)
+}
@@ -0,0 +1,54 @@
+package gitlab
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListGroupMergeRequests(t *testing.T) {
+	fake := newFakeGitLab()
+	fake.pageSize = 2
+
+	fake.addProject(&Project{PathWithNamespace: "traefik/sub/traefik"})
+	fake.addProject(&Project{PathWithNamespace: "containous/traefik"})
+
+	for range 3 {
+		fake.addMergeRequest("traefik/sub/traefik", &MergeRequest{Labels: []string{"status/3-needs-merge"}})
+	}
+
+	fake.addMergeRequest("traefik/sub/traefik", &MergeRequest{})
+	fake.addMergeRequest("traefik/sub/traefik", &MergeRequest{Labels: []string{"status/3-needs-merge"}, State: StateMerged})
+	fake.addMergeRequest("containous/traefik", &MergeRequest{Labels: []string{"status/3-needs-merge"}})
+
+	client := fake.newServer(t)
+
+	mrs, err := client.ListGroupMergeRequests(t.Context(), "traefik", ListOptions{Labels: []string{"status/3-needs-merge"}})
+	require.NoError(t, err)
+
+	var refs []string
+	for _, mr := range mrs {
+		refs = append(refs, mr.References.Full)
+	}
+
+	assert.Equal(t, []string{"traefik/sub/traefik!1", "traefik/sub/traefik!2", "traefik/sub/traefik!3"}, refs)
+	assert.Equal(t, "traefik/sub/traefik", mrs[0].ProjectPath())
+}
+
+func TestClient_error(t *testing.T) {
+	fake := newFakeGitLab()
+	fake.addProject(&Project{PathWithNamespace: "traefik/traefik"})
+
+	client := fake.newServer(t)
+
+	_, err := client.GetMergeRequest(t.Context(), "traefik/traefik", 1)
+
+	var errResp *ErrorResponse
+	require.ErrorAs(t, err, &errResp)
+
+	assert.Equal(t, http.StatusNotFound, errResp.StatusCode)
+	assert.Equal(t, "404 Not found", errResp.Message)
+	assert.EqualError(t, err, "GET /api/v4/projects/traefik/traefik/merge_requests/1: 404 404 Not found")
+}
@@ -0,0 +1,412 @@
+package gitlab
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGitLab a stand-in of the GitLab REST API (the endpoints used by the bot), backed by an in-memory state.
+type fakeGitLab struct {
+	mu sync.Mutex
+
+	projects  map[string]*Project
+	mrs       map[string]map[int]*MergeRequest
+	approvals map[string]map[int][]string
+	issues    map[string]map[int]*Issue
+	notes     map[string][]string
+	merges    map[string]MergeOptions
+
+	// pageSize the size of the pages of the merge requests search (the per_page parameter is ignored).
+	pageSize int
+	// rebases the number of rebases with the API.
+	rebases int
+	// requiredApprovals the number of approvals required by the approval rules of the projects.
+	requiredApprovals int
+}
+
+func newFakeGitLab() *fakeGitLab {
+	return &fakeGitLab{
+		projects:  make(map[string]*Project),
+		mrs:       make(map[string]map[int]*MergeRequest),
+		approvals: make(map[string]map[int][]string),
+		issues:    make(map[string]map[int]*Issue),
+		notes:     make(map[string][]string),
+		merges:    make(map[string]MergeOptions),
+		pageSize:  100,
+	}
+}
+
+// addProject adds a project, the ID and the default branch are defined if empty.
+func (f *fakeGitLab) addProject(project *Project) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	project.ID = cmp.Or(project.ID, len(f.projects)+1)
+	project.DefaultBranch = cmp.Or(project.DefaultBranch, "main")
+	project.MergeMethod = cmp.Or(project.MergeMethod, MergeMethodMerge)
+
+	f.projects[project.PathWithNamespace] = project
+	f.mrs[project.PathWithNamespace] = make(map[int]*MergeRequest)
+	f.approvals[project.PathWithNamespace] = make(map[int][]string)
+	f.issues[project.PathWithNamespace] = make(map[int]*Issue)
+}
+
+// addMergeRequest adds an opened merge request from a branch of the project, the IID is defined if zero.
+func (f *fakeGitLab) addMergeRequest(path string, mr *MergeRequest) *MergeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	project := f.projects[path]
+
+	mr.IID = cmp.Or(mr.IID, len(f.mrs[path])+1)
+	mr.ProjectID = project.ID
+	mr.TargetProjectID = project.ID
+	mr.SourceProjectID = cmp.Or(mr.SourceProjectID, project.ID)
+	mr.State = cmp.Or(mr.State, StateOpened)
+	mr.SourceBranch = cmp.Or(mr.SourceBranch, "feature")
+	mr.TargetBranch = cmp.Or(mr.TargetBranch, project.DefaultBranch)
+	mr.SHA = cmp.Or(mr.SHA, fmt.Sprintf("sha-%d", mr.IID))
+	mr.DetailedMergeStatus = cmp.Or(mr.DetailedMergeStatus, "mergeable")
+	mr.References = References{Short: "!" + strconv.Itoa(mr.IID), Full: path + "!" + strconv.Itoa(mr.IID)}
+
+	if mr.UpdatedAt.IsZero() {
+		mr.UpdatedAt = time.Now()
+	}
+
+	f.mrs[path][mr.IID] = mr
+
+	return mr
+}
+
+// approve adds an approval to a merge request.
+func (f *fakeGitLab) approve(path string, iid int, username string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.approvals[path][iid] = append(f.approvals[path][iid], username)
+}
+
+// addIssue adds an opened issue.
+func (f *fakeGitLab) addIssue(path string, iid int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.issues[path][iid] = &Issue{IID: iid, State: "opened"}
+}
+
+// mergeRequest gets a copy of a merge request.
+func (f *fakeGitLab) mergeRequest(path string, iid int) MergeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return *f.mrs[path][iid]
+}
+
+// issue gets a copy of an issue.
+func (f *fakeGitLab) issue(path string, iid int) Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return *f.issues[path][iid]
+}
+
+// noteBodies gets the comments of a merge request ("!1") or of an issue ("#1").
+func (f *fakeGitLab) noteBodies(path, ref string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.notes[path+ref]
+}
+
+// mergeOptions gets the options of the merge of a merge request.
+func (f *fakeGitLab) mergeOptions(path string, iid int) (MergeOptions, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	options, ok := f.merges[path+"!"+strconv.Itoa(iid)]
+
+	return options, ok
+}
+
+// newServer creates a server serving the fake, and a client of the server.
+func (f *fakeGitLab) newServer(t *testing.T) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v4/user", func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(rw, http.StatusOK, &User{ID: 1, Username: "lobicornis"})
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/{id}", f.withProject(func(rw http.ResponseWriter, _ *http.Request, project *Project) {
+		writeJSON(rw, http.StatusOK, project)
+	}))
+
+	mux.HandleFunc("GET /api/v4/groups/{group}/merge_requests", f.listMergeRequests)
+
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}", f.withMergeRequest(func(rw http.ResponseWriter, _ *http.Request, _ *Project, mr *MergeRequest) {
+		writeJSON(rw, http.StatusOK, mr)
+	}))
+
+	mux.HandleFunc("PUT /api/v4/projects/{id}/merge_requests/{iid}", f.withMergeRequest(f.updateMergeRequest))
+
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}/approvals", f.withMergeRequest(func(rw http.ResponseWriter, _ *http.Request, project *Project, mr *MergeRequest) {
+		approvals := &Approvals{}
+		for _, username := range f.approvals[project.PathWithNamespace][mr.IID] {
+			approvals.ApprovedBy = append(approvals.ApprovedBy, Approver{User: User{Username: username}})
+		}
+
+		approvals.ApprovalsLeft = max(f.requiredApprovals-len(approvals.ApprovedBy), 0)
+		approvals.Approved = approvals.ApprovalsLeft == 0
+
+		writeJSON(rw, http.StatusOK, approvals)
+	}))
+
+	mux.HandleFunc("POST /api/v4/projects/{id}/merge_requests/{iid}/notes", f.withMergeRequest(func(rw http.ResponseWriter, req *http.Request, project *Project, mr *MergeRequest) {
+		f.createNote(rw, req, project.PathWithNamespace+mr.References.Short)
+	}))
+
+	mux.HandleFunc("PUT /api/v4/projects/{id}/merge_requests/{iid}/rebase", f.withMergeRequest(func(rw http.ResponseWriter, _ *http.Request, _ *Project, mr *MergeRequest) {
+		f.rebases++
+
+		mr.SHA += "-rebased"
+		mr.DivergedCommitsCount = 0
+		mr.HeadPipeline = &Pipeline{ID: mr.IID * 100, SHA: mr.SHA, Status: "pending"}
+		mr.UpdatedAt = time.Now()
+
+		writeJSON(rw, http.StatusAccepted, map[string]bool{"rebase_in_progress": true})
+	}))
+
+	mux.HandleFunc("PUT /api/v4/projects/{id}/merge_requests/{iid}/merge", f.withMergeRequest(f.merge))
+
+	mux.HandleFunc("PUT /api/v4/projects/{id}/issues/{iid}", f.withIssue(func(rw http.ResponseWriter, req *http.Request, issue *Issue) {
+		var body struct {
+			StateEvent  string `json:"state_event"`
+			MilestoneID int    `json:"milestone_id"`
+		}
+
+		if !decode(rw, req, &body) {
+			return
+		}
+
+		if body.StateEvent == "close" {
+			issue.State = "closed"
+		}
+
+		if body.MilestoneID != 0 {
+			issue.Milestone = &Milestone{ID: body.MilestoneID}
+		}
+
+		writeJSON(rw, http.StatusOK, issue)
+	}))
+
+	mux.HandleFunc("POST /api/v4/projects/{id}/issues/{iid}/notes", f.withIssue(func(rw http.ResponseWriter, req *http.Request, issue *Issue) {
+		project := f.projects[req.PathValue("id")]
+
+		f.createNote(rw, req, project.PathWithNamespace+"#"+strconv.Itoa(issue.IID))
+	}))
+
+	mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, http.StatusNotFound, "404 Not Found: "+req.Method+" "+req.URL.Path)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return NewClient(server.Client(), server.URL, "secret")
+}
+
+func (f *fakeGitLab) listMergeRequests(rw http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	group := req.PathValue("group")
+
+	var labels []string
+	if raw := req.URL.Query().Get("labels"); raw != "" {
+		labels = strings.Split(raw, ",")
+	}
+
+	var result []*MergeRequest
+	for path, mrs := range f.mrs {
+		if !strings.HasPrefix(path, group+"/") {
+			continue
+		}
+
+		for _, mr := range mrs {
+			if mr.State == StateOpened && !slices.ContainsFunc(labels, func(label string) bool { return !mr.HasLabel(label) }) {
+				result = append(result, mr)
+			}
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *MergeRequest) int {
+		return cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), strings.Compare(a.References.Full, b.References.Full))
+	})
+
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+	page = max(page, 1)
+
+	start := min((page-1)*f.pageSize, len(result))
+	end := min(start+f.pageSize, len(result))
+
+	if end < len(result) {
+		rw.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+
+	writeJSON(rw, http.StatusOK, result[start:end])
+}
+
+func (f *fakeGitLab) updateMergeRequest(rw http.ResponseWriter, req *http.Request, _ *Project, mr *MergeRequest) {
+	var body struct {
+		AddLabels    string `json:"add_labels"`
+		RemoveLabels string `json:"remove_labels"`
+	}
+
+	if !decode(rw, req, &body) {
+		return
+	}
+
+	for label := range strings.SplitSeq(body.AddLabels, ",") {
+		if label != "" && !mr.HasLabel(label) {
+			mr.Labels = append(mr.Labels, label)
+		}
+	}
+
+	for label := range strings.SplitSeq(body.RemoveLabels, ",") {
+		mr.Labels = slices.DeleteFunc(mr.Labels, func(lbl string) bool { return lbl == label })
+	}
+
+	if len(mr.Labels) == 0 {
+		mr.Labels = nil
+	}
+
+	mr.UpdatedAt = time.Now()
+
+	writeJSON(rw, http.StatusOK, mr)
+}
+
+func (f *fakeGitLab) merge(rw http.ResponseWriter, req *http.Request, project *Project, mr *MergeRequest) {
+	var options MergeOptions
+	if !decode(rw, req, &options) {
+		return
+	}
+
+	switch {
+	case mr.State != StateOpened:
+		writeError(rw, http.StatusMethodNotAllowed, "405 Method Not Allowed")
+		return
+	case mr.HasConflicts:
+		writeError(rw, http.StatusUnprocessableEntity, "Branch cannot be merged")
+		return
+	case options.SHA != "" && options.SHA != mr.SHA:
+		writeError(rw, http.StatusConflict, "SHA does not match HEAD of source branch: "+mr.SHA)
+		return
+	}
+
+	mr.State = StateMerged
+	mr.MergeCommitSHA = "merge-" + mr.SHA
+	mr.UpdatedAt = time.Now()
+
+	if options.Squash {
+		mr.SquashCommitSHA = "squash-" + mr.SHA
+	}
+
+	f.merges[project.PathWithNamespace+mr.References.Short] = options
+
+	writeJSON(rw, http.StatusOK, mr)
+}
+
+func (f *fakeGitLab) createNote(rw http.ResponseWriter, req *http.Request, key string) {
+	var note Note
+	if !decode(rw, req, &note) {
+		return
+	}
+
+	f.notes[key] = append(f.notes[key], note.Body)
+	note.ID = len(f.notes[key])
+
+	writeJSON(rw, http.StatusCreated, note)
+}
+
+// withProject resolves the project of a request (path or ID).
+func (f *fakeGitLab) withProject(fn func(rw http.ResponseWriter, req *http.Request, project *Project)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id := req.PathValue("id")
+
+		for path, project := range f.projects {
+			if path == id || strconv.Itoa(project.ID) == id {
+				req.SetPathValue("id", path)
+
+				fn(rw, req, project)
+
+				return
+			}
+		}
+
+		writeError(rw, http.StatusNotFound, "404 Project Not Found")
+	}
+}
+
+// withMergeRequest resolves the merge request of a request.
+func (f *fakeGitLab) withMergeRequest(fn func(rw http.ResponseWriter, req *http.Request, project *Project, mr *MergeRequest)) http.HandlerFunc {
+	return f.withProject(func(rw http.ResponseWriter, req *http.Request, project *Project) {
+		iid, _ := strconv.Atoi(req.PathValue("iid"))
+
+		mr, ok := f.mrs[project.PathWithNamespace][iid]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "404 Not found")
+			return
+		}
+
+		fn(rw, req, project, mr)
+	})
+}
+
+// withIssue resolves the issue of a request.
+func (f *fakeGitLab) withIssue(fn func(rw http.ResponseWriter, req *http.Request, issue *Issue)) http.HandlerFunc {
+	return f.withProject(func(rw http.ResponseWriter, req *http.Request, project *Project) {
+		iid, _ := strconv.Atoi(req.PathValue("iid"))
+
+		issue, ok := f.issues[project.PathWithNamespace][iid]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "404 Not found")
+			return
+		}
+
+		fn(rw, req, issue)
+	})
+}
+
+func decode(rw http.ResponseWriter, req *http.Request, value any) bool {
+	err := json.NewDecoder(req.Body).Decode(value)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, "400 Bad request - "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeError(rw http.ResponseWriter, status int, message string) {
+	writeJSON(rw, status, map[string]string{"message": message})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, value any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	_ = json.NewEncoder(rw).Encode(value)
+}
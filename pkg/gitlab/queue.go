@@ -0,0 +1,56 @@
+package gitlab
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// Finder a merge request search manager.
+type Finder struct {
+	client  *Client
+	markers conf.Markers
+}
+
+// NewFinder creates a new finder.
+func NewFinder(client *Client, markers conf.Markers) Finder {
+	return Finder{
+		client:  client,
+		markers: markers,
+	}
+}
+
+// Search searches the opened merge requests of a group with the labels, and without the excluded labels.
+// The merge requests are grouped by project, sorted by priority (the highest first) and then by update (the oldest first).
+func (f Finder) Search(ctx context.Context, group string, labels, excludedLabels []string) (map[string][]*MergeRequest, error) {
+	mrs, err := f.client.ListGroupMergeRequests(ctx, group, ListOptions{Labels: labels})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the merge requests: %w", err)
+	}
+
+	overview := make(map[string][]*MergeRequest)
+
+	for _, mr := range mrs {
+		if slices.ContainsFunc(excludedLabels, mr.HasLabel) {
+			continue
+		}
+
+		overview[mr.ProjectPath()] = append(overview[mr.ProjectPath()], mr)
+	}
+
+	for _, project := range overview {
+		slices.SortStableFunc(project, func(a, b *MergeRequest) int {
+			return cmp.Compare(f.GetPriority(b), f.GetPriority(a))
+		})
+	}
+
+	return overview, nil
+}
+
+// GetPriority gets the priority of a merge request from its priority labels.
+func (f Finder) GetPriority(mr *MergeRequest) int {
+	return f.markers.GetPriority(mr.Labels...)
+}
@@ -0,0 +1,58 @@
+package gitlab
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+var fakeMarkers = conf.Markers{
+	LightReview:       "bot/light-review",
+	NeedMerge:         "status/3-needs-merge",
+	MergeInProgress:   "status/4-merge-in-progress",
+	MergeMethodPrefix: "bot/merge-method-",
+	MergeRetryPrefix:  "bot/merge-retry-",
+	NeedHumanMerge:    "bot/need-human-merge",
+	MergeNoRebase:     "bot/merge-no-rebase",
+	NoMerge:           "bot/no-merge",
+	Priorities: map[string]int{
+		"bot/priority-high": 10,
+		"bot/priority-low":  -10,
+	},
+}
+
+func TestFinder_Search(t *testing.T) {
+	fake := newFakeGitLab()
+
+	fake.addProject(&Project{PathWithNamespace: "traefik/traefik"})
+	fake.addProject(&Project{PathWithNamespace: "traefik/lobicornis"})
+
+	fake.addMergeRequest("traefik/traefik", &MergeRequest{Labels: []string{fakeMarkers.NeedMerge}, UpdatedAt: time.Now().Add(-time.Hour)})
+	fake.addMergeRequest("traefik/traefik", &MergeRequest{Labels: []string{fakeMarkers.NeedMerge}, UpdatedAt: time.Now().Add(-2 * time.Hour)})
+	fake.addMergeRequest("traefik/traefik", &MergeRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.NoMerge}})
+	fake.addMergeRequest("traefik/traefik", &MergeRequest{Labels: []string{fakeMarkers.NeedMerge, "bot/priority-low"}, UpdatedAt: time.Now().Add(-3 * time.Hour)})
+	fake.addMergeRequest("traefik/traefik", &MergeRequest{Labels: []string{fakeMarkers.NeedMerge, "bot/priority-high"}})
+	fake.addMergeRequest("traefik/lobicornis", &MergeRequest{Labels: []string{fakeMarkers.NeedMerge}})
+
+	finder := NewFinder(fake.newServer(t), fakeMarkers)
+
+	overview, err := finder.Search(t.Context(), "traefik", []string{fakeMarkers.NeedMerge}, []string{fakeMarkers.NoMerge})
+	require.NoError(t, err)
+
+	iids := make(map[string][]int)
+	for path, mrs := range overview {
+		for _, mr := range mrs {
+			iids[path] = append(iids[path], mr.IID)
+		}
+	}
+
+	expected := map[string][]int{
+		"traefik/traefik":    {5, 2, 1, 4},
+		"traefik/lobicornis": {1},
+	}
+
+	assert.Equal(t, expected, iids)
+}
@@ -0,0 +1,185 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// Merge request states.
+const (
+	StateOpened = "opened"
+	StateMerged = "merged"
+)
+
+// Pipeline statuses.
+const (
+	PipelineSuccess  = "success"
+	PipelineSkipped  = "skipped"
+	PipelineFailed   = "failed"
+	PipelineCanceled = "canceled"
+)
+
+// Project merge methods.
+const (
+	MergeMethodMerge = "merge"
+)
+
+// Repository a GitLab project: the merge requests of the project for the pipeline of the pull requests.
+type Repository struct {
+	client *Client
+
+	debug       bool
+	cloneRebase bool
+
+	timeouts conf.Timeouts
+	git      conf.Git
+
+	// path the path of the project (group/project).
+	path  string
+	token string
+
+	config conf.RepoConfig
+
+	metrics *metrics.Metrics
+}
+
+// NewRepository creates a new GitLab project.
+func NewRepository(client *Client, path, token string, timeouts conf.Timeouts, gitConfig conf.Git, config conf.RepoConfig, cloneRebase bool, recorder *metrics.Metrics) *Repository {
+	return &Repository{
+		client:      client,
+		debug:       zerolog.GlobalLevel() <= zerolog.DebugLevel,
+		cloneRebase: cloneRebase,
+		timeouts:    timeouts,
+		git:         gitConfig,
+		path:        path,
+		token:       token,
+		config:      config,
+		metrics:     recorder,
+	}
+}
+
+// GetPullRequest gets a merge request.
+func (r *Repository) GetPullRequest(ctx context.Context, iid int) (*MergeRequest, error) {
+	return r.client.GetMergeRequest(ctx, r.path, iid)
+}
+
+// Describe gets the information of a merge request used by the pipeline.
+func (r *Repository) Describe(mr *MergeRequest) repository.PullRequest {
+	info := repository.PullRequest{
+		Number:       mr.IID,
+		Title:        mr.Title,
+		Body:         mr.Description,
+		Labels:       mr.Labels,
+		State:        mr.State,
+		Merged:       mr.State == StateMerged,
+		Draft:        mr.Draft || mr.DetailedMergeStatus == "draft_status",
+		HasMilestone: mr.Milestone != nil,
+		Conflicts:    mr.HasConflicts,
+	}
+
+	if mr.State == StateOpened {
+		info.State = repository.StateOpen
+	}
+
+	switch mr.DetailedMergeStatus {
+	case "checking", "unchecked", "preparing":
+		info.Waiting = "mergeability check"
+	case "discussions_not_resolved", "blocked_status", "requested_changes", "not_open":
+		info.Blocked = fmt.Sprintf("the detailed merge status is %q", mr.DetailedMergeStatus)
+	}
+
+	if mr.RebaseInProgress {
+		info.Waiting = "rebase"
+	}
+
+	return info
+}
+
+// CheckReviews checks if a merge request has the required number of approvals, and satisfies the approval rules of the project.
+func (r *Repository) CheckReviews(ctx context.Context, mr *MergeRequest, minReview int) error {
+	approvals, err := r.client.GetApprovals(ctx, r.path, mr.IID)
+	if err != nil {
+		return err
+	}
+
+	if len(approvals.ApprovedBy) < minReview {
+		return fmt.Errorf("need more review [%d/%d]", len(approvals.ApprovedBy), minReview)
+	}
+
+	if !approvals.Approved {
+		return fmt.Errorf("the approval rules are not satisfied: %d approvals left", approvals.ApprovalsLeft)
+	}
+
+	return nil
+}
+
+// GetChecksState gets the state of the head pipeline: the aggregated state of the checks.
+// A merge request without pipeline is successful.
+func (r *Repository) GetChecksState(_ context.Context, mr *MergeRequest) (string, error) {
+	if mr.HeadPipeline == nil {
+		return repository.Success, nil
+	}
+
+	switch mr.HeadPipeline.Status {
+	case PipelineSuccess, PipelineSkipped:
+		return repository.Success, nil
+	case PipelineFailed, PipelineCanceled:
+		return "", fmt.Errorf("the pipeline #%d is %s", mr.HeadPipeline.ID, mr.HeadPipeline.Status)
+	default:
+		return repository.Pending, nil
+	}
+}
+
+// NeedUpdate checks if the merge requests must be up-to-date.
+// The merge requests of the projects with a linear history (fast-forward, or semi-linear) must be up-to-date.
+func (r *Repository) NeedUpdate(ctx context.Context, _ *MergeRequest) (bool, error) {
+	project, err := r.client.GetProject(ctx, r.path)
+	if err != nil {
+		return false, fmt.Errorf("unable to get the project: %w", err)
+	}
+
+	return project.MergeMethod != MergeMethodMerge, nil
+}
+
+// IsUpToDate checks if the source branch of a merge request contains the head of the target branch.
+func (r *Repository) IsUpToDate(_ context.Context, mr *MergeRequest) (bool, error) {
+	return mr.DivergedCommitsCount == 0, nil
+}
+
+// AddLabels adds some labels on a merge request.
+// The labels of the merge request are refreshed by the response.
+func (r *Repository) AddLabels(ctx context.Context, mr *MergeRequest, labels ...string) error {
+	updated, err := r.client.UpdateLabels(ctx, r.path, mr.IID, labels, nil)
+	if err != nil {
+		return err
+	}
+
+	mr.Labels = updated.Labels
+
+	return nil
+}
+
+// RemoveLabels removes some labels of a merge request.
+// The labels of the merge request are refreshed by the response.
+func (r *Repository) RemoveLabels(ctx context.Context, mr *MergeRequest, labels ...string) error {
+	updated, err := r.client.UpdateLabels(ctx, r.path, mr.IID, nil, labels)
+	if err != nil {
+		return err
+	}
+
+	mr.Labels = updated.Labels
+
+	return nil
+}
+
+// AddComment adds a note on a merge request.
+func (r *Repository) AddComment(ctx context.Context, mr *MergeRequest, message string) error {
+	_, err := r.client.CreateNote(ctx, r.path, mr.IID, message)
+
+	return err
+}
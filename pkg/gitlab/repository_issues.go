@@ -0,0 +1,82 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	closingRE = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?|implement(?:s|ed)?):?((?:\s*(?:,|and)?\s*#\d+\b)+)`)
+	issueRE   = regexp.MustCompile(`#(\d+)`)
+)
+
+// closeRelatedIssues closes the issues referenced by the closing patterns of the description of a merge request.
+// GitLab closes these issues only when a merge request is merged into the default branch:
+// the issues closed for another branch get a comment.
+func (r *Repository) closeRelatedIssues(ctx context.Context, mr *MergeRequest) error {
+	logger := log.Ctx(ctx)
+
+	issues := parseClosingIssues(mr.Description)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	project, err := r.client.GetProject(ctx, r.path)
+	if err != nil {
+		return fmt.Errorf("unable to get the project: %w", err)
+	}
+
+	var milestoneID int
+	if mr.Milestone != nil {
+		milestoneID = mr.Milestone.ID
+	}
+
+	for _, iid := range issues {
+		logger.Info().Msgf("closes issue #%d", iid)
+
+		_, err = r.client.CloseIssue(ctx, r.path, iid, milestoneID)
+		if err != nil {
+			return fmt.Errorf("unable to close issue #%d: %w", iid, err)
+		}
+
+		if mr.TargetBranch == project.DefaultBranch {
+			continue
+		}
+
+		_, err = r.client.CreateIssueNote(ctx, r.path, iid, fmt.Sprintf("Closed by !%d.", mr.IID))
+		if err != nil {
+			return fmt.Errorf("unable to add comment on issue #%d: %w", iid, err)
+		}
+	}
+
+	return nil
+}
+
+// parseClosingIssues gets the issues referenced by the closing patterns of a text: "Closes #1, #2 and #3".
+func parseClosingIssues(text string) []int {
+	var issues []int
+
+	seen := make(map[int]struct{})
+
+	for _, match := range closingRE.FindAllStringSubmatch(text, -1) {
+		for _, ref := range issueRE.FindAllStringSubmatch(match[1], -1) {
+			iid, err := strconv.Atoi(ref[1])
+			if err != nil {
+				continue
+			}
+
+			if _, ok := seen[iid]; ok {
+				continue
+			}
+
+			seen[iid] = struct{}{}
+			issues = append(issues, iid)
+		}
+	}
+
+	return issues
+}
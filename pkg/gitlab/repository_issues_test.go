@@ -0,0 +1,52 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseClosingIssues(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		text     string
+		expected []int
+	}{
+		{
+			desc: "no issue",
+			text: "Fix the bug in the router.",
+		},
+		{
+			desc:     "one issue",
+			text:     "Fixes #12",
+			expected: []int{12},
+		},
+		{
+			desc:     "colon",
+			text:     "Closes: #12",
+			expected: []int{12},
+		},
+		{
+			desc:     "list",
+			text:     "This MR closes #1, #2 and #3.",
+			expected: []int{1, 2, 3},
+		},
+		{
+			desc:     "multiple patterns",
+			text:     "Resolves #4\n\nImplements #5, fixes #4",
+			expected: []int{4, 5},
+		},
+		{
+			desc: "reference without closing pattern",
+			text: "Related to #7",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, parseClosingIssues(test.text))
+		})
+	}
+}
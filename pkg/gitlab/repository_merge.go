@@ -0,0 +1,40 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// MergeMethods gets the merge methods supported by GitLab:
+// the merge method of a merge request is the merge method of its project (merge commit, semi-linear history, or fast-forward),
+// only the squash can be chosen by merge request.
+func (*Repository) MergeMethods() []string {
+	return []string{conf.MergeMethodSquash}
+}
+
+// Merge merges a merge request with the API (squash), and closes the related issues.
+func (r *Repository) Merge(ctx context.Context, mr *MergeRequest, mergeMethod string, message repository.CommitMessage) error {
+	if mergeMethod != conf.MergeMethodSquash {
+		return fmt.Errorf("the merge method [%s] is not supported by GitLab", mergeMethod)
+	}
+
+	options := MergeOptions{
+		SHA:                 mr.SHA,
+		Squash:              true,
+		SquashCommitMessage: strings.TrimSpace(message.Title + "\n\n" + message.Body),
+	}
+
+	_, err := r.client.Merge(ctx, r.path, mr.IID, options)
+	if err != nil {
+		return err
+	}
+
+	err = r.closeRelatedIssues(ctx, mr)
+	repository.IgnoreError(ctx, err)
+
+	return nil
+}
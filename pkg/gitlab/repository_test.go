@@ -0,0 +1,391 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+const fakeProjectPath = "traefik/traefik"
+
+func TestRepository_Describe(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		mr       *MergeRequest
+		expected repository.PullRequest
+	}{
+		{
+			desc: "opened",
+			mr: &MergeRequest{
+				IID:         1,
+				Title:       "Fix the bug",
+				Description: "Fixes #3",
+				Labels:      []string{"bot/need-merge"},
+				State:       StateOpened,
+				Milestone:   &Milestone{ID: 7},
+			},
+			expected: repository.PullRequest{
+				Number:       1,
+				Title:        "Fix the bug",
+				Body:         "Fixes #3",
+				Labels:       []string{"bot/need-merge"},
+				State:        repository.StateOpen,
+				HasMilestone: true,
+			},
+		},
+		{
+			desc:     "merged",
+			mr:       &MergeRequest{State: StateMerged},
+			expected: repository.PullRequest{State: StateMerged, Merged: true},
+		},
+		{
+			desc:     "locked",
+			mr:       &MergeRequest{State: "locked"},
+			expected: repository.PullRequest{State: "locked"},
+		},
+		{
+			desc:     "conflicts",
+			mr:       &MergeRequest{State: StateOpened, HasConflicts: true},
+			expected: repository.PullRequest{State: repository.StateOpen, Conflicts: true},
+		},
+		{
+			desc:     "draft status",
+			mr:       &MergeRequest{State: StateOpened, DetailedMergeStatus: "draft_status"},
+			expected: repository.PullRequest{State: repository.StateOpen, Draft: true},
+		},
+		{
+			desc:     "mergeability check",
+			mr:       &MergeRequest{State: StateOpened, DetailedMergeStatus: "checking"},
+			expected: repository.PullRequest{State: repository.StateOpen, Waiting: "mergeability check"},
+		},
+		{
+			desc:     "rebase in progress",
+			mr:       &MergeRequest{State: StateOpened, DetailedMergeStatus: "mergeable", RebaseInProgress: true, DivergedCommitsCount: 2},
+			expected: repository.PullRequest{State: repository.StateOpen, Waiting: "rebase"},
+		},
+		{
+			desc: "unresolved discussions",
+			mr:   &MergeRequest{State: StateOpened, DetailedMergeStatus: "discussions_not_resolved"},
+			expected: repository.PullRequest{
+				State:   repository.StateOpen,
+				Blocked: `the detailed merge status is "discussions_not_resolved"`,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &Repository{}
+
+			assert.Equal(t, test.expected, repo.Describe(test.mr))
+		})
+	}
+}
+
+func TestRepository_IsUpToDate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		mr       *MergeRequest
+		expected bool
+	}{
+		{
+			desc:     "up-to-date",
+			mr:       &MergeRequest{State: StateOpened},
+			expected: true,
+		},
+		{
+			desc: "not up-to-date",
+			mr:   &MergeRequest{State: StateOpened, DetailedMergeStatus: "need_rebase", DivergedCommitsCount: 2},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &Repository{}
+
+			upToDate, err := repo.IsUpToDate(t.Context(), test.mr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, upToDate)
+		})
+	}
+}
+
+func TestRepository_CheckReviews(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		approvals int
+		required  int
+		minReview int
+		expected  string
+	}{
+		{
+			desc:      "approved",
+			approvals: 1,
+			minReview: 1,
+		},
+		{
+			desc:      "missing approvals",
+			minReview: 1,
+			expected:  "need more review [0/1]",
+		},
+		{
+			desc:      "approval rules",
+			approvals: 1,
+			required:  2,
+			expected:  "the approval rules are not satisfied: 1 approvals left",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitLab()
+			fake.requiredApprovals = test.required
+			fake.addProject(&Project{PathWithNamespace: fakeProjectPath})
+
+			mr := fake.addMergeRequest(fakeProjectPath, &MergeRequest{})
+
+			for range test.approvals {
+				fake.approve(fakeProjectPath, mr.IID, "ldez")
+			}
+
+			repo := newFakeRepository(fake.newServer(t), conf.RepoConfig{}, false)
+
+			err := repo.CheckReviews(t.Context(), mr, test.minReview)
+
+			if test.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestRepository_GetChecksState(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		pipeline      *Pipeline
+		expected      string
+		expectedError string
+	}{
+		{
+			desc:     "no pipeline",
+			expected: repository.Success,
+		},
+		{
+			desc:     "success",
+			pipeline: &Pipeline{ID: 1, Status: PipelineSuccess},
+			expected: repository.Success,
+		},
+		{
+			desc:     "skipped",
+			pipeline: &Pipeline{ID: 1, Status: PipelineSkipped},
+			expected: repository.Success,
+		},
+		{
+			desc:     "running",
+			pipeline: &Pipeline{ID: 1, Status: "running"},
+			expected: repository.Pending,
+		},
+		{
+			desc:          "failed",
+			pipeline:      &Pipeline{ID: 1, Status: PipelineFailed},
+			expectedError: "the pipeline #1 is failed",
+		},
+		{
+			desc:          "canceled",
+			pipeline:      &Pipeline{ID: 2, Status: PipelineCanceled},
+			expectedError: "the pipeline #2 is canceled",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &Repository{}
+
+			state, err := repo.GetChecksState(t.Context(), &MergeRequest{HeadPipeline: test.pipeline})
+
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, state)
+		})
+	}
+}
+
+func TestRepository_NeedUpdate(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		mergeMethod string
+		expected    bool
+	}{
+		{
+			desc:        "merge commit",
+			mergeMethod: MergeMethodMerge,
+		},
+		{
+			desc:        "semi-linear history",
+			mergeMethod: "rebase_merge",
+			expected:    true,
+		},
+		{
+			desc:        "fast-forward",
+			mergeMethod: "ff",
+			expected:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitLab()
+			fake.addProject(&Project{PathWithNamespace: fakeProjectPath, MergeMethod: test.mergeMethod})
+
+			repo := newFakeRepository(fake.newServer(t), conf.RepoConfig{}, false)
+
+			needUpdate, err := repo.NeedUpdate(t.Context(), &MergeRequest{})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, needUpdate)
+		})
+	}
+}
+
+func TestRepository_labels(t *testing.T) {
+	fake := newFakeGitLab()
+	fake.addProject(&Project{PathWithNamespace: fakeProjectPath})
+
+	mr := fake.addMergeRequest(fakeProjectPath, &MergeRequest{Labels: []string{fakeMarkers.NeedMerge}})
+
+	repo := newFakeRepository(fake.newServer(t), conf.RepoConfig{}, false)
+
+	err := repo.AddLabels(t.Context(), mr, fakeMarkers.MergeInProgress)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress}, mr.Labels)
+
+	err = repo.RemoveLabels(t.Context(), mr, fakeMarkers.NeedMerge)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{fakeMarkers.MergeInProgress}, mr.Labels)
+	assert.Equal(t, mr.Labels, fake.mergeRequest(fakeProjectPath, mr.IID).Labels)
+}
+
+func TestRepository_Merge(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		mergeMethod string
+		message     repository.CommitMessage
+		expected    MergeOptions
+	}{
+		{
+			desc:        "squash",
+			mergeMethod: conf.MergeMethodSquash,
+			message:     repository.CommitMessage{Title: "Fix the bug", Body: "Co-authored-by: ldez <ldez@example.com>"},
+			expected: MergeOptions{
+				SHA:                 "sha-1",
+				Squash:              true,
+				SquashCommitMessage: "Fix the bug\n\nCo-authored-by: ldez <ldez@example.com>",
+			},
+		},
+		{
+			desc:        "squash without body",
+			mergeMethod: conf.MergeMethodSquash,
+			message:     repository.CommitMessage{Title: "Fix the bug"},
+			expected:    MergeOptions{SHA: "sha-1", Squash: true, SquashCommitMessage: "Fix the bug"},
+		},
+		{
+			desc:        "squash with the message of GitLab",
+			mergeMethod: conf.MergeMethodSquash,
+			expected:    MergeOptions{SHA: "sha-1", Squash: true},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitLab()
+			fake.addProject(&Project{PathWithNamespace: fakeProjectPath})
+
+			mr := fake.addMergeRequest(fakeProjectPath, &MergeRequest{})
+
+			repo := newFakeRepository(fake.newServer(t), conf.RepoConfig{}, false)
+
+			err := repo.Merge(t.Context(), mr, test.mergeMethod, test.message)
+			require.NoError(t, err)
+
+			options, merged := fake.mergeOptions(fakeProjectPath, mr.IID)
+			require.True(t, merged)
+
+			assert.Equal(t, test.expected, options)
+			assert.Equal(t, StateMerged, fake.mergeRequest(fakeProjectPath, mr.IID).State)
+		})
+	}
+}
+
+func TestRepository_Merge_unsupported(t *testing.T) {
+	fake := newFakeGitLab()
+	fake.addProject(&Project{PathWithNamespace: fakeProjectPath})
+
+	mr := fake.addMergeRequest(fakeProjectPath, &MergeRequest{})
+
+	repo := newFakeRepository(fake.newServer(t), conf.RepoConfig{}, false)
+
+	err := repo.Merge(t.Context(), mr, conf.MergeMethodRebase, repository.CommitMessage{})
+	require.EqualError(t, err, "the merge method [rebase] is not supported by GitLab")
+
+	_, merged := fake.mergeOptions(fakeProjectPath, mr.IID)
+	assert.False(t, merged)
+}
+
+func TestRepository_Merge_closeIssues(t *testing.T) {
+	fake := newFakeGitLab()
+	fake.addProject(&Project{PathWithNamespace: fakeProjectPath})
+
+	fake.addIssue(fakeProjectPath, 3)
+	fake.addIssue(fakeProjectPath, 4)
+
+	mr := fake.addMergeRequest(fakeProjectPath, &MergeRequest{
+		Description:  "Fixes #3, #4",
+		TargetBranch: "v1.0",
+		Milestone:    &Milestone{ID: 7, Title: "v1.0.1"},
+	})
+
+	repo := newFakeRepository(fake.newServer(t), conf.RepoConfig{}, false)
+
+	err := repo.Merge(t.Context(), mr, conf.MergeMethodSquash, repository.CommitMessage{})
+	require.NoError(t, err)
+
+	for _, iid := range []int{3, 4} {
+		issue := fake.issue(fakeProjectPath, iid)
+
+		assert.Equal(t, "closed", issue.State)
+		assert.Equal(t, &Milestone{ID: 7}, issue.Milestone)
+	}
+
+	assert.Equal(t, []string{"Closed by !1."}, fake.noteBodies(fakeProjectPath, "#3"))
+}
+
+// newFakeRepository creates a GitLab project for the project traefik/traefik.
+func newFakeRepository(client *Client, config conf.RepoConfig, cloneRebase bool) *Repository {
+	gitConfig := conf.Git{UserName: "lobicornis", Email: "lobicornis@example.com"}
+
+	return NewRepository(client, fakeProjectPath, "secret", conf.Timeouts{}, gitConfig, config, cloneRebase, nil)
+}
@@ -0,0 +1,159 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ldez/go-git-cmd-wrapper/v2/clone"
+	"github.com/ldez/go-git-cmd-wrapper/v2/config"
+	"github.com/ldez/go-git-cmd-wrapper/v2/fetch"
+	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
+	"github.com/ldez/go-git-cmd-wrapper/v2/push"
+	"github.com/ldez/go-git-cmd-wrapper/v2/rebase"
+	"github.com/ldez/go-git-cmd-wrapper/v2/remote"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+	"github.com/traefik/lobicornis/v3/pkg/repository"
+)
+
+// CanUpdate checks if the source branch of a merge request can be rebased.
+func (r *Repository) CanUpdate(ctx context.Context, mr *MergeRequest) error {
+	if mr.SourceProjectID != mr.TargetProjectID {
+		return nil
+	}
+
+	protected, err := r.isProtectedBranch(ctx, mr.SourceBranch)
+	if err != nil {
+		return err
+	}
+
+	if protected {
+		return fmt.Errorf("the branch %s on the main repository is protected and cannot be updated", mr.SourceBranch)
+	}
+
+	return nil
+}
+
+// Update rebases the source branch of a merge request on its target branch, with the API or with a clone.
+func (r *Repository) Update(ctx context.Context, mr *MergeRequest) (string, error) {
+	if r.cloneRebase {
+		return repository.ActionRebase, r.cloneAndRebase(ctx, mr)
+	}
+
+	// the rebase is asynchronous: the merge request is processed again by the next run.
+	return repository.ActionRebase, r.client.Rebase(ctx, r.path, mr.IID)
+}
+
+// isProtectedBranch checks if a branch of the project must never be rebased.
+// The default branch is always protected.
+func (r *Repository) isProtectedBranch(ctx context.Context, branch string) (bool, error) {
+	for _, pattern := range r.config.GetProtectedBranches() {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true, nil
+		}
+	}
+
+	project, err := r.client.GetProject(ctx, r.path)
+	if err != nil {
+		return false, fmt.Errorf("unable to get the project: %w", err)
+	}
+
+	return branch == project.DefaultBranch, nil
+}
+
+// cloneAndRebase rebases the source branch of a merge request with a clone, and pushes it.
+func (r *Repository) cloneAndRebase(ctx context.Context, mr *MergeRequest) error {
+	logger := log.Ctx(ctx)
+
+	target, err := r.client.GetProject(ctx, r.path)
+	if err != nil {
+		return fmt.Errorf("unable to get the target project: %w", err)
+	}
+
+	source := target
+	if mr.SourceProjectID != mr.TargetProjectID {
+		source, err = r.client.GetProject(ctx, strconv.Itoa(mr.SourceProjectID))
+		if err != nil {
+			return fmt.Errorf("unable to get the source project: %w", err)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "myrmica-lobicornis")
+	if err != nil {
+		return err
+	}
+
+	defer func() { repository.IgnoreError(ctx, os.RemoveAll(dir)) }()
+
+	logger.Info().Msgf("Target branch: %s - Source branch: %s", mr.TargetBranch, mr.SourceBranch)
+
+	output, err := repository.GitWithTimeout(ctx, r.timeouts.Clone, func(ctx context.Context) (string, error) {
+		return git.CloneWithContext(ctx,
+			clone.Repository(r.repositoryURL(source)),
+			clone.Branch(mr.SourceBranch),
+			clone.Directory(dir),
+			git.Debugger(r.debug))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone: %w\n %s", err, output)
+	}
+
+	for key, value := range map[string]string{"user.name": r.git.UserName, "user.email": r.git.Email} {
+		output, err = git.ConfigWithContext(ctx, global.UpperC(dir), config.Entry(key, value))
+		if err != nil {
+			return fmt.Errorf("failed to configure git: %w\n %s", err, output)
+		}
+	}
+
+	output, err = git.RemoteWithContext(ctx, global.UpperC(dir), remote.Add(repository.RemoteUpstream, r.repositoryURL(target)), git.Debugger(r.debug))
+	if err != nil {
+		return fmt.Errorf("failed to add remote: %w\n %s", err, output)
+	}
+
+	output, err = repository.GitWithTimeout(ctx, r.timeouts.Fetch, func(ctx context.Context) (string, error) {
+		return git.FetchWithContext(ctx, global.UpperC(dir), fetch.NoTags, fetch.Remote(repository.RemoteUpstream), fetch.RefSpec(mr.TargetBranch), git.Debugger(r.debug))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s/%s: %w\n %s", repository.RemoteUpstream, mr.TargetBranch, err, output)
+	}
+
+	output, err = repository.GitWithTimeout(ctx, r.timeouts.Rebase, func(ctx context.Context) (string, error) {
+		return git.RebaseWithContext(ctx, global.UpperC(dir), rebase.RebaseMerges(""), rebase.Branch(repository.RemoteUpstream+"/"+mr.TargetBranch), git.Debugger(r.debug))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rebase: %w\n %s", err, output)
+	}
+
+	start := time.Now()
+
+	output, err = repository.GitWithTimeout(ctx, r.timeouts.Push, func(ctx context.Context) (string, error) {
+		return git.PushWithContext(ctx, global.UpperC(dir), push.ForceWithLease, push.Remote(repository.RemoteOrigin), push.RefSpec(mr.SourceBranch), git.Debugger(r.debug))
+	})
+
+	r.metrics.GitDuration(metrics.OperationPush, time.Since(start))
+
+	if err != nil {
+		return fmt.Errorf("failed to push branch %s: %w\n %s", mr.SourceBranch, err, output)
+	}
+
+	return nil
+}
+
+// repositoryURL gets the URL of the Git repository of a project, with the credentials.
+func (r *Repository) repositoryURL(project *Project) string {
+	if r.git.SSH {
+		return project.SSHURLToRepo
+	}
+
+	if r.token == "" || !strings.HasPrefix(project.HTTPURLToRepo, "https://") {
+		return project.HTTPURLToRepo
+	}
+
+	return "https://oauth2:" + r.token + "@" + strings.TrimPrefix(project.HTTPURLToRepo, "https://")
+}
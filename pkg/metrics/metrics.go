@@ -0,0 +1,275 @@
+// Package metrics provides the Prometheus metrics of the bot.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Git operations.
+const (
+	OperationClone = "clone"
+	OperationPush  = "push"
+)
+
+// MethodTrain the merge method of the pull requests merged by a merge train.
+const MethodTrain = "train"
+
+// Metrics the metrics of the bot.
+// All the methods can be called on a nil Metrics: the metrics are disabled.
+type Metrics struct {
+	processed    *prometheus.CounterVec
+	merged       *prometheus.CounterVec
+	updated      *prometheus.CounterVec
+	human        *prometheus.CounterVec
+	checkStates  *prometheus.CounterVec
+	queueSize    *prometheus.GaugeVec
+	mergeTime    *prometheus.HistogramVec
+	gitDuration  *prometheus.HistogramVec
+	apiRequests  *prometheus.CounterVec
+	apiRateLimit *prometheus.GaugeVec
+
+	forgeRequests  *prometheus.CounterVec
+	forgeRateLimit *prometheus.GaugeVec
+
+	handler http.Handler
+}
+
+// New creates the metrics, registered in their own registry.
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+	factory := promauto.With(registry)
+
+	return &Metrics{
+		processed: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_pull_requests_processed_total",
+			Help: "Number of pull requests processed.",
+		}, []string{"repo"}),
+		merged: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_pull_requests_merged_total",
+			Help: "Number of pull requests merged.",
+		}, []string{"repo", "method"}),
+		updated: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_pull_requests_updated_total",
+			Help: "Number of pull requests updated with their base branch.",
+		}, []string{"repo", "action"}),
+		human: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_pull_requests_need_human_total",
+			Help: "Number of pull requests sent to a human.",
+		}, []string{"repo", "reason"}),
+		checkStates: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_check_states_total",
+			Help: "Number of aggregated check states evaluated.",
+		}, []string{"repo", "state"}),
+		queueSize: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lobicornis_queue_size",
+			Help: "Number of pull requests waiting to be merged.",
+		}, []string{"repo"}),
+		mergeTime: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lobicornis_merge_time_seconds",
+			Help:    "Time between the addition of the need-merge label and the merge.",
+			Buckets: []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400, 259200},
+		}, []string{"repo"}),
+		gitDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lobicornis_git_duration_seconds",
+			Help:    "Duration of the git operations.",
+			Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, []string{"operation"}),
+		apiRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_github_requests_total",
+			Help: "Number of GitHub API requests.",
+		}, []string{"code"}),
+		apiRateLimit: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lobicornis_github_rate_limit_remaining",
+			Help: "Number of GitHub API requests remaining in the current rate limit window.",
+		}, []string{"owner", "resource"}),
+		forgeRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "lobicornis_forge_requests_total",
+			Help: "Number of API requests to the forge (GitLab, Gitea).",
+		}, []string{"provider", "owner", "code"}),
+		forgeRateLimit: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lobicornis_forge_rate_limit_remaining",
+			Help: "Number of API requests to the forge remaining in the current rate limit window.",
+		}, []string{"provider", "owner"}),
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// PullRequestProcessed counts a processed pull request.
+func (m *Metrics) PullRequestProcessed(repo string) {
+	if m == nil {
+		return
+	}
+
+	m.processed.WithLabelValues(repo).Inc()
+}
+
+// PullRequestMerged counts a merged pull request.
+func (m *Metrics) PullRequestMerged(repo, method string) {
+	if m == nil {
+		return
+	}
+
+	m.merged.WithLabelValues(repo, method).Inc()
+}
+
+// PullRequestUpdated counts an updated pull request (rebase or merge).
+func (m *Metrics) PullRequestUpdated(repo, action string) {
+	if m == nil {
+		return
+	}
+
+	m.updated.WithLabelValues(repo, action).Inc()
+}
+
+// PullRequestNeedHuman counts a pull request sent to a human.
+func (m *Metrics) PullRequestNeedHuman(repo, reason string) {
+	if m == nil {
+		return
+	}
+
+	m.human.WithLabelValues(repo, reason).Inc()
+}
+
+// CheckState counts an aggregated check state.
+func (m *Metrics) CheckState(repo, state string) {
+	if m == nil {
+		return
+	}
+
+	m.checkStates.WithLabelValues(repo, state).Inc()
+}
+
+// QueueSize sets the number of pull requests waiting to be merged.
+func (m *Metrics) QueueSize(repo string, size int) {
+	if m == nil {
+		return
+	}
+
+	m.queueSize.WithLabelValues(repo).Set(float64(size))
+}
+
+// ResetQueueSizes removes the queue sizes of all the repositories.
+func (m *Metrics) ResetQueueSizes() {
+	if m == nil {
+		return
+	}
+
+	m.queueSize.Reset()
+}
+
+// MergeTime observes the time between the addition of the need-merge label and the merge.
+func (m *Metrics) MergeTime(repo string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.mergeTime.WithLabelValues(repo).Observe(duration.Seconds())
+}
+
+// GitDuration observes the duration of a git operation.
+func (m *Metrics) GitDuration(operation string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.gitDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// ServeHTTP writes the metrics with the Prometheus exposition format.
+func (m *Metrics) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if m == nil {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		return
+	}
+
+	m.handler.ServeHTTP(rw, req)
+}
+
+// Transport instruments the requests to the GitHub API made with the credentials of an owner.
+// Returns the base transport if the metrics are disabled.
+func (m *Metrics) Transport(base http.RoundTripper, owner string) http.RoundTripper {
+	if m == nil {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{metrics: m, base: base, owner: owner}
+}
+
+type transport struct {
+	metrics *Metrics
+	base    http.RoundTripper
+	owner   string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.metrics.apiRequests.WithLabelValues("error").Inc()
+		return resp, err
+	}
+
+	t.metrics.apiRequests.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+
+	remaining, errConv := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if errConv == nil {
+		resource := resp.Header.Get("X-RateLimit-Resource")
+		if resource == "" {
+			resource = "core"
+		}
+
+		t.metrics.apiRateLimit.WithLabelValues(t.owner, resource).Set(float64(remaining))
+	}
+
+	return resp, nil
+}
+
+// ForgeTransport instruments the requests to the API of a forge (GitLab, Gitea) made for an owner (a group for GitLab).
+// Returns the base transport if the metrics are disabled.
+func (m *Metrics) ForgeTransport(base http.RoundTripper, provider, owner string) http.RoundTripper {
+	if m == nil {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &forgeTransport{metrics: m, base: base, provider: provider, owner: owner}
+}
+
+type forgeTransport struct {
+	metrics  *Metrics
+	base     http.RoundTripper
+	provider string
+	owner    string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *forgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.metrics.forgeRequests.WithLabelValues(t.provider, t.owner, "error").Inc()
+		return resp, err
+	}
+
+	t.metrics.forgeRequests.WithLabelValues(t.provider, t.owner, strconv.Itoa(resp.StatusCode)).Inc()
+
+	// only sent by GitLab.
+	remaining, errConv := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if errConv == nil {
+		t.metrics.forgeRateLimit.WithLabelValues(t.provider, t.owner).Set(float64(remaining))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := New()
+
+	m.PullRequestProcessed("traefik/traefik")
+	m.PullRequestProcessed("traefik/traefik")
+	m.PullRequestMerged("traefik/traefik", "squash")
+	m.PullRequestUpdated("traefik/traefik", "rebase")
+	m.PullRequestNeedHuman("traefik/traefik", "milestone")
+	m.CheckState("traefik/traefik", "pending")
+	m.QueueSize("traefik/traefik", 3)
+	m.QueueSize("traefik/yaegi", 1)
+	m.MergeTime("traefik/traefik", 10*time.Minute)
+	m.GitDuration(OperationClone, 3*time.Second)
+	m.GitDuration(OperationClone, 500*time.Millisecond)
+
+	rw := httptest.NewRecorder()
+	m.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	expected := []string{
+		`# TYPE lobicornis_pull_requests_processed_total counter`,
+		`lobicornis_pull_requests_processed_total{repo="traefik/traefik"} 2`,
+		`lobicornis_pull_requests_merged_total{method="squash",repo="traefik/traefik"} 1`,
+		`lobicornis_pull_requests_updated_total{action="rebase",repo="traefik/traefik"} 1`,
+		`lobicornis_pull_requests_need_human_total{reason="milestone",repo="traefik/traefik"} 1`,
+		`lobicornis_check_states_total{repo="traefik/traefik",state="pending"} 1`,
+		`# TYPE lobicornis_queue_size gauge`,
+		`lobicornis_queue_size{repo="traefik/traefik"} 3`,
+		`lobicornis_queue_size{repo="traefik/yaegi"} 1`,
+		`# TYPE lobicornis_merge_time_seconds histogram`,
+		`lobicornis_merge_time_seconds_bucket{repo="traefik/traefik",le="300"} 0`,
+		`lobicornis_merge_time_seconds_bucket{repo="traefik/traefik",le="900"} 1`,
+		`lobicornis_merge_time_seconds_sum{repo="traefik/traefik"} 600`,
+		`lobicornis_git_duration_seconds_bucket{operation="clone",le="0.5"} 1`,
+		`lobicornis_git_duration_seconds_bucket{operation="clone",le="5"} 2`,
+		`lobicornis_git_duration_seconds_count{operation="clone"} 2`,
+	}
+
+	for _, line := range expected {
+		assert.Contains(t, rw.Body.String(), line+"\n")
+	}
+}
+
+func TestMetrics_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("X-RateLimit-Remaining", "4999")
+		rw.Header().Set("X-RateLimit-Resource", "search")
+	}))
+	t.Cleanup(server.Close)
+
+	m := New()
+
+	client := &http.Client{Transport: m.Transport(nil, "traefik")}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.InDelta(t, float64(1), testutil.ToFloat64(m.apiRequests.WithLabelValues("200")), 0)
+	assert.InDelta(t, float64(4999), testutil.ToFloat64(m.apiRateLimit.WithLabelValues("traefik", "search")), 0)
+}
+
+func TestMetrics_nil(t *testing.T) {
+	var m *Metrics
+
+	m.PullRequestProcessed("traefik/traefik")
+	m.GitDuration(OperationPush, time.Second)
+
+	assert.Equal(t, http.DefaultTransport, m.Transport(http.DefaultTransport, "traefik"))
+}
+
+func TestMetrics_ForgeTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("RateLimit-Remaining", "1999")
+	}))
+	t.Cleanup(server.Close)
+
+	m := New()
+
+	client := &http.Client{Transport: m.ForgeTransport(nil, "gitlab", "traefik")}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.InDelta(t, float64(1), testutil.ToFloat64(m.forgeRequests.WithLabelValues("gitlab", "traefik", "200")), 0)
+	assert.InDelta(t, float64(1999), testutil.ToFloat64(m.forgeRateLimit.WithLabelValues("gitlab", "traefik")), 0)
+
+	// the GitHub metrics are not affected.
+	assert.Equal(t, 0, testutil.CollectAndCount(m.apiRequests))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/ldez/go-git-cmd-wrapper/v2/checkout"
@@ -11,10 +12,13 @@ import (
 	"github.com/ldez/go-git-cmd-wrapper/v2/config"
 	"github.com/ldez/go-git-cmd-wrapper/v2/fetch"
 	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
 	"github.com/ldez/go-git-cmd-wrapper/v2/remote"
+	"github.com/ldez/go-git-cmd-wrapper/v2/types"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
 )
 
 type remoteModel struct {
@@ -29,22 +33,30 @@ type prModel struct {
 }
 
 // Clone a clone manager.
+// All the git commands are executed in an explicit directory, the process working directory is never changed.
 type Clone struct {
-	git   conf.Git
-	token string
-	debug bool
+	git      conf.Git
+	timeouts conf.Timeouts
+	token    string
+	debug    bool
+	cache    *MirrorCache
+
+	metrics *metrics.Metrics
 }
 
-func newClone(gitConfig conf.Git, token string) Clone {
+func newClone(gitConfig conf.Git, timeouts conf.Timeouts, token string, cache *MirrorCache, recorder *metrics.Metrics) Clone {
 	return Clone{
-		git:   gitConfig,
-		token: token,
-		debug: log.Logger.GetLevel() == zerolog.DebugLevel,
+		git:      gitConfig,
+		timeouts: timeouts,
+		token:    token,
+		debug:    zerolog.GlobalLevel() <= zerolog.DebugLevel,
+		cache:    cache,
+		metrics:  recorder,
 	}
 }
 
 // PullRequestForMerge Clone a pull request for a merge.
-func (c Clone) PullRequestForMerge(ctx context.Context, pr *github.PullRequest) (string, error) {
+func (c Clone) PullRequestForMerge(ctx context.Context, dir string, pr *github.PullRequest) (string, error) {
 	var forkURL string
 	if pr.Base.Repo.GetPrivate() {
 		forkURL = makeRepositoryURL(pr.Head.Repo.GetGitURL(), c.git.SSH, c.token)
@@ -66,11 +78,11 @@ func (c Clone) PullRequestForMerge(ctx context.Context, pr *github.PullRequest)
 		},
 	}
 
-	return c.pullRequest(ctx, pr, model)
+	return c.pullRequest(ctx, dir, pr, model)
 }
 
 // PullRequestForUpdate Clone a pull request for an update (rebase).
-func (c Clone) PullRequestForUpdate(ctx context.Context, pr *github.PullRequest) (string, error) {
+func (c Clone) PullRequestForUpdate(ctx context.Context, dir string, pr *github.PullRequest) (string, error) {
 	var unchangedURL string
 	if pr.Base.Repo.GetPrivate() {
 		unchangedURL = makeRepositoryURL(pr.Base.Repo.GetGitURL(), c.git.SSH, c.token)
@@ -92,10 +104,26 @@ func (c Clone) PullRequestForUpdate(ctx context.Context, pr *github.PullRequest)
 		},
 	}
 
-	return c.pullRequest(ctx, pr, model)
+	return c.pullRequest(ctx, dir, pr, model)
 }
 
-func (c Clone) pullRequest(ctx context.Context, pr *github.PullRequest, prModel prModel) (string, error) {
+// BaseBranch Clone the base branch of a pull request.
+func (c Clone) BaseBranch(ctx context.Context, dir string, pr *github.PullRequest) error {
+	model := remoteModel{
+		url: makeRepositoryURL(pr.Base.Repo.GetGitURL(), c.git.SSH, c.token),
+		ref: pr.Base.GetRef(),
+	}
+
+	output, err := c.fromMainRepository(ctx, dir, pr.Base.Repo.GetGitURL(), model)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg(output)
+		return err
+	}
+
+	return nil
+}
+
+func (c Clone) pullRequest(ctx context.Context, dir string, pr *github.PullRequest, prModel prModel) (string, error) {
 	logger := log.Ctx(ctx)
 
 	if isOnMainRepository(pr) {
@@ -103,7 +131,7 @@ func (c Clone) pullRequest(ctx context.Context, pr *github.PullRequest, prModel
 
 		remoteName := RemoteOrigin
 
-		output, err := c.fromMainRepository(ctx, prModel.changed)
+		output, err := c.fromMainRepository(ctx, dir, pr.Base.Repo.GetGitURL(), prModel.changed)
 		if err != nil {
 			logger.Error().Err(err).Msg(output)
 			return "", err
@@ -113,7 +141,7 @@ func (c Clone) pullRequest(ctx context.Context, pr *github.PullRequest, prModel
 	}
 
 	remoteName := RemoteUpstream
-	output, err := c.fromFork(ctx, prModel.changed, prModel.unchanged, remoteName)
+	output, err := c.fromFork(ctx, dir, pr.Base.Repo.GetGitURL(), prModel.changed, prModel.unchanged, remoteName)
 	if err != nil {
 		logger.Error().Err(err).Msg(output)
 		return "", err
@@ -122,18 +150,18 @@ func (c Clone) pullRequest(ctx context.Context, pr *github.PullRequest, prModel
 	return remoteName, nil
 }
 
-func (c Clone) fromMainRepository(ctx context.Context, remoteModel remoteModel) (string, error) {
-	output, err := git.CloneWithContext(ctx, clone.Repository(remoteModel.url), clone.Directory("."), git.Debugger(c.debug))
+func (c Clone) fromMainRepository(ctx context.Context, dir, baseURL string, remoteModel remoteModel) (string, error) {
+	output, err := c.cloneRepository(ctx, baseURL, clone.Repository(remoteModel.url), clone.Directory(dir), git.Debugger(c.debug))
 	if err != nil {
 		return output, err
 	}
 
-	output, err = configureGit(ctx, c.git)
+	output, err = configureGit(ctx, dir, c.git)
 	if err != nil {
 		return output, err
 	}
 
-	output, err = git.CheckoutWithContext(ctx, checkout.Branch(remoteModel.ref), git.Debugger(c.debug))
+	output, err = git.CheckoutWithContext(ctx, global.UpperC(dir), checkout.Branch(remoteModel.ref), git.Debugger(c.debug))
 	if err != nil {
 		return output, fmt.Errorf("failed to checkout branch %s: %w", remoteModel.ref, err)
 	}
@@ -141,27 +169,29 @@ func (c Clone) fromMainRepository(ctx context.Context, remoteModel remoteModel)
 	return "", nil
 }
 
-func (c Clone) fromFork(ctx context.Context, origin, upstream remoteModel, remoteName string) (string, error) {
-	output, err := git.CloneWithContext(ctx,
+func (c Clone) fromFork(ctx context.Context, dir, baseURL string, origin, upstream remoteModel, remoteName string) (string, error) {
+	output, err := c.cloneRepository(ctx, baseURL,
 		clone.Repository(origin.url),
 		clone.Branch(origin.ref),
-		clone.Directory("."),
+		clone.Directory(dir),
 		git.Debugger(c.debug))
 	if err != nil {
 		return output, err
 	}
 
-	output, err = configureGit(ctx, c.git)
+	output, err = configureGit(ctx, dir, c.git)
 	if err != nil {
 		return output, err
 	}
 
-	output, err = git.RemoteWithContext(ctx, remote.Add(remoteName, upstream.url), git.Debugger(c.debug))
+	output, err = git.RemoteWithContext(ctx, global.UpperC(dir), remote.Add(remoteName, upstream.url), git.Debugger(c.debug))
 	if err != nil {
 		return output, fmt.Errorf("failed to add remote: %w", err)
 	}
 
-	output, err = git.FetchWithContext(ctx, fetch.NoTags, fetch.Remote(remoteName), fetch.RefSpec(upstream.ref), git.Debugger(c.debug))
+	output, err = GitWithTimeout(ctx, c.timeouts.Fetch, func(ctx context.Context) (string, error) {
+		return git.FetchWithContext(ctx, global.UpperC(dir), fetch.NoTags, fetch.Remote(remoteName), fetch.RefSpec(upstream.ref), git.Debugger(c.debug))
+	})
 	if err != nil {
 		return output, fmt.Errorf("failed to fetch %s/%s : %w", remoteName, upstream.ref, err)
 	}
@@ -169,6 +199,31 @@ func (c Clone) fromFork(ctx context.Context, origin, upstream remoteModel, remot
 	return "", nil
 }
 
+// cloneRepository clones a repository.
+// If the cache is enabled, the mirror of the base repository is used as reference.
+// The update of the mirror is included in the clone timeout.
+func (c Clone) cloneRepository(ctx context.Context, baseURL string, options ...types.Option) (string, error) {
+	ctx, cancel := WithTimeout(ctx, c.timeouts.Clone)
+	defer cancel()
+
+	if c.cache != nil {
+		path, release, err := c.cache.reference(ctx, baseURL, makeRepositoryURL(baseURL, c.git.SSH, c.token))
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Unable to use the mirror cache.")
+		} else {
+			defer release()
+
+			options = append([]types.Option{withReference(path)}, options...)
+		}
+	}
+
+	defer func(start time.Time) { c.metrics.GitDuration(metrics.OperationClone, time.Since(start)) }(time.Now())
+
+	return GitWithTimeout(ctx, 0, func(ctx context.Context) (string, error) {
+		return git.CloneWithContext(ctx, options...)
+	})
+}
+
 func makeRepositoryURL(url string, ssh bool, token string) string {
 	if ssh {
 		return strings.ReplaceAll(url, "git://github.com/", "git@github.com:")
@@ -182,30 +237,30 @@ func makeRepositoryURL(url string, ssh bool, token string) string {
 	return strings.ReplaceAll(url, "git://", prefix)
 }
 
-func configureGit(ctx context.Context, gitConfig conf.Git) (string, error) {
-	output, err := git.ConfigWithContext(ctx, config.Entry("rebase.autoSquash", "true"))
+func configureGit(ctx context.Context, dir string, gitConfig conf.Git) (string, error) {
+	output, err := git.ConfigWithContext(ctx, global.UpperC(dir), config.Entry("rebase.autoSquash", "true"))
 	if err != nil {
 		return output, err
 	}
 
-	output, err = git.ConfigWithContext(ctx, config.Entry("push.default", "current"))
+	output, err = git.ConfigWithContext(ctx, global.UpperC(dir), config.Entry("push.default", "current"))
 	if err != nil {
 		return output, err
 	}
 
-	return configureGitUserInfo(ctx, gitConfig.UserName, gitConfig.Email)
+	return configureGitUserInfo(ctx, dir, gitConfig.UserName, gitConfig.Email)
 }
 
-func configureGitUserInfo(ctx context.Context, gitUserName, gitUserEmail string) (string, error) {
+func configureGitUserInfo(ctx context.Context, dir, gitUserName, gitUserEmail string) (string, error) {
 	if len(gitUserEmail) != 0 {
-		output, err := git.ConfigWithContext(ctx, config.Entry("user.email", gitUserEmail))
+		output, err := git.ConfigWithContext(ctx, global.UpperC(dir), config.Entry("user.email", gitUserEmail))
 		if err != nil {
 			return output, err
 		}
 	}
 
 	if len(gitUserName) != 0 {
-		output, err := git.ConfigWithContext(ctx, config.Entry("user.name", gitUserName))
+		output, err := git.ConfigWithContext(ctx, global.UpperC(dir), config.Entry("user.name", gitUserName))
 		if err != nil {
 			return output, err
 		}
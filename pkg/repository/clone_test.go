@@ -1,13 +1,12 @@
 package repository
 
 import (
-	"context"
-	"os"
 	"strings"
 	"testing"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
 	"github.com/ldez/go-git-cmd-wrapper/v2/remote"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,15 +24,15 @@ func TestClone_PullRequestForUpdate(t *testing.T) {
 		{
 			name:                "PR with 2 different repositories",
 			expectedRemoteName:  "upstream",
-			expectedOriginURL:   "https://github.com/ldez/traefik.git",
-			expectedUpstreamURL: "https://github.com/traefik/traefik.git",
+			expectedOriginURL:   forkRepoName,
+			expectedUpstreamURL: fakeRepoName,
 		},
 		{
 			name:                "PR from main repository",
 			sameRepo:            true,
 			expectedRemoteName:  "origin",
-			expectedOriginURL:   "https://github.com/traefik/traefik.git",
-			expectedUpstreamURL: "https://github.com/traefik/traefik.git",
+			expectedOriginURL:   fakeRepoName,
+			expectedUpstreamURL: fakeRepoName,
 		},
 	}
 
@@ -43,34 +42,30 @@ func TestClone_PullRequestForUpdate(t *testing.T) {
 		SSH:      false,
 	}
 
-	clone := newClone(gitConfig, "")
+	clone := newClone(gitConfig, conf.Timeouts{}, "", nil, nil)
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			dir := t.TempDir()
-			t.Chdir(dir)
-
-			tempDir, err := os.Getwd()
-			require.NoError(t, err)
+			t.Parallel()
 
-			t.Log(tempDir)
+			dir := t.TempDir()
 
-			pr := createFakePR(test.sameRepo)
+			pr := createFakePR(t, test.sameRepo)
 
-			remoteName, err := clone.PullRequestForUpdate(context.Background(), pr)
+			remoteName, err := clone.PullRequestForUpdate(t.Context(), dir, pr)
 			require.NoError(t, err)
 
 			assert.Equal(t, test.expectedRemoteName, remoteName)
 
-			localOriginURL, err := git.Remote(remote.GetURL("origin"))
+			localOriginURL, err := git.Remote(global.UpperC(dir), remote.GetURL("origin"))
 			require.NoError(t, err)
 
-			assert.Equal(t, test.expectedOriginURL, strings.TrimSpace(localOriginURL))
+			assert.Equal(t, repositoryURL(pr, test.expectedOriginURL), strings.TrimSpace(localOriginURL))
 
-			localUpstreamURL, err := git.Remote(remote.GetURL(test.expectedRemoteName))
+			localUpstreamURL, err := git.Remote(global.UpperC(dir), remote.GetURL(test.expectedRemoteName))
 			require.NoError(t, err)
 
-			assert.Equal(t, test.expectedUpstreamURL, strings.TrimSpace(localUpstreamURL))
+			assert.Equal(t, repositoryURL(pr, test.expectedUpstreamURL), strings.TrimSpace(localUpstreamURL))
 		})
 	}
 }
@@ -86,15 +81,15 @@ func TestClone_PullRequestForMerge(t *testing.T) {
 		{
 			name:                "PR with 2 different repositories",
 			expectedRemoteName:  "upstream",
-			expectedOriginURL:   "https://github.com/traefik/traefik.git",
-			expectedUpstreamURL: "https://github.com/ldez/traefik.git",
+			expectedOriginURL:   fakeRepoName,
+			expectedUpstreamURL: forkRepoName,
 		},
 		{
 			name:                "PR from main repository",
 			sameRepo:            true,
 			expectedRemoteName:  "origin",
-			expectedOriginURL:   "https://github.com/traefik/traefik.git",
-			expectedUpstreamURL: "https://github.com/traefik/traefik.git",
+			expectedOriginURL:   fakeRepoName,
+			expectedUpstreamURL: fakeRepoName,
 		},
 	}
 
@@ -104,34 +99,30 @@ func TestClone_PullRequestForMerge(t *testing.T) {
 		SSH:      false,
 	}
 
-	clone := newClone(gitConfig, "")
+	clone := newClone(gitConfig, conf.Timeouts{}, "", nil, nil)
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			dir := t.TempDir()
-			t.Chdir(dir)
-
-			tempDir, err := os.Getwd()
-			require.NoError(t, err)
+			t.Parallel()
 
-			t.Log(tempDir)
+			dir := t.TempDir()
 
-			pr := createFakePR(test.sameRepo)
+			pr := createFakePR(t, test.sameRepo)
 
-			remoteName, err := clone.PullRequestForMerge(context.Background(), pr)
+			remoteName, err := clone.PullRequestForMerge(t.Context(), dir, pr)
 			require.NoError(t, err)
 
 			assert.Equal(t, test.expectedRemoteName, remoteName)
 
-			localOriginURL, err := git.Remote(remote.GetURL("origin"))
+			localOriginURL, err := git.Remote(global.UpperC(dir), remote.GetURL("origin"))
 			require.NoError(t, err)
 
-			assert.Equal(t, test.expectedOriginURL, strings.TrimSpace(localOriginURL))
+			assert.Equal(t, repositoryURL(pr, test.expectedOriginURL), strings.TrimSpace(localOriginURL))
 
-			localUpstreamURL, err := git.Remote(remote.GetURL(test.expectedRemoteName))
+			localUpstreamURL, err := git.Remote(global.UpperC(dir), remote.GetURL(test.expectedRemoteName))
 			require.NoError(t, err)
 
-			assert.Equal(t, test.expectedUpstreamURL, strings.TrimSpace(localUpstreamURL))
+			assert.Equal(t, repositoryURL(pr, test.expectedUpstreamURL), strings.TrimSpace(localUpstreamURL))
 		})
 	}
 }
@@ -182,32 +173,35 @@ func Test_makeRepositoryURL(t *testing.T) {
 	}
 }
 
-func createFakePR(sameRepo bool) *github.PullRequest {
-	pr := &github.PullRequest{
-		Number: github.Ptr(666),
+// createFakePR creates a pull request from the branch "v1.3", with repositories backed by local bare repositories.
+func createFakePR(t *testing.T, sameRepo bool) *github.PullRequest {
+	t.Helper()
+
+	fake, _ := newE2EGitHub(t)
+
+	headRepo := forkRepoName
+	if sameRepo {
+		headRepo = fakeRepoName
 	}
-	pr.Base = &github.PullRequestBranch{
-		Repo: &github.Repository{
-			GitURL: github.Ptr("git://github.com/traefik/traefik.git"),
+
+	fake.AddBranch(headRepo, "v1.3", "Fix the bug")
+
+	number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{
+		Number: github.Ptr(666),
+		Head: &github.PullRequestBranch{
+			Ref:  github.Ptr("v1.3"),
+			Repo: &github.Repository{FullName: github.Ptr(headRepo)},
 		},
-		Ref: github.Ptr("master"),
-	}
+	})
 
-	if sameRepo {
-		pr.Head = &github.PullRequestBranch{
-			Repo: &github.Repository{
-				GitURL: github.Ptr("git://github.com/traefik/traefik.git"),
-			},
-			Ref: github.Ptr("v1.3"),
-		}
-	} else {
-		pr.Head = &github.PullRequestBranch{
-			Repo: &github.Repository{
-				GitURL: github.Ptr("git://github.com/ldez/traefik.git"),
-			},
-			Ref: github.Ptr("v1.3"),
-		}
+	return fake.PullRequest(fakeRepoName, number)
+}
+
+// repositoryURL gets the URL of the base or the head repository of a pull request.
+func repositoryURL(pr *github.PullRequest, fullName string) string {
+	if pr.Head.Repo.GetFullName() == fullName {
+		return pr.Head.Repo.GetGitURL()
 	}
 
-	return pr
+	return pr.Base.Repo.GetGitURL()
 }
@@ -0,0 +1,532 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+)
+
+// StateOpen the state of an open pull request.
+const StateOpen = "open"
+
+// Forge a forge (GitHub, GitLab, Gitea) driven by the pipeline of the pull requests.
+// P is the pull request (or merge request) of the forge.
+type Forge[P any] interface {
+	// GetPullRequest gets a pull request.
+	GetPullRequest(ctx context.Context, number int) (P, error)
+	// Describe gets the information of a pull request used by the pipeline.
+	Describe(pr P) PullRequest
+	// CheckReviews checks if a pull request has the required number of approvals.
+	CheckReviews(ctx context.Context, pr P, minReview int) error
+	// GetChecksState gets the aggregated state of the checks of a pull request: success or pending.
+	// The failed state is an error.
+	GetChecksState(ctx context.Context, pr P) (string, error)
+	// NeedUpdate checks if the target branch of a pull request requires an up-to-date branch.
+	NeedUpdate(ctx context.Context, pr P) (bool, error)
+	// IsUpToDate checks if the head branch of a pull request contains the head of its target branch.
+	IsUpToDate(ctx context.Context, pr P) (bool, error)
+	// AddLabels adds some labels on a pull request.
+	AddLabels(ctx context.Context, pr P, labels ...string) error
+	// RemoveLabels removes some labels of a pull request.
+	RemoveLabels(ctx context.Context, pr P, labels ...string) error
+	// AddComment adds a comment on a pull request.
+	AddComment(ctx context.Context, pr P, message string) error
+	// CanUpdate checks if the head branch of a pull request can be updated.
+	CanUpdate(ctx context.Context, pr P) error
+	// Update updates the head branch of a pull request with its target branch, and returns the action (merge or rebase).
+	Update(ctx context.Context, pr P) (string, error)
+	// MergeMethods gets the merge methods supported by the forge.
+	MergeMethods() []string
+	// Merge merges a pull request, and closes its related issues.
+	Merge(ctx context.Context, pr P, mergeMethod string, message CommitMessage) error
+}
+
+// StatusForge a forge publishing the commit status of the bot on the pull requests.
+type StatusForge[P any] interface {
+	// PublishStatus publishes the commit status of the bot on a pull request.
+	PublishStatus(ctx context.Context, pr P, state, description string)
+	// ForgetStatus stops tracking the commit status of a merged pull request.
+	ForgetStatus(pr P)
+}
+
+// ConfigFileForge a forge reading the configuration file of the repositories.
+type ConfigFileForge[P any] interface {
+	// ApplyConfigFile overrides the configuration of the repository with its configuration file, read from the target branch of a pull request.
+	// Returns the configuration of the repository, an invalid configuration file is an error with the reason ReasonConfig.
+	ApplyConfigFile(ctx context.Context, pr P) (conf.RepoConfig, error)
+}
+
+// PullRequest the information of a pull request of a forge.
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	Labels []string
+
+	// State the state of the pull request (StateOpen for an open pull request).
+	State  string
+	Merged bool
+	Draft  bool
+
+	HasMilestone bool
+	Conflicts    bool
+	Private      bool
+
+	// Waiting the operation of the forge to wait for (e.g. a mergeability check), empty if none.
+	Waiting string
+	// Blocked the reason why the forge blocks the merge, empty if none.
+	Blocked string
+}
+
+// CommitMessage the message of a squash commit.
+// An empty message means the default message of the forge.
+type CommitMessage struct {
+	Title string
+	Body  string
+}
+
+// ForgeRepository a repository manager of a forge: the pipeline of the pull requests applied to a forge.
+// The commit status and the configuration file of the repository are only supported by the forges implementing StatusForge and ConfigFileForge.
+type ForgeRepository[P any] struct {
+	forge Forge[P]
+
+	dryRun bool
+
+	markers  conf.Markers
+	retry    conf.Retry
+	timeouts conf.Timeouts
+
+	fullName string
+	token    string
+
+	config conf.RepoConfig
+
+	metrics *metrics.Metrics
+}
+
+// NewForgeRepository creates a new repository manager of a forge.
+func NewForgeRepository[P any](forge Forge[P], fullName, token string, markers conf.Markers, retry conf.Retry, timeouts conf.Timeouts, config conf.RepoConfig, extra conf.Extra, recorder *metrics.Metrics) *ForgeRepository[P] {
+	return &ForgeRepository[P]{
+		forge:    forge,
+		dryRun:   extra.DryRun,
+		markers:  markers,
+		retry:    retry,
+		timeouts: timeouts,
+		fullName: fullName,
+		token:    token,
+		config:   config,
+		metrics:  recorder,
+	}
+}
+
+// Process try to merge a pull request.
+// The processing of the pull request is limited by the pull request deadline.
+func (r *ForgeRepository[P]) Process(ctx context.Context, number int) error {
+	ctx, cancel := WithTimeout(ctx, r.timeouts.PullRequest)
+	defer cancel()
+
+	pr, err := r.forge.GetPullRequest(ctx, number)
+	if err != nil {
+		return fmt.Errorf("failed to get pull request: %w", err)
+	}
+
+	r.metrics.PullRequestProcessed(r.fullName)
+
+	err = r.applyConfigFile(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	err = r.process(ctx, pr)
+	if err != nil {
+		if isInterrupted(err) {
+			return r.interrupted(ctx, pr, err)
+		}
+
+		r.publishError(ctx, pr, err)
+		r.callHuman(ctx, pr, GetReason(err), err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// process try to merge a pull request: the bot acts on the first failed gate, or merges the pull request.
+func (r *ForgeRepository[P]) process(ctx context.Context, pr P) error {
+	eval := &evaluation[P]{pr: pr, info: r.forge.Describe(pr)}
+
+	for _, evaluate := range r.gates() {
+		gate := evaluate(ctx, eval)
+		if !gate.Passed {
+			return r.act(ctx, pr, gate)
+		}
+
+		if gate.Name == GateMergeable {
+			r.cleanRetryLabel(ctx, pr)
+		}
+	}
+
+	return WithReason(ReasonMerge, r.merge(ctx, pr, eval.mergeMethod))
+}
+
+// act applies the action of the bot on a pull request for a failed gate.
+func (r *ForgeRepository[P]) act(ctx context.Context, pr P, gate Gate) error {
+	logger := log.Ctx(ctx)
+
+	switch gate.action {
+	case NextNone:
+		logger.Info().Msgf("%s: %s", gate.Name, gate.Message)
+		return nil
+
+	case NextWaitCI:
+		logger.Info().Msg("State: pending. Waiting for the CI.")
+		r.publishStatus(ctx, pr, statusPending, "waiting for CI")
+		return nil
+
+	case NextCleanLabels:
+		logger.Info().Msg("the PR is already merged")
+
+		err := r.removeLabels(ctx, pr, mergeLabels(r.markers, r.markers.MergeInProgress)...)
+		IgnoreError(ctx, err)
+
+		return nil
+
+	case NextUpdate:
+		r.publishStatus(ctx, pr, statusPending, "updating branch")
+
+		err := r.update(ctx, pr)
+		if err != nil {
+			return WithReason(ReasonUpdate, fmt.Errorf("failed to update: %w", err))
+		}
+
+		return nil
+	}
+
+	switch GetReason(gate.err) {
+	case ReasonChecks:
+		logger.Error().Err(gate.err).Msg("Checks status")
+		return WithReason(ReasonChecks, r.retryLater(ctx, pr, r.retry.OnStatuses, gate.err, "checks failed"))
+
+	case ReasonConflicts:
+		logger.Info().Msg("Conflicts must be resolved in the PR.")
+		return WithReason(ReasonConflicts, r.retryLater(ctx, pr, r.retry.OnMergeable, gate.err, "conflicts"))
+
+	default:
+		return gate.err
+	}
+}
+
+// retryLater labels a pull request for a retry when possible, otherwise returns the error.
+func (r *ForgeRepository[P]) retryLater(ctx context.Context, pr P, retry bool, rootErr error, failure string) error {
+	err := r.manageRetryLabel(ctx, pr, retry, rootErr)
+	if err != nil {
+		return err
+	}
+
+	r.publishStatus(ctx, pr, statusPending, "waiting for a retry: "+failure)
+
+	return nil
+}
+
+// getMinReview gets the minimal number of approvals of a pull request.
+func (r *ForgeRepository[P]) getMinReview(info PullRequest) int {
+	if r.config.GetMinLightReview() != 0 && slices.Contains(info.Labels, r.markers.LightReview) {
+		return r.config.GetMinLightReview()
+	}
+
+	return r.config.GetMinReview()
+}
+
+// applyConfigFile overrides the configuration with the configuration file of the repository, if the forge supports it.
+// An invalid configuration file is reported on the pull request.
+func (r *ForgeRepository[P]) applyConfigFile(ctx context.Context, pr P) error {
+	gate := r.configGate(ctx, pr)
+	if gate.Passed {
+		return nil
+	}
+
+	if GetReason(gate.err) == ReasonConfig {
+		r.callHuman(ctx, pr, ReasonConfig, gate.Message)
+	}
+
+	return gate.err
+}
+
+// update updates the head branch of a pull request with its target branch.
+func (r *ForgeRepository[P]) update(ctx context.Context, pr P) error {
+	logger := log.Ctx(ctx)
+	logger.Info().Msg("UPDATE")
+
+	err := r.forge.CanUpdate(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	err = r.addLabels(ctx, pr, r.markers.MergeInProgress)
+	if err != nil {
+		logger.Error().Err(err).Msg("unable to add labels")
+	}
+
+	if r.dryRun {
+		logger.Debug().Msg("Updated: dry run.")
+		return nil
+	}
+
+	action, err := r.forge.Update(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	r.metrics.PullRequestUpdated(r.fullName, action)
+
+	return nil
+}
+
+// merge merges a pull request.
+func (r *ForgeRepository[P]) merge(ctx context.Context, pr P, mergeMethod string) error {
+	logger := log.Ctx(ctx)
+
+	logger.Info().Msgf("MERGE(%s)\n", mergeMethod)
+
+	err := r.removeLabels(ctx, pr, r.markers.MergeInProgress)
+	IgnoreError(ctx, err)
+
+	if r.dryRun {
+		logger.Info().Msg("Fake merge: dry run")
+		return nil
+	}
+
+	var message CommitMessage
+	if mergeMethod == conf.MergeMethodSquash {
+		message = r.getCommitMessage(r.forge.Describe(pr))
+	}
+
+	err = r.forge.Merge(ctx, pr, mergeMethod, message)
+	if err != nil {
+		return fmt.Errorf("failed to merge PR: %w", err)
+	}
+
+	logger.Info().Msg("Merged")
+
+	r.publishStatus(ctx, pr, statusSuccess, "merged")
+	r.forgetStatus(pr)
+	r.metrics.PullRequestMerged(r.fullName, mergeMethod)
+
+	err = r.removeLabels(ctx, pr, mergeLabels(r.markers)...)
+	IgnoreError(ctx, err)
+
+	return nil
+}
+
+// getCommitMessage gets the message of a squash commit: the default message of the forge if empty.
+func (r *ForgeRepository[P]) getCommitMessage(info PullRequest) CommitMessage {
+	switch r.config.GetCommitMessage() {
+	case conf.CommitMessageGitHub:
+		return CommitMessage{}
+	case conf.CommitMessageDescription:
+		return CommitMessage{Title: info.Title, Body: info.Body}
+	default:
+		return CommitMessage{Title: info.Title, Body: strings.Join(getCoAuthors(info.Body), "\n")}
+	}
+}
+
+// interrupted leaves a pull request in a well-defined state after a timeout or a cancellation.
+// After a timeout, the pull request is labeled for a retry when the retries are enabled, otherwise it's sent to a human.
+// After a cancellation (shutdown), the labels are kept: the pull request is processed again by the next run.
+func (r *ForgeRepository[P]) interrupted(ctx context.Context, pr P, err error) error {
+	logger := log.Ctx(ctx)
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		logger.Warn().Err(err).Msg("Processing canceled, the pull request will be processed by the next run.")
+		return err
+	}
+
+	logger.Error().Err(err).Msg("Processing timeout.")
+
+	// the context of the pull request is done: the cleanup uses its own timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	err = r.retryLater(ctx, pr, true, fmt.Errorf("timeout: %w", err), "timeout")
+	if err == nil {
+		return nil
+	}
+
+	r.publishError(ctx, pr, err)
+	r.callHuman(ctx, pr, ReasonTimeout, err.Error())
+
+	return WithReason(ReasonTimeout, err)
+}
+
+func (r *ForgeRepository[P]) callHuman(ctx context.Context, pr P, reason, message string) {
+	log.Ctx(ctx).Warn().Str("reason", reason).Msg(message)
+
+	r.metrics.PullRequestNeedHuman(r.fullName, reason)
+
+	err := r.addComment(ctx, pr, ":no_entry_sign: "+message)
+	IgnoreError(ctx, err)
+
+	err = r.addLabels(ctx, pr, r.markers.NeedHumanMerge)
+	IgnoreError(ctx, err)
+
+	err = r.removeLabels(ctx, pr, r.markers.MergeInProgress)
+	IgnoreError(ctx, err)
+}
+
+// publishStatus publishes the commit status of the bot on a pull request, if the forge supports it.
+func (r *ForgeRepository[P]) publishStatus(ctx context.Context, pr P, state, description string) {
+	if forge, ok := r.forge.(StatusForge[P]); ok {
+		forge.PublishStatus(ctx, pr, state, description)
+	}
+}
+
+// publishError publishes the reason why a pull request cannot be merged.
+func (r *ForgeRepository[P]) publishError(ctx context.Context, pr P, err error) {
+	r.publishStatus(ctx, pr, statusError, getStatusDescription(err))
+}
+
+// forgetStatus stops tracking the commit status of a merged pull request, if the forge supports it.
+func (r *ForgeRepository[P]) forgetStatus(pr P) {
+	if forge, ok := r.forge.(StatusForge[P]); ok {
+		forge.ForgetStatus(pr)
+	}
+}
+
+func (r *ForgeRepository[P]) addComment(ctx context.Context, pr P, message string) error {
+	if !r.config.GetAddErrorInComment() && !r.forge.Describe(pr).Private {
+		return nil
+	}
+
+	msg := message
+	if r.token != "" {
+		msg = strings.ReplaceAll(message, r.token, "xxx")
+	}
+
+	if r.dryRun {
+		log.Ctx(ctx).Debug().Msgf("Add comment: %s", msg)
+		return nil
+	}
+
+	return r.forge.AddComment(ctx, pr, msg)
+}
+
+// addLabels adds some labels on a pull request.
+func (r *ForgeRepository[P]) addLabels(ctx context.Context, pr P, labels ...string) error {
+	log.Ctx(ctx).Debug().Msgf("Add labels: %s. Dry run: %v", labels, r.dryRun)
+
+	if r.dryRun {
+		return nil
+	}
+
+	return r.forge.AddLabels(ctx, pr, labels...)
+}
+
+// removeLabels removes some labels on a pull request, the labels not on the pull request are ignored.
+func (r *ForgeRepository[P]) removeLabels(ctx context.Context, pr P, labels ...string) error {
+	current := r.forge.Describe(pr).Labels
+
+	var toRemove []string
+	for _, label := range labels {
+		if slices.Contains(current, label) && !slices.Contains(toRemove, label) {
+			toRemove = append(toRemove, label)
+		}
+	}
+
+	if len(toRemove) == 0 {
+		return nil
+	}
+
+	log.Ctx(ctx).Debug().Msgf("Remove labels: %s. Dry run: %v", toRemove, r.dryRun)
+
+	if r.dryRun {
+		return nil
+	}
+
+	return r.forge.RemoveLabels(ctx, pr, toRemove...)
+}
+
+func (r *ForgeRepository[P]) cleanRetryLabel(ctx context.Context, pr P) {
+	if !r.retry.OnMergeable && !r.retry.OnStatuses {
+		return
+	}
+
+	currentRetryLabel := findWithPrefix(r.forge.Describe(pr).Labels, r.markers.MergeRetryPrefix)
+	if currentRetryLabel != "" {
+		err := r.removeLabels(ctx, pr, currentRetryLabel)
+		IgnoreError(ctx, err)
+	}
+}
+
+func (r *ForgeRepository[P]) manageRetryLabel(ctx context.Context, pr P, retry bool, rootErr error) error {
+	if !retry || r.retry.Number <= 0 {
+		return rootErr
+	}
+
+	currentRetryLabel := findWithPrefix(r.forge.Describe(pr).Labels, r.markers.MergeRetryPrefix)
+	if currentRetryLabel == "" {
+		// first retry
+		err := r.addLabels(ctx, pr, r.markers.MergeRetryPrefix+"1", r.markers.MergeInProgress)
+		IgnoreError(ctx, err)
+
+		return nil
+	}
+
+	err := r.removeLabels(ctx, pr, currentRetryLabel)
+	IgnoreError(ctx, err)
+
+	number := extractRetryNumber(currentRetryLabel, r.markers.MergeRetryPrefix)
+
+	if number >= r.retry.Number {
+		return fmt.Errorf("too many retry [%d/%d]: %w", number, r.retry.Number, rootErr)
+	}
+
+	// retry
+	err = r.addLabels(ctx, pr, r.markers.MergeRetryPrefix+strconv.Itoa(number+1))
+	IgnoreError(ctx, err)
+
+	return nil
+}
+
+func extractRetryNumber(label, prefix string) int {
+	raw := strings.TrimPrefix(label, prefix)
+
+	number, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to extract retry number")
+		return 0
+	}
+
+	return number
+}
+
+// mergeLabels gets the labels removed after a merge, with some extra labels.
+func mergeLabels(markers conf.Markers, extra ...string) []string {
+	return append([]string{
+		markers.NeedMerge,
+		markers.LightReview,
+		markers.MergeMethodPrefix + conf.MergeMethodSquash,
+		markers.MergeMethodPrefix + conf.MergeMethodMerge,
+		markers.MergeMethodPrefix + conf.MergeMethodRebase,
+		markers.MergeMethodPrefix + conf.MergeMethodFastForward,
+		markers.MergeNoRebase,
+	}, extra...)
+}
+
+// findWithPrefix finds a value with a specific prefix.
+func findWithPrefix(values []string, prefix string) string {
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// Gates of a pull request, in the order of the evaluation by the bot.
+const (
+	GateConfig      = "config"
+	GateLabels      = "labels"
+	GateMilestone   = "milestone"
+	GateReviews     = "reviews"
+	GateChecks      = "checks"
+	GateMergeable   = "mergeable"
+	GateMergeMethod = "merge method"
+	GateUpToDate    = "up-to-date"
+)
+
+// Next actions of the bot in the explain report.
+const (
+	NextNone        = "none"
+	NextCallHuman   = "call a human"
+	NextRetry       = "retry later"
+	NextWaitCI      = "wait for the CI"
+	NextCleanLabels = "remove the labels"
+	NextUpdate      = "update the branch"
+	NextMerge       = "merge"
+)
+
+// Report the evaluation of the gates of a pull request.
+type Report struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	// Note a limit of the report, empty if none.
+	Note  string `json:"note,omitempty"`
+	Gates []Gate `json:"gates"`
+	// Action the action of the next pass of the bot.
+	Action string `json:"action"`
+}
+
+// Gate the result of a gate.
+type Gate struct {
+	Name    string         `json:"name"`
+	Passed  bool           `json:"passed"`
+	Message string         `json:"message,omitempty"`
+	Data    map[string]any `json:"data,omitempty"`
+	// action the action of the bot when the gate fails.
+	action string
+	// err the error of the bot when the gate fails, nil if the failure is not an error (e.g. pending checks).
+	err error
+}
+
+// evaluation the state of a pull request shared by its gates.
+type evaluation[P any] struct {
+	pr   P
+	info PullRequest
+
+	mergeMethod string
+	upToDate    *bool
+}
+
+// Explain evaluates the gates of a pull request, as the bot does, without mutating anything.
+// The merge train is not covered.
+func (r *ForgeRepository[P]) Explain(ctx context.Context, number int) (*Report, error) {
+	pr, err := r.forge.GetPullRequest(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+
+	info := r.forge.Describe(pr)
+
+	report := &Report{
+		Repository: r.fullName,
+		Number:     info.Number,
+		Title:      info.Title,
+		Gates:      []Gate{r.configGate(ctx, pr)},
+		Action:     NextMerge,
+	}
+
+	if r.config.GetMergeTrainSize() > 1 {
+		report.Note = fmt.Sprintf("the merge train is not covered: the pull requests are merged by trains of %d", r.config.GetMergeTrainSize())
+	}
+
+	eval := &evaluation[P]{pr: pr, info: info}
+
+	for _, evaluate := range r.gates() {
+		report.Gates = append(report.Gates, evaluate(ctx, eval))
+	}
+
+	for _, gate := range report.Gates {
+		if !gate.Passed {
+			report.Action = gate.action
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// gates gets the gates of a pull request, after the configuration, in the order of the evaluation.
+func (r *ForgeRepository[P]) gates() []func(context.Context, *evaluation[P]) Gate {
+	return []func(context.Context, *evaluation[P]) Gate{
+		r.labelsGate,
+		r.milestoneGate,
+		r.reviewsGate,
+		r.checksGate,
+		r.mergeableGate,
+		r.mergeMethodGate,
+		r.upToDateGate,
+	}
+}
+
+// configGate overrides the configuration with the configuration file of the repository, if the forge supports it.
+// The configuration is overridden in memory only.
+func (r *ForgeRepository[P]) configGate(ctx context.Context, pr P) Gate {
+	gate := Gate{Name: GateConfig, Passed: true, action: NextCallHuman}
+
+	if forge, ok := r.forge.(ConfigFileForge[P]); ok {
+		config, err := forge.ApplyConfigFile(ctx, pr)
+		if err != nil {
+			return failGate(gate, err)
+		}
+
+		r.config = config
+	}
+
+	gate.Data = map[string]any{
+		"mergeMethod":    r.config.GetMergeMethod(),
+		"minReview":      r.config.GetMinReview(),
+		"minLightReview": r.config.GetMinLightReview(),
+		"needMilestone":  r.config.GetNeedMilestone(),
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) labelsGate(_ context.Context, eval *evaluation[P]) Gate {
+	labels := eval.info.Labels
+
+	gate := Gate{Name: GateLabels, Passed: true, Data: map[string]any{"labels": labels}, action: NextNone}
+
+	switch {
+	case !slices.Contains(labels, r.markers.NeedMerge):
+		gate.Passed = false
+		gate.Message = fmt.Sprintf("the label %s is missing", r.markers.NeedMerge)
+	case slices.Contains(labels, r.markers.NoMerge):
+		gate.Passed = false
+		gate.Message = fmt.Sprintf("the label %s is present", r.markers.NoMerge)
+	case slices.Contains(labels, r.markers.NeedHumanMerge):
+		gate.Passed = false
+		gate.Message = fmt.Sprintf("the label %s is present", r.markers.NeedHumanMerge)
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) milestoneGate(_ context.Context, eval *evaluation[P]) Gate {
+	gate := Gate{
+		Name:   GateMilestone,
+		Passed: true,
+		Data: map[string]any{
+			"required": r.config.GetNeedMilestone(),
+			"present":  eval.info.HasMilestone,
+		},
+		action: NextCallHuman,
+	}
+
+	if r.config.GetNeedMilestone() && !eval.info.HasMilestone {
+		return failGate(gate, WithReason(ReasonMilestone, errors.New("the milestone is missing")))
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) reviewsGate(ctx context.Context, eval *evaluation[P]) Gate {
+	minReview := r.getMinReview(eval.info)
+
+	gate := Gate{
+		Name:   GateReviews,
+		Passed: true,
+		Data: map[string]any{
+			"required":    minReview,
+			"lightReview": slices.Contains(eval.info.Labels, r.markers.LightReview),
+		},
+		action: NextCallHuman,
+	}
+
+	err := r.forge.CheckReviews(ctx, eval.pr, minReview)
+	if err != nil {
+		return failGate(gate, WithReason(ReasonReview, fmt.Errorf("error related to review: %w", err)))
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) checksGate(ctx context.Context, eval *evaluation[P]) Gate {
+	gate := Gate{Name: GateChecks, Passed: true, action: r.retryAction(eval.info, r.retry.OnStatuses)}
+
+	state, err := r.forge.GetChecksState(ctx, eval.pr)
+	if err != nil {
+		return failGate(gate, WithReason(ReasonChecks, fmt.Errorf("checks status: %w", err)))
+	}
+
+	gate.Data = map[string]any{"state": state}
+
+	if state == Pending {
+		gate.Passed = false
+		gate.Message = "waiting for the CI"
+		gate.action = NextWaitCI
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) mergeableGate(_ context.Context, eval *evaluation[P]) Gate {
+	info := eval.info
+
+	gate := Gate{
+		Name:   GateMergeable,
+		Passed: true,
+		Data: map[string]any{
+			"state":     info.State,
+			"merged":    info.Merged,
+			"conflicts": info.Conflicts,
+			"draft":     info.Draft,
+		},
+		action: NextCallHuman,
+	}
+
+	switch {
+	case info.Merged:
+		gate.Passed = false
+		gate.Message = "the PR is already merged"
+		gate.action = NextCleanLabels
+	case info.State != StateOpen:
+		return failGate(gate, WithReason(ReasonMergeableState, fmt.Errorf("the pull request is %s", info.State)))
+	case info.Conflicts:
+		gate.action = r.retryAction(info, r.retry.OnMergeable)
+		return failGate(gate, WithReason(ReasonConflicts, errors.New("conflicts must be resolved in the PR")))
+	case info.Draft:
+		return failGate(gate, WithReason(ReasonMergeableState, errors.New("the pull request is a draft")))
+	case info.Waiting != "":
+		gate.Passed = false
+		gate.Message = fmt.Sprintf("waiting for the %s", info.Waiting)
+		gate.action = NextNone
+	case info.Blocked != "":
+		return failGate(gate, WithReason(ReasonMergeableState, errors.New(info.Blocked)))
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) mergeMethodGate(ctx context.Context, eval *evaluation[P]) Gate {
+	gate := Gate{Name: GateMergeMethod, Passed: true, action: NextCallHuman}
+
+	mergeMethod, err := getMergeMethod(r.markers, r.config, eval.info.Labels)
+	if err != nil {
+		return failGate(gate, WithReason(ReasonMergeMethod, err))
+	}
+
+	eval.mergeMethod = mergeMethod
+
+	gate.Data = map[string]any{"method": mergeMethod}
+
+	if !slices.Contains(r.forge.MergeMethods(), mergeMethod) {
+		return failGate(gate, WithReason(ReasonMergeMethod, fmt.Errorf("the merge method [%s] is not supported by the forge", mergeMethod)))
+	}
+
+	if mergeMethod != conf.MergeMethodFastForward {
+		return gate
+	}
+
+	upToDate, err := r.isUpToDate(ctx, eval)
+	if err != nil {
+		return failGate(gate, err)
+	}
+
+	if !upToDate {
+		return failGate(gate, WithReason(ReasonMergeMethod, fmt.Errorf("the use of the merge method [%s] is impossible when a branch is not up-to-date", mergeMethod)))
+	}
+
+	return gate
+}
+
+func (r *ForgeRepository[P]) upToDateGate(ctx context.Context, eval *evaluation[P]) Gate {
+	noRebase := slices.Contains(eval.info.Labels, r.markers.MergeNoRebase)
+
+	gate := Gate{Name: GateUpToDate, Passed: true, Data: map[string]any{"noRebase": noRebase}, action: NextCallHuman}
+
+	needUpdate := r.config.GetForceNeedUpToDate()
+	if r.config.GetCheckNeedUpToDate() {
+		var err error
+
+		needUpdate, err = r.forge.NeedUpdate(ctx, eval.pr)
+		if err != nil {
+			return failGate(gate, err)
+		}
+	}
+
+	gate.Data["required"] = needUpdate
+
+	upToDate, err := r.isUpToDate(ctx, eval)
+	if err != nil {
+		return failGate(gate, err)
+	}
+
+	gate.Data["upToDate"] = upToDate
+
+	if needUpdate && !upToDate && !noRebase {
+		gate.Passed = false
+		gate.Message = "the branch is not up-to-date with its target branch"
+		gate.action = NextUpdate
+	}
+
+	return gate
+}
+
+// isUpToDate checks if the head branch of a pull request is up-to-date, the result is shared by the gates.
+func (r *ForgeRepository[P]) isUpToDate(ctx context.Context, eval *evaluation[P]) (bool, error) {
+	if eval.upToDate != nil {
+		return *eval.upToDate, nil
+	}
+
+	upToDate, err := r.forge.IsUpToDate(ctx, eval.pr)
+	if err != nil {
+		return false, err
+	}
+
+	eval.upToDate = &upToDate
+
+	return upToDate, nil
+}
+
+// retryAction gets the action of the bot when a retry is possible (see manageRetryLabel).
+func (r *ForgeRepository[P]) retryAction(info PullRequest, enabled bool) string {
+	if !enabled || r.retry.Number <= 0 {
+		return NextCallHuman
+	}
+
+	currentRetryLabel := findWithPrefix(info.Labels, r.markers.MergeRetryPrefix)
+	if currentRetryLabel != "" && extractRetryNumber(currentRetryLabel, r.markers.MergeRetryPrefix) >= r.retry.Number {
+		return NextCallHuman
+	}
+
+	return NextRetry
+}
+
+func failGate(gate Gate, err error) Gate {
+	gate.Passed = false
+	gate.Message = err.Error()
+	gate.err = err
+
+	return gate
+}
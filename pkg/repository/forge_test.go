@@ -0,0 +1,691 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// fakePull a pull request of the fake forge.
+type fakePull struct {
+	info PullRequest
+
+	approvals  int
+	checks     string
+	checksErr  error
+	needUpdate bool
+	upToDate   bool
+	updateErr  error
+
+	comments []string
+	updates  int
+	merged   bool
+	method   string
+	message  CommitMessage
+}
+
+// fakeForge an in-memory forge with a single pull request.
+type fakeForge struct {
+	pull *fakePull
+
+	// mergeMethods the supported merge methods, all if empty.
+	mergeMethods []string
+}
+
+func (f *fakeForge) GetPullRequest(_ context.Context, number int) (*fakePull, error) {
+	if f.pull.info.Number != number {
+		return nil, fmt.Errorf("pull request #%d not found", number)
+	}
+
+	return f.pull, nil
+}
+
+func (f *fakeForge) Describe(pr *fakePull) PullRequest {
+	return pr.info
+}
+
+func (f *fakeForge) CheckReviews(_ context.Context, pr *fakePull, minReview int) error {
+	if pr.approvals < minReview {
+		return fmt.Errorf("need more review [%d/%d]", pr.approvals, minReview)
+	}
+
+	return nil
+}
+
+func (f *fakeForge) GetChecksState(_ context.Context, pr *fakePull) (string, error) {
+	return cmp.Or(pr.checks, Success), pr.checksErr
+}
+
+func (f *fakeForge) NeedUpdate(_ context.Context, pr *fakePull) (bool, error) {
+	return pr.needUpdate, nil
+}
+
+func (f *fakeForge) IsUpToDate(_ context.Context, pr *fakePull) (bool, error) {
+	return pr.upToDate, nil
+}
+
+func (f *fakeForge) AddLabels(_ context.Context, pr *fakePull, labels ...string) error {
+	pr.info.Labels = append(pr.info.Labels, labels...)
+	return nil
+}
+
+func (f *fakeForge) RemoveLabels(_ context.Context, pr *fakePull, labels ...string) error {
+	pr.info.Labels = slices.DeleteFunc(pr.info.Labels, func(lbl string) bool { return slices.Contains(labels, lbl) })
+	return nil
+}
+
+func (f *fakeForge) AddComment(_ context.Context, pr *fakePull, message string) error {
+	pr.comments = append(pr.comments, message)
+	return nil
+}
+
+func (f *fakeForge) CanUpdate(_ context.Context, pr *fakePull) error {
+	return pr.updateErr
+}
+
+func (f *fakeForge) Update(_ context.Context, pr *fakePull) (string, error) {
+	pr.updates++
+	return ActionRebase, nil
+}
+
+func (f *fakeForge) MergeMethods() []string {
+	if len(f.mergeMethods) == 0 {
+		return []string{conf.MergeMethodSquash, conf.MergeMethodMerge, conf.MergeMethodRebase, conf.MergeMethodFastForward}
+	}
+
+	return f.mergeMethods
+}
+
+func (f *fakeForge) Merge(_ context.Context, pr *fakePull, mergeMethod string, message CommitMessage) error {
+	pr.merged = true
+	pr.method = mergeMethod
+	pr.message = message
+
+	return nil
+}
+
+// fakeStatusForge a fake forge publishing the commit status of the bot.
+type fakeStatusForge struct {
+	*fakeForge
+
+	statuses  []string
+	forgotten bool
+}
+
+func (f *fakeStatusForge) PublishStatus(_ context.Context, _ *fakePull, state, description string) {
+	f.statuses = append(f.statuses, state+": "+description)
+}
+
+func (f *fakeStatusForge) ForgetStatus(_ *fakePull) {
+	f.forgotten = true
+}
+
+func TestForgeRepository_Process(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		pull   fakePull
+		config conf.RepoConfig
+		retry  conf.Retry
+		extra  conf.Extra
+
+		mergeMethods []string
+
+		expectedReason   string
+		expectedLabels   []string
+		expectedComments []string
+		expectedUpdates  int
+		expectedMethod   string
+		expectedMessage  CommitMessage
+	}{
+		{
+			desc: "squash",
+			pull: fakePull{
+				info: PullRequest{
+					Title:  "Fix the bug",
+					Body:   "Fixes #3\n\nCo-authored-by: ldez <ldez@example.com>",
+					Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+				},
+				approvals: 1,
+			},
+			expectedMethod:  conf.MergeMethodSquash,
+			expectedMessage: CommitMessage{Title: "Fix the bug", Body: "Co-authored-by: ldez <ldez@example.com>"},
+		},
+		{
+			desc: "squash with the description",
+			pull: fakePull{
+				info:      PullRequest{Title: "Fix the bug", Body: "Fixes #3", Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+			},
+			config:          conf.RepoConfig{CommitMessage: conf.String(conf.CommitMessageDescription)},
+			expectedMethod:  conf.MergeMethodSquash,
+			expectedMessage: CommitMessage{Title: "Fix the bug", Body: "Fixes #3"},
+		},
+		{
+			desc: "merge method label",
+			pull: fakePull{
+				info:      PullRequest{Title: "Fix the bug", Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodMerge}},
+				approvals: 1,
+			},
+			expectedMethod: conf.MergeMethodMerge,
+		},
+		{
+			desc: "unsupported merge method label",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodRebase}},
+				approvals: 1,
+			},
+			mergeMethods:     []string{conf.MergeMethodSquash},
+			expectedReason:   ReasonMergeMethod,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodRebase, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: the merge method [rebase] is not supported by the forge"},
+		},
+		{
+			desc: "light review",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.LightReview}},
+				approvals: 1,
+			},
+			config:         conf.RepoConfig{MinReview: conf.Int(2), MinLightReview: conf.Int(1)},
+			expectedMethod: conf.MergeMethodSquash,
+		},
+		{
+			desc: "missing approvals",
+			pull: fakePull{
+				info: PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+			},
+			expectedReason:   ReasonReview,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: error related to review: need more review [0/1]"},
+		},
+		{
+			desc: "missing milestone",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+			},
+			config:           conf.RepoConfig{NeedMilestone: conf.Bool(true)},
+			expectedReason:   ReasonMilestone,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: the milestone is missing"},
+		},
+		{
+			desc: "pending checks",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+				checks:    Pending,
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge},
+		},
+		{
+			desc: "failed checks",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+				checksErr: errors.New("the pipeline #1 is failed"),
+			},
+			expectedReason:   ReasonChecks,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: checks status: the pipeline #1 is failed"},
+		},
+		{
+			desc: "failed checks with retry",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+				checksErr: errors.New("the pipeline #1 is failed"),
+			},
+			retry:          conf.Retry{Number: 1, OnStatuses: true},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1", fakeMarkers.MergeInProgress},
+		},
+		{
+			desc: "failed checks with too many retries",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1", fakeMarkers.MergeInProgress}},
+				approvals: 1,
+				checksErr: errors.New("the pipeline #1 is failed"),
+			},
+			retry:            conf.Retry{Number: 1, OnStatuses: true},
+			expectedReason:   ReasonChecks,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: too many retry [1/1]: checks status: the pipeline #1 is failed"},
+		},
+		{
+			desc: "conflicts",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}, Conflicts: true},
+				approvals: 1,
+			},
+			expectedReason:   ReasonConflicts,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: conflicts must be resolved in the PR"},
+		},
+		{
+			desc: "closed",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress}, State: "closed"},
+				approvals: 1,
+			},
+			expectedReason:   ReasonMergeableState,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: the pull request is closed"},
+		},
+		{
+			desc: "draft",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}, Draft: true},
+				approvals: 1,
+			},
+			expectedReason:   ReasonMergeableState,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: the pull request is a draft"},
+		},
+		{
+			desc: "waiting",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}, Waiting: "mergeability check"},
+				approvals: 1,
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge},
+		},
+		{
+			desc: "blocked",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}, Blocked: `the detailed merge status is "discussions_not_resolved"`},
+				approvals: 1,
+			},
+			expectedReason:   ReasonMergeableState,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{`:no_entry_sign: the detailed merge status is "discussions_not_resolved"`},
+		},
+		{
+			desc: "update",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1"}},
+				approvals: 1,
+			},
+			retry:           conf.Retry{Number: 1, OnMergeable: true},
+			config:          conf.RepoConfig{ForceNeedUpToDate: conf.Bool(true)},
+			expectedLabels:  []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedUpdates: 1,
+		},
+		{
+			desc: "update required by the forge",
+			pull: fakePull{
+				info:       PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals:  1,
+				needUpdate: true,
+			},
+			config:          conf.RepoConfig{CheckNeedUpToDate: conf.Bool(true), ForceNeedUpToDate: conf.Bool(true)},
+			expectedLabels:  []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedUpdates: 1,
+		},
+		{
+			desc: "update not required by the forge",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+			},
+			config:         conf.RepoConfig{CheckNeedUpToDate: conf.Bool(true), ForceNeedUpToDate: conf.Bool(true)},
+			expectedMethod: conf.MergeMethodSquash,
+		},
+		{
+			desc: "not up-to-date without rebase",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeNoRebase}},
+				approvals: 1,
+			},
+			config:         conf.RepoConfig{ForceNeedUpToDate: conf.Bool(true)},
+			expectedMethod: conf.MergeMethodSquash,
+		},
+		{
+			desc: "branch that cannot be updated",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+				approvals: 1,
+				updateErr: errors.New("the branch main on the main repository is protected and cannot be updated"),
+			},
+			config:           conf.RepoConfig{ForceNeedUpToDate: conf.Bool(true)},
+			expectedReason:   ReasonUpdate,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: failed to update: the branch main on the main repository is protected and cannot be updated"},
+		},
+		{
+			desc: "fast-forward not up-to-date",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodFastForward}},
+				approvals: 1,
+			},
+			expectedReason:   ReasonMergeMethod,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodFastForward, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: the use of the merge method [ff] is impossible when a branch is not up-to-date"},
+		},
+		{
+			desc: "already merged",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress, "kind/bug"}, State: "merged", Merged: true},
+				approvals: 1,
+			},
+			expectedLabels: []string{"kind/bug"},
+		},
+		{
+			desc: "comment on a private repository",
+			pull: fakePull{
+				info: PullRequest{Labels: []string{fakeMarkers.NeedMerge}, Private: true},
+			},
+			config:           conf.RepoConfig{AddErrorInComment: conf.Bool(false)},
+			expectedReason:   ReasonReview,
+			expectedLabels:   []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedComments: []string{":no_entry_sign: error related to review: need more review [0/1]"},
+		},
+		{
+			desc: "no comment",
+			pull: fakePull{
+				info: PullRequest{Labels: []string{fakeMarkers.NeedMerge}},
+			},
+			config:         conf.RepoConfig{AddErrorInComment: conf.Bool(false)},
+			expectedReason: ReasonReview,
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+		},
+		{
+			desc: "dry run",
+			pull: fakePull{
+				info:      PullRequest{Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress}},
+				approvals: 1,
+			},
+			extra:          conf.Extra{DryRun: true},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			pull := test.pull
+			pull.info.Number = 1
+			pull.info.State = cmp.Or(pull.info.State, StateOpen)
+
+			forge := &fakeForge{pull: &pull, mergeMethods: test.mergeMethods}
+
+			repo := NewForgeRepository(forge, fakeRepoName, "secret", fakeMarkers, test.retry, conf.Timeouts{}, forgeConfig(test.config), test.extra, nil)
+
+			err := repo.Process(t.Context(), 1)
+
+			if test.expectedReason != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedReason, GetReason(err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedComments, pull.comments)
+			assert.Equal(t, test.expectedUpdates, pull.updates)
+
+			if test.expectedMethod == "" {
+				assert.Equal(t, test.expectedLabels, pull.info.Labels)
+				assert.False(t, pull.merged)
+				return
+			}
+
+			require.True(t, pull.merged)
+			assert.Equal(t, test.expectedMethod, pull.method)
+			assert.Equal(t, test.expectedMessage, pull.message)
+			assert.Empty(t, pull.info.Labels)
+		})
+	}
+}
+
+func TestForgeRepository_Process_timeout(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		labels []string
+
+		expectedError  bool
+		expectedLabels []string
+	}{
+		{
+			desc:           "retry",
+			labels:         []string{fakeMarkers.NeedMerge},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1", fakeMarkers.MergeInProgress},
+		},
+		{
+			desc:           "too many retries",
+			labels:         []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1", fakeMarkers.MergeInProgress},
+			expectedError:  true,
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			pull := &fakePull{
+				info:      PullRequest{Number: 1, State: StateOpen, Labels: test.labels},
+				approvals: 1,
+				checksErr: fmt.Errorf("list the pipelines: %w", context.DeadlineExceeded),
+			}
+
+			repo := NewForgeRepository(&fakeForge{pull: pull}, fakeRepoName, "", fakeMarkers, conf.Retry{Number: 1}, conf.Timeouts{}, forgeConfig(conf.RepoConfig{}), conf.Extra{}, nil)
+
+			err := repo.Process(t.Context(), 1)
+
+			if test.expectedError {
+				require.Error(t, err)
+				assert.Equal(t, ReasonTimeout, GetReason(err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedLabels, pull.info.Labels)
+		})
+	}
+}
+
+func TestForgeRepository_Process_status(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		pull   fakePull
+		config conf.RepoConfig
+		retry  conf.Retry
+
+		expectedStatuses  []string
+		expectedForgotten bool
+	}{
+		{
+			desc:              "merged",
+			pull:              fakePull{approvals: 1},
+			expectedStatuses:  []string{"success: merged"},
+			expectedForgotten: true,
+		},
+		{
+			desc:             "pending checks",
+			pull:             fakePull{approvals: 1, checks: Pending},
+			expectedStatuses: []string{"pending: waiting for CI"},
+		},
+		{
+			desc:             "missing milestone",
+			pull:             fakePull{approvals: 1},
+			config:           conf.RepoConfig{NeedMilestone: conf.Bool(true)},
+			expectedStatuses: []string{"error: blocked: missing milestone"},
+		},
+		{
+			desc:             "conflicts with retry",
+			pull:             fakePull{approvals: 1, info: PullRequest{Conflicts: true}},
+			retry:            conf.Retry{Number: 1, OnMergeable: true},
+			expectedStatuses: []string{"pending: waiting for a retry: conflicts"},
+		},
+		{
+			desc:             "update",
+			pull:             fakePull{approvals: 1},
+			config:           conf.RepoConfig{ForceNeedUpToDate: conf.Bool(true)},
+			expectedStatuses: []string{"pending: updating branch"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			pull := test.pull
+			pull.info.Number = 1
+			pull.info.State = StateOpen
+			pull.info.Labels = []string{fakeMarkers.NeedMerge}
+
+			forge := &fakeStatusForge{fakeForge: &fakeForge{pull: &pull}}
+
+			repo := NewForgeRepository[*fakePull](forge, fakeRepoName, "", fakeMarkers, test.retry, conf.Timeouts{}, forgeConfig(test.config), conf.Extra{}, nil)
+
+			_ = repo.Process(t.Context(), 1)
+
+			assert.Equal(t, test.expectedStatuses, forge.statuses)
+			assert.Equal(t, test.expectedForgotten, forge.forgotten)
+		})
+	}
+}
+
+func TestForgeRepository_Explain(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		pull   fakePull
+		config conf.RepoConfig
+		retry  conf.Retry
+
+		expectedFailed []string
+		expectedAction string
+		expectedNote   string
+	}{
+		{
+			desc:           "merge",
+			pull:           fakePull{approvals: 1, upToDate: true},
+			expectedAction: NextMerge,
+		},
+		{
+			desc:           "missing label",
+			pull:           fakePull{approvals: 1, upToDate: true, info: PullRequest{Labels: []string{"kind/bug"}}},
+			expectedFailed: []string{GateLabels},
+			expectedAction: NextNone,
+		},
+		{
+			desc:           "pending checks",
+			pull:           fakePull{approvals: 1, upToDate: true, checks: Pending},
+			expectedFailed: []string{GateChecks},
+			expectedAction: NextWaitCI,
+		},
+		{
+			desc:           "failed checks with retry",
+			pull:           fakePull{approvals: 1, upToDate: true, checksErr: errors.New("the pipeline #1 is failed")},
+			retry:          conf.Retry{Number: 1, OnStatuses: true},
+			expectedFailed: []string{GateChecks},
+			expectedAction: NextRetry,
+		},
+		{
+			desc:           "conflicts after the last retry",
+			pull:           fakePull{approvals: 1, upToDate: true, info: PullRequest{Conflicts: true, Labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1"}}},
+			retry:          conf.Retry{Number: 1, OnMergeable: true},
+			expectedFailed: []string{GateMergeable},
+			expectedAction: NextCallHuman,
+		},
+		{
+			desc:           "already merged",
+			pull:           fakePull{approvals: 1, upToDate: true, info: PullRequest{Merged: true}},
+			expectedFailed: []string{GateMergeable},
+			expectedAction: NextCleanLabels,
+		},
+		{
+			desc:           "update",
+			pull:           fakePull{approvals: 1},
+			config:         conf.RepoConfig{ForceNeedUpToDate: conf.Bool(true)},
+			expectedFailed: []string{GateUpToDate},
+			expectedAction: NextUpdate,
+		},
+		{
+			desc:           "fast-forward on a branch not up-to-date",
+			pull:           fakePull{approvals: 1},
+			config:         conf.RepoConfig{MergeMethod: conf.String(conf.MergeMethodFastForward), ForceNeedUpToDate: conf.Bool(true)},
+			expectedFailed: []string{GateMergeMethod, GateUpToDate},
+			expectedAction: NextCallHuman,
+		},
+		{
+			desc:           "merge train",
+			pull:           fakePull{approvals: 1, upToDate: true},
+			config:         conf.RepoConfig{MergeTrainSize: conf.Int(3)},
+			expectedAction: NextMerge,
+			expectedNote:   "the merge train is not covered: the pull requests are merged by trains of 3",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			pull := test.pull
+			pull.info.Number = 1
+			pull.info.State = StateOpen
+
+			if pull.info.Labels == nil {
+				pull.info.Labels = []string{fakeMarkers.NeedMerge}
+			}
+
+			labels := slices.Clone(pull.info.Labels)
+
+			forge := &fakeStatusForge{fakeForge: &fakeForge{pull: &pull}}
+
+			repo := NewForgeRepository[*fakePull](forge, fakeRepoName, "", fakeMarkers, test.retry, conf.Timeouts{}, forgeConfig(test.config), conf.Extra{}, nil)
+
+			report, err := repo.Explain(t.Context(), 1)
+			require.NoError(t, err)
+
+			var failed []string
+			for _, gate := range report.Gates {
+				if !gate.Passed {
+					failed = append(failed, gate.Name)
+				}
+			}
+
+			assert.Equal(t, test.expectedFailed, failed)
+			assert.Equal(t, test.expectedAction, report.Action)
+			assert.Equal(t, test.expectedNote, report.Note)
+
+			// nothing must be mutated.
+			assert.Equal(t, labels, pull.info.Labels)
+			assert.Empty(t, pull.comments)
+			assert.Empty(t, forge.statuses)
+			assert.Zero(t, pull.updates)
+			assert.False(t, pull.merged)
+		})
+	}
+}
+
+// forgeConfig gets a configuration of a repository: the fields not defined are the defaults of the bot.
+func forgeConfig(config conf.RepoConfig) conf.RepoConfig {
+	if config.MergeMethod == nil {
+		config.MergeMethod = conf.String(conf.MergeMethodSquash)
+	}
+
+	if config.MinReview == nil {
+		config.MinReview = conf.Int(1)
+	}
+
+	if config.MinLightReview == nil {
+		config.MinLightReview = conf.Int(0)
+	}
+
+	if config.AddErrorInComment == nil {
+		config.AddErrorInComment = conf.Bool(true)
+	}
+
+	if config.CommitMessage == nil {
+		config.CommitMessage = conf.String(conf.CommitMessageEmpty)
+	}
+
+	return config
+}
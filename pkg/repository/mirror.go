@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ldez/go-git-cmd-wrapper/v2/clone"
+	"github.com/ldez/go-git-cmd-wrapper/v2/config"
+	"github.com/ldez/go-git-cmd-wrapper/v2/fetch"
+	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
+	"github.com/ldez/go-git-cmd-wrapper/v2/types"
+	"github.com/rs/zerolog/log"
+)
+
+// lockRetryInterval the interval between two attempts to lock a mirror locked by another process.
+const lockRetryInterval = 100 * time.Millisecond
+
+// MirrorCache a cache of bare mirrors of the repositories.
+// The mirrors are used as reference by the clones to reduce the network usage.
+// A mirror is locked during its refresh and while it's used by a clone:
+// the cache can be shared by concurrent jobs, and by several processes (file lock next to the mirror).
+type MirrorCache struct {
+	dir   string
+	debug bool
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewMirrorCache creates a new mirror cache.
+// Returns nil if the directory is empty: the cache is disabled.
+func NewMirrorCache(dir string, debug bool) *MirrorCache {
+	if dir == "" {
+		return nil
+	}
+
+	return &MirrorCache{
+		dir:   dir,
+		debug: debug,
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// reference gets an up-to-date mirror of a repository, and locks it.
+// The release function must be called when the mirror is not used anymore.
+func (m *MirrorCache) reference(ctx context.Context, gitURL, repoURL string) (string, func(), error) {
+	key, err := mirrorKey(gitURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path := filepath.Join(m.dir, key)
+
+	lock := m.lock(path)
+	lock.Lock()
+
+	unlockFile, err := m.lockFile(ctx, path)
+	if err != nil {
+		lock.Unlock()
+		return "", nil, fmt.Errorf("failed to lock the mirror %s: %w", path, err)
+	}
+
+	release := func() {
+		unlockFile()
+		lock.Unlock()
+	}
+
+	err = m.refresh(ctx, path, repoURL)
+	if err != nil {
+		release()
+		return "", nil, err
+	}
+
+	return path, release, nil
+}
+
+// lockFile locks a mirror across the processes.
+func (m *MirrorCache) lockFile(ctx context.Context, path string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return nil, err
+	}
+
+	return lockFile(ctx, path+".lock")
+}
+
+func (m *MirrorCache) lock(path string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, ok := m.locks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[path] = lock
+	}
+
+	return lock
+}
+
+// refresh creates the mirror if it doesn't exist, or fetches it.
+func (m *MirrorCache) refresh(ctx context.Context, path, repoURL string) error {
+	logger := log.Ctx(ctx)
+
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Debug().Str("mirror", path).Msg("Create mirror.")
+
+		err = os.MkdirAll(filepath.Dir(path), 0o750)
+		if err != nil {
+			return err
+		}
+
+		output, errClone := git.CloneWithContext(ctx,
+			clone.Mirror,
+			// no automatic gc: the objects of the mirror are used by the clones.
+			clone.Config("gc.auto", "0"),
+			clone.Repository(repoURL),
+			clone.Directory(path),
+			git.Debugger(m.debug))
+		if errClone != nil {
+			IgnoreError(ctx, os.RemoveAll(path))
+			return fmt.Errorf("failed to create the mirror %s: %w\n %s", path, errClone, cleanOutput(output, repoURL))
+		}
+
+		// the URL can contain a token: the URL is always provided explicitly to fetch.
+		_, err = git.ConfigWithContext(ctx, global.UpperC(path), config.Entry("remote.origin.url", "invalid"), git.Debugger(m.debug))
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	logger.Debug().Str("mirror", path).Msg("Refresh mirror.")
+
+	output, err := git.FetchWithContext(ctx,
+		global.UpperC(path),
+		fetch.Prune,
+		fetch.Remote(repoURL),
+		fetch.RefSpec("+refs/*:refs/*"),
+		git.Debugger(m.debug))
+	if err != nil {
+		return fmt.Errorf("failed to fetch the mirror %s: %w\n %s", path, err, cleanOutput(output, repoURL))
+	}
+
+	return nil
+}
+
+// withReference adds the mirror as reference of a clone.
+// The objects are copied from the mirror (dissociate): the clone doesn't depend on the mirror after the clone.
+func withReference(path string) types.Option {
+	return func(g *types.Cmd) {
+		g.AddOptions("--reference-if-able")
+		g.AddOptions(path)
+		g.AddOptions("--dissociate")
+	}
+}
+
+// mirrorKey gets the relative path of the mirror of a repository from its Git URL.
+func mirrorKey(gitURL string) (string, error) {
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL: %w", err)
+	}
+
+	host := u.Host
+	if u.Scheme == "file" {
+		host = "local"
+	}
+
+	name := strings.Trim(u.Path, "/")
+	if host == "" || name == "" || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid repository URL: %s", gitURL)
+	}
+
+	if !strings.HasSuffix(name, ".git") {
+		name += ".git"
+	}
+
+	return filepath.Join(host, filepath.FromSlash(name)), nil
+}
+
+// cleanOutput removes the URL (that can contain a token) from a git output.
+func cleanOutput(output, repoURL string) string {
+	return strings.ReplaceAll(output, repoURL, "xxx")
+}
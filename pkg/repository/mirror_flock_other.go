@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package repository
+
+import "context"
+
+// lockFile is a no-op: the file locks are not supported on this platform,
+// the cache directory must not be shared by several processes.
+func lockFile(_ context.Context, _ string) (func(), error) {
+	return func() {}, nil
+}
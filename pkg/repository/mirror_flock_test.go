@@ -0,0 +1,33 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_lockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirror.git.lock")
+
+	release, err := lockFile(t.Context(), path)
+	require.NoError(t, err)
+
+	// another open file description: same behavior as another process.
+	ctx, cancel := context.WithTimeout(t.Context(), 3*lockRetryInterval)
+	defer cancel()
+
+	_, err = lockFile(ctx, path)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	time.AfterFunc(lockRetryInterval, release)
+
+	releaseNext, err := lockFile(t.Context(), path)
+	require.NoError(t, err)
+
+	releaseNext()
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mirrorKey(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		gitURL   string
+		expected string
+		errorMsg string
+	}{
+		{
+			desc:     "git URL",
+			gitURL:   "git://github.com/traefik/traefik.git",
+			expected: filepath.Join("github.com", "traefik", "traefik.git"),
+		},
+		{
+			desc:     "HTTPS URL without suffix",
+			gitURL:   "https://github.com/traefik/traefik",
+			expected: filepath.Join("github.com", "traefik", "traefik.git"),
+		},
+		{
+			desc:     "file URL",
+			gitURL:   "file:///tmp/traefik/traefik.git",
+			expected: filepath.Join("local", "tmp", "traefik", "traefik.git"),
+		},
+		{
+			desc:     "missing host",
+			gitURL:   "/traefik/traefik.git",
+			errorMsg: "invalid repository URL: /traefik/traefik.git",
+		},
+		{
+			desc:     "path traversal",
+			gitURL:   "https://github.com/../traefik.git",
+			errorMsg: "invalid repository URL: https://github.com/../traefik.git",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := mirrorKey(test.gitURL)
+
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, key)
+		})
+	}
+}
+
+func TestMirrorCache_reference(t *testing.T) {
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runGit(t, "", "init", "--bare", "--initial-branch=master", origin)
+
+	work := t.TempDir()
+	runGit(t, "", "clone", origin, work)
+	runGit(t, work, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "first")
+	runGit(t, work, "push", "origin", "HEAD:master")
+
+	cache := NewMirrorCache(t.TempDir(), false)
+
+	originURL := "file://" + filepath.ToSlash(origin)
+
+	// creation.
+	path, release, err := cache.reference(t.Context(), originURL, originURL)
+	require.NoError(t, err)
+	release()
+
+	assert.Equal(t, runGit(t, work, "rev-parse", "HEAD"), runGit(t, path, "rev-parse", "master"))
+	assert.Equal(t, "invalid", runGit(t, path, "config", "remote.origin.url"))
+
+	// refresh.
+	runGit(t, work, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "second")
+	runGit(t, work, "push", "origin", "HEAD:master")
+
+	path, release, err = cache.reference(t.Context(), originURL, originURL)
+	require.NoError(t, err)
+	release()
+
+	assert.Equal(t, runGit(t, work, "rev-parse", "HEAD"), runGit(t, path, "rev-parse", "master"))
+}
+
+func TestNewMirrorCache_disabled(t *testing.T) {
+	assert.Nil(t, NewMirrorCache("", false))
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	output, err := exec.CommandContext(t.Context(), "git", args...).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	return strings.TrimSpace(string(output))
+}
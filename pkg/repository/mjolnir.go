@@ -9,11 +9,12 @@ import (
 
 	"github.com/google/go-github/v74/github"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
 )
 
 // Mjolnir the hammer of Thor.
 type Mjolnir struct {
-	client *github.Client
+	client *ghapi.Client
 
 	globalFixesIssueRE *regexp.Regexp
 	fixesIssueRE       *regexp.Regexp
@@ -25,7 +26,7 @@ type Mjolnir struct {
 	name  string
 }
 
-func newMjolnir(client *github.Client, owner, name string, dryRun bool) Mjolnir {
+func newMjolnir(client *ghapi.Client, owner, name string, dryRun bool) Mjolnir {
 	return Mjolnir{
 		client: client,
 
@@ -45,6 +46,15 @@ func (m Mjolnir) CloseRelatedIssues(ctx context.Context, pr *github.PullRequest)
 	logger := log.Ctx(ctx)
 
 	issueNumbers := m.parseIssueFixes(ctx, pr.GetBody())
+	if len(issueNumbers) == 0 {
+		return nil
+	}
+
+	// GitHub closes the issues automatically when a pull request is merged into the default branch.
+	defaultBranch, err := getDefaultBranch(ctx, m.client, pr)
+	if err != nil {
+		return err
+	}
 
 	for _, issueNumber := range issueNumbers {
 		logger.Info().Msgf("closes issue #%d, add milestones %s", issueNumber, pr.Milestone.GetTitle())
@@ -58,7 +68,7 @@ func (m Mjolnir) CloseRelatedIssues(ctx context.Context, pr *github.PullRequest)
 
 		// Add comment if needed
 
-		if pr.Base.GetRef() == mainBranch {
+		if pr.Base.GetRef() == defaultBranch {
 			return nil
 		}
 
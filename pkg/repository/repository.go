@@ -2,211 +2,168 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
 )
 
-const mainBranch = "master"
-
 type numbered interface {
 	GetNumber() int
 }
 
 // Repository a new repository manager.
 type Repository struct {
-	client *github.Client
+	client *ghapi.Client
 
 	clone   Clone
 	mjolnir Mjolnir
 
-	debug  bool
-	dryRun bool
+	debug      bool
+	dryRun     bool
+	configFile bool
 
 	markers conf.Markers
 	retry   conf.Retry
 
+	timeouts conf.Timeouts
+
 	owner string
 	name  string
 
-	token string
-
 	config conf.RepoConfig
+
+	metrics  *metrics.Metrics
+	statuses *StatusPublisher
+
+	// pipeline the pipeline of the pull requests applied to the repository.
+	pipeline *ForgeRepository[*github.PullRequest]
 }
 
 // New creates a new repository manager.
-func New(client *github.Client, fullName, token string, markers conf.Markers, retry conf.Retry, gitConfig conf.Git, config conf.RepoConfig, extra conf.Extra) *Repository {
+func New(client *ghapi.Client, fullName, token string, markers conf.Markers, retry conf.Retry, timeouts conf.Timeouts, gitConfig conf.Git, config conf.RepoConfig, extra conf.Extra, cache *MirrorCache, recorder *metrics.Metrics, statuses *StatusPublisher) *Repository {
 	repoFragments := strings.Split(fullName, "/")
 
 	owner := repoFragments[0]
 	repoName := repoFragments[1]
 
-	return &Repository{
-		client:  client,
-		clone:   newClone(gitConfig, token),
-		mjolnir: newMjolnir(client, owner, repoName, extra.DryRun),
-		dryRun:  extra.DryRun,
-		markers: markers,
-		retry:   retry,
-		owner:   owner,
-		name:    repoName,
-		token:   token,
-		config:  config,
-	}
+	repo := &Repository{
+		client:     client,
+		clone:      newClone(gitConfig, timeouts, token, cache, recorder),
+		mjolnir:    newMjolnir(client, owner, repoName, extra.DryRun),
+		dryRun:     extra.DryRun,
+		configFile: extra.RepoConfigFile,
+		markers:    markers,
+		retry:      retry,
+		timeouts:   timeouts,
+		owner:      owner,
+		name:       repoName,
+		config:     config,
+		metrics:    recorder,
+		statuses:   statuses,
+	}
+
+	repo.pipeline = NewForgeRepository[*github.PullRequest](repo, fullName, token, markers, retry, timeouts, config, extra, recorder)
+
+	return repo
 }
 
 // Process try to merge a pull request.
+// The processing of the pull request is limited by the pull request deadline.
 func (r *Repository) Process(ctx context.Context, prNumber int) error {
-	pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, prNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get pull request: %w", err)
-	}
-
-	err = r.process(ctx, pr)
-	if err != nil {
-		r.callHuman(ctx, pr, err.Error())
-
-		return err
-	}
-
-	return nil
+	return r.pipeline.Process(ctx, prNumber)
 }
 
-// process try to merge a pull request.
-func (r *Repository) process(ctx context.Context, pr *github.PullRequest) error {
-	logger := log.Ctx(ctx)
-
-	if r.config.GetNeedMilestone() && pr.Milestone == nil {
-		return errors.New("the milestone is missing")
-	}
-
-	err := r.hasReviewsApprove(ctx, pr)
-	if err != nil {
-		return fmt.Errorf("error related to review: %w", err)
-	}
-
-	status, err := r.getAggregatedState(ctx, pr)
-	if err != nil {
-		logger.Error().Err(err).Msg("Checks status")
+// Explain evaluates the gates of a pull request, as the bot does, without mutating anything.
+// The merge train is not covered.
+func (r *Repository) Explain(ctx context.Context, prNumber int) (*Report, error) {
+	return r.pipeline.Explain(ctx, prNumber)
+}
 
-		return r.manageRetryLabel(ctx, pr, r.retry.OnStatuses, fmt.Errorf("checks status: %w", err))
-	}
+// GetPullRequest gets a pull request.
+func (r *Repository) GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, number)
 
-	if status == Pending || status == Queued || status == InProgress {
-		// skip
-		logger.Info().Msg("State: pending. Waiting for the CI.")
-		return nil
-	}
+	return pr, err
+}
 
-	if pr.GetMerged() {
-		logger.Info().Msg("the PR is already merged")
-
-		labelsToRemove := []string{
-			r.markers.MergeInProgress,
-			r.markers.NeedMerge,
-			r.markers.LightReview,
-			r.markers.MergeMethodPrefix + conf.MergeMethodSquash,
-			r.markers.MergeMethodPrefix + conf.MergeMethodMerge,
-			r.markers.MergeMethodPrefix + conf.MergeMethodRebase,
-			r.markers.MergeMethodPrefix + conf.MergeMethodFastForward,
-			r.markers.MergeNoRebase,
-		}
-		err = r.removeLabels(ctx, pr, labelsToRemove)
-		ignoreError(ctx, err)
-
-		return nil
+// Describe gets the information of a pull request used by the pipeline.
+// The draft, blocked, and unknown mergeable states block the merge.
+func (r *Repository) Describe(pr *github.PullRequest) PullRequest {
+	var labels []string
+	for _, lbl := range pr.Labels {
+		labels = append(labels, lbl.GetName())
 	}
 
-	if !pr.GetMergeable() {
-		logger.Info().Msg("Conflicts must be resolved in the PR.")
-
-		return r.manageRetryLabel(ctx, pr, r.retry.OnMergeable, errors.New("conflicts must be resolved in the PR"))
+	info := PullRequest{
+		Number:       pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		Body:         pr.GetBody(),
+		Labels:       labels,
+		State:        pr.GetState(),
+		Merged:       pr.GetMerged(),
+		HasMilestone: pr.Milestone != nil,
+		Conflicts:    !pr.GetMergeable(),
+		Private:      pr.GetBase().GetRepo().GetPrivate(),
 	}
 
 	switch pr.GetMergeableState() {
 	case MergeableStateDraft, MergeableStateBlocked, MergeableStateUnknown:
-		return fmt.Errorf("the mergeable state is %q", pr.GetMergeableState())
+		info.Blocked = fmt.Sprintf("the mergeable state is %q", pr.GetMergeableState())
 	}
 
-	r.cleanRetryLabel(ctx, pr)
+	return info
+}
 
-	// Get status checks
-	var needUpdate bool
-	if r.config.GetCheckNeedUpToDate() {
-		rcs, _, errCheck := r.client.Repositories.GetRequiredStatusChecks(ctx, r.owner, r.name, pr.Base.GetRef())
-		if errCheck != nil {
-			return fmt.Errorf("unable to get status checks: %w", errCheck)
-		}
+// AddComment adds a comment on a pull request.
+func (r *Repository) AddComment(ctx context.Context, pr *github.PullRequest, message string) error {
+	comment := &github.IssueComment{Body: github.Ptr(message)}
 
-		needUpdate = rcs.Strict
-	} else if r.config.GetForceNeedUpToDate() {
-		needUpdate = true
-	}
+	_, _, err := r.client.Issues.CreateComment(ctx, r.owner, r.name, pr.GetNumber(), comment)
 
-	mergeMethod, err := r.getMergeMethod(pr)
-	if err != nil {
-		return err
-	}
+	return err
+}
 
-	upToDateBranch, err := r.isUpToDateBranch(ctx, pr)
+// IgnoreError logs an error that doesn't stop the processing.
+func IgnoreError(ctx context.Context, err error) {
 	if err != nil {
-		return err
+		log.Ctx(ctx).Debug().Err(err).Msg("ignored error")
 	}
+}
 
-	if !upToDateBranch && mergeMethod == conf.MergeMethodFastForward {
-		return fmt.Errorf("the use of the merge method [%s] is impossible when a branch is not up-to-date", mergeMethod)
+// getDefaultBranch gets the default branch of the base repository of a pull request.
+func getDefaultBranch(ctx context.Context, client *ghapi.Client, pr *github.PullRequest) (string, error) {
+	if branch := pr.Base.Repo.GetDefaultBranch(); branch != "" {
+		return branch, nil
 	}
 
-	// Need to be up to date?
-	if needUpdate && !upToDateBranch && !hasLabel(pr, r.markers.MergeNoRebase) {
-		err := r.update(ctx, pr)
-		if err != nil {
-			err = fmt.Errorf("failed to update: %w", err)
-		}
-		return err
+	repo, _, err := client.Repositories.Get(ctx, pr.Base.Repo.Owner.GetLogin(), pr.Base.Repo.GetName())
+	if err != nil {
+		return "", fmt.Errorf("failed to get the repository: %w", err)
 	}
 
-	return r.merge(ctx, pr, mergeMethod)
-}
-
-func (r *Repository) callHuman(ctx context.Context, pr *github.PullRequest, message string) {
-	log.Ctx(ctx).Warn().Msg(message)
-
-	err := r.addComment(ctx, pr, ":no_entry_sign: "+message)
-	ignoreError(ctx, err)
-
-	err = r.addLabels(ctx, pr, r.markers.NeedHumanMerge)
-	ignoreError(ctx, err)
-
-	err = r.removeLabel(ctx, pr, r.markers.MergeInProgress)
-	ignoreError(ctx, err)
+	return repo.GetDefaultBranch(), nil
 }
 
-func (r *Repository) addComment(ctx context.Context, pr *github.PullRequest, message string) error {
-	if !r.config.GetAddErrorInComment() && !pr.Base.Repo.GetPrivate() {
-		return nil
+// isProtectedBranch checks if a branch of the main repository must never be rebased or force-pushed.
+// The default branch is always protected.
+func (r *Repository) isProtectedBranch(ctx context.Context, pr *github.PullRequest, branch string) (bool, error) {
+	if matchAny(r.config.GetProtectedBranches(), branch) {
+		return true, nil
 	}
 
-	msg := strings.ReplaceAll(message, r.token, "xxx")
-
-	if r.dryRun {
-		log.Ctx(ctx).Debug().Msgf("Add comment: %s", msg)
-		return nil
+	defaultBranch, err := getDefaultBranch(ctx, r.client, pr)
+	if err != nil {
+		return false, err
 	}
 
-	comment := &github.IssueComment{Body: github.Ptr(msg)}
-
-	_, _, err := r.client.Issues.CreateComment(ctx, r.owner, r.name, pr.GetNumber(), comment)
-
-	return err
+	return branch == defaultBranch, nil
 }
 
-func ignoreError(ctx context.Context, err error) {
-	if err != nil {
-		log.Ctx(ctx).Debug().Err(err).Msg("ignored error")
-	}
+func (r *Repository) fullName() string {
+	return r.owner + "/" + r.name
 }
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// ApplyConfigFile overrides the configuration with the configuration file of the repository.
+// The file is read from the base branch of the pull request: the changes of the pull request never apply to itself.
+func (r *Repository) ApplyConfigFile(ctx context.Context, pr *github.PullRequest) (conf.RepoConfig, error) {
+	if !r.configFile {
+		return r.config, nil
+	}
+
+	data, err := r.getConfigFile(ctx, pr.Base.GetRef())
+	if err != nil {
+		return conf.RepoConfig{}, err
+	}
+
+	if data == nil {
+		return r.config, nil
+	}
+
+	config, err := conf.ParseRepoConfig(data, r.config)
+	if err != nil {
+		return conf.RepoConfig{}, WithReason(ReasonConfig, err)
+	}
+
+	log.Ctx(ctx).Debug().Msgf("Use the configuration file %s.", conf.RepoConfigFile)
+
+	r.config = config
+
+	return config, nil
+}
+
+// getConfigFile gets the content of the configuration file of the repository on a branch.
+// Returns nil if the file doesn't exist.
+func (r *Repository) getConfigFile(ctx context.Context, ref string) ([]byte, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: ref}
+
+	file, _, resp, err := r.client.Repositories.GetContents(ctx, r.owner, r.name, conf.RepoConfigFile, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get %s: %w", conf.RepoConfigFile, err)
+	}
+
+	if file == nil || file.GetType() != "file" {
+		return nil, fmt.Errorf("%s is not a file", conf.RepoConfigFile)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", conf.RepoConfigFile, err)
+	}
+
+	return []byte(content), nil
+}
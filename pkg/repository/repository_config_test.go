@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+func TestRepository_applyConfigFile(t *testing.T) {
+	base := conf.RepoConfig{
+		MergeMethod:       conf.String(conf.MergeMethodSquash),
+		MinLightReview:    conf.Int(0),
+		MinReview:         conf.Int(1),
+		NeedMilestone:     conf.Bool(true),
+		AddErrorInComment: conf.Bool(true),
+	}
+
+	testCases := []struct {
+		desc       string
+		configFile bool
+		content    string
+		expected   conf.RepoConfig
+		errorMsg   string
+		reported   bool
+	}{
+		{
+			desc:     "disabled",
+			content:  "minReview: 2",
+			expected: base,
+		},
+		{
+			desc:       "no file",
+			configFile: true,
+			expected:   base,
+		},
+		{
+			desc:       "override",
+			configFile: true,
+			content:    "minReview: 2\nmergeMethod: merge",
+			expected: conf.RepoConfig{
+				MergeMethod:       conf.String(conf.MergeMethodMerge),
+				MinLightReview:    conf.Int(0),
+				MinReview:         conf.Int(2),
+				NeedMilestone:     conf.Bool(true),
+				AddErrorInComment: conf.Bool(true),
+			},
+		},
+		{
+			desc:       "lower minReview",
+			configFile: true,
+			content:    "minReview: 0",
+			expected:   base,
+			errorMsg:   "invalid .github/lobicornis.yml: minReview can only be increased: the central configuration requires 1",
+			reported:   true,
+		},
+		{
+			desc:       "invalid file",
+			configFile: true,
+			content:    "mergeMethod: foo",
+			expected:   base,
+			errorMsg:   `invalid .github/lobicornis.yml: mergeMethod is invalid: "foo"`,
+			reported:   true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var comments, labels atomic.Int32
+
+			mux := http.NewServeMux()
+
+			mux.HandleFunc("GET /repos/traefik/traefik/contents/.github/lobicornis.yml", func(rw http.ResponseWriter, req *http.Request) {
+				// the file of the head branch of the pull request is never used.
+				if req.URL.Query().Get("ref") != "main" {
+					writeJSON(t, rw, &github.RepositoryContent{
+						Type:     github.Ptr("file"),
+						Encoding: github.Ptr("base64"),
+						Content:  github.Ptr(base64.StdEncoding.EncodeToString([]byte("minReview: 0"))),
+					})
+
+					return
+				}
+
+				if test.content == "" {
+					http.NotFound(rw, nil)
+					return
+				}
+
+				writeJSON(t, rw, &github.RepositoryContent{
+					Type:     github.Ptr("file"),
+					Encoding: github.Ptr("base64"),
+					Content:  github.Ptr(base64.StdEncoding.EncodeToString([]byte(test.content))),
+				})
+			})
+
+			mux.HandleFunc("POST /repos/traefik/traefik/issues/1/comments", func(rw http.ResponseWriter, _ *http.Request) {
+				comments.Add(1)
+				writeJSON(t, rw, &github.IssueComment{})
+			})
+
+			mux.HandleFunc("POST /repos/traefik/traefik/issues/1/labels", func(rw http.ResponseWriter, _ *http.Request) {
+				labels.Add(1)
+				writeJSON(t, rw, []*github.Label{})
+			})
+
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			markers := conf.Markers{NeedHumanMerge: "bot/need-human-merge"}
+			extra := conf.Extra{RepoConfigFile: test.configFile}
+
+			repo := New(ghapi.New(client), "traefik/traefik", "", markers, conf.Retry{}, conf.Timeouts{}, conf.Git{}, base, extra, nil, nil, nil)
+
+			pr := &github.PullRequest{
+				Number: github.Ptr(1),
+				Base:   &github.PullRequestBranch{Ref: github.Ptr("main")},
+				Head:   &github.PullRequestBranch{Ref: github.Ptr("feature")},
+			}
+
+			err := repo.pipeline.applyConfigFile(t.Context(), pr)
+
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expected, repo.config)
+			assert.Equal(t, test.expected, repo.pipeline.config)
+
+			if test.reported {
+				assert.Equal(t, int32(1), comments.Load())
+				assert.Equal(t, int32(1), labels.Load())
+			} else {
+				assert.Zero(t, comments.Load())
+				assert.Zero(t, labels.Load())
+			}
+		})
+	}
+}
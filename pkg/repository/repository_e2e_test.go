@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi/ghapitest"
+)
+
+const forkRepoName = "ldez/traefik"
+
+// newE2EGitHub creates a fake GitHub, served over HTTP, with a repository and a fork backed by local bare repositories.
+func newE2EGitHub(t *testing.T) (*ghapitest.Fake, *ghapi.Client) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	fake := ghapitest.New()
+
+	fake.AddRepository(&github.Repository{
+		Owner:  &github.User{Login: github.Ptr("traefik")},
+		Name:   github.Ptr("traefik"),
+		GitURL: github.Ptr("file://" + filepath.Join(dir, "traefik.git")),
+	})
+
+	fake.AddFork(fakeRepoName, &github.Repository{
+		Owner:  &github.User{Login: github.Ptr("ldez")},
+		Name:   github.Ptr("traefik"),
+		GitURL: github.Ptr("file://" + filepath.Join(dir, "ldez.git")),
+	})
+
+	server := ghapitest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, ghapi.New(ghapitest.NewClient(server))
+}
+
+func TestRepository_Process_e2e(t *testing.T) {
+	testCases := []struct {
+		desc string
+		// setup creates the pull request #1, and returns its head repository.
+		setup func(t *testing.T, fake *ghapitest.Fake) string
+		// runs the number of runs of the bot.
+		runs int
+
+		expectedReason string
+		expectedLabels []string
+		expectedBase   []string
+		expectedHead   []string
+	}{
+		{
+			desc: "rebase a branch of a fork",
+			setup: func(_ *testing.T, fake *ghapitest.Fake) string {
+				fake.AddBranch(forkRepoName, "feature", "Fix the bug")
+				fake.AddBranch(fakeRepoName, "main", "Another change")
+
+				addE2EPullRequest(fake, forkRepoName, true, fakeMarkers.NeedMerge)
+
+				return forkRepoName
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedBase:   []string{"Another change", "Initial commit"},
+			expectedHead:   []string{"Fix the bug", "Another change", "Initial commit"},
+		},
+		{
+			desc: "rebase a branch of the main repository",
+			setup: func(_ *testing.T, fake *ghapitest.Fake) string {
+				fake.AddBranch(fakeRepoName, "feature", "Fix the bug")
+				fake.AddBranch(fakeRepoName, "main", "Another change")
+
+				addE2EPullRequest(fake, fakeRepoName, false, fakeMarkers.NeedMerge)
+
+				return fakeRepoName
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedBase:   []string{"Another change", "Initial commit"},
+			expectedHead:   []string{"Fix the bug", "Another change", "Initial commit"},
+		},
+		{
+			desc: "merge the base branch into a branch with merges",
+			setup: func(t *testing.T, fake *ghapitest.Fake) string {
+				t.Helper()
+
+				fake.AddBranch(forkRepoName, "feature", "Fix the bug")
+				fake.AddBranch(fakeRepoName, "main", "Another change")
+
+				addE2EPullRequest(fake, forkRepoName, true, fakeMarkers.NeedMerge)
+
+				// the branch already contains a merge.
+				_, _, err := fake.Client().PullRequests.UpdateBranch(t.Context(), "traefik", "traefik", 1, nil)
+				require.ErrorAs(t, err, new(*github.AcceptedError))
+
+				fake.AddBranch(fakeRepoName, "main", "Yet another change")
+
+				return forkRepoName
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedBase:   []string{"Yet another change", "Another change", "Initial commit"},
+			expectedHead: []string{
+				"Merge remote-tracking branch 'upstream/main' into feature",
+				"Yet another change",
+				"Merge branch 'main' into feature",
+				"Another change",
+				"Fix the bug",
+				"Initial commit",
+			},
+		},
+		{
+			desc: "update with the API",
+			setup: func(_ *testing.T, fake *ghapitest.Fake) string {
+				fake.AddBranch(forkRepoName, "feature", "Fix the bug")
+				fake.AddBranch(fakeRepoName, "main", "Another change")
+
+				addE2EPullRequest(fake, forkRepoName, false, fakeMarkers.NeedMerge)
+
+				return forkRepoName
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedBase:   []string{"Another change", "Initial commit"},
+			expectedHead:   []string{"Merge branch 'main' into feature", "Another change", "Fix the bug", "Initial commit"},
+		},
+		{
+			desc: "rebase conflict",
+			setup: func(_ *testing.T, fake *ghapitest.Fake) string {
+				fake.AddFile(forkRepoName, "feature", "README.md", "fork", "Fix the bug")
+				fake.AddFile(fakeRepoName, "main", "README.md", "main", "Another change")
+
+				addE2EPullRequest(fake, forkRepoName, true, fakeMarkers.NeedMerge)
+
+				return forkRepoName
+			},
+			expectedReason: ReasonUpdate,
+			// the merge in progress label added by the update is removed: the labels are refreshed by the responses of the API.
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			expectedBase:   []string{"Another change", "Initial commit"},
+			expectedHead:   []string{"Fix the bug", "Initial commit"},
+		},
+		{
+			desc: "fast-forward",
+			setup: func(_ *testing.T, fake *ghapitest.Fake) string {
+				fake.AddBranch(forkRepoName, "feature", "Fix the bug")
+
+				addE2EPullRequest(fake, forkRepoName, true, fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix+conf.MergeMethodFastForward)
+
+				return forkRepoName
+			},
+			expectedBase: []string{"Fix the bug", "Initial commit"},
+			expectedHead: []string{"Fix the bug", "Initial commit"},
+		},
+		{
+			desc: "rebase then squash",
+			setup: func(_ *testing.T, fake *ghapitest.Fake) string {
+				fake.AddBranch(forkRepoName, "feature", "Fix the bug")
+				fake.AddBranch(forkRepoName, "feature", "Fix the tests")
+				fake.AddBranch(fakeRepoName, "main", "Another change")
+
+				addE2EPullRequest(fake, forkRepoName, true, fakeMarkers.NeedMerge)
+
+				return forkRepoName
+			},
+			runs:         2,
+			expectedBase: []string{"Fix the bug in the router", "Another change", "Initial commit"},
+			expectedHead: []string{"Fix the tests", "Fix the bug", "Another change", "Initial commit"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake, client := newE2EGitHub(t)
+
+			headRepo := test.setup(t, fake)
+
+			config := conf.RepoConfig{
+				MergeMethod:       conf.String(conf.MergeMethodSquash),
+				MinReview:         conf.Int(1),
+				ForceNeedUpToDate: conf.Bool(true),
+			}
+
+			gitConfig := conf.Git{UserName: "lobicornis", Email: "lobicornis@example.com"}
+
+			repo := New(client, fakeRepoName, "", fakeMarkers, conf.Retry{}, conf.Timeouts{}, gitConfig, config, conf.Extra{}, nil, nil, nil)
+
+			var err error
+			for range max(test.runs, 1) {
+				err = repo.Process(t.Context(), 1)
+			}
+
+			if test.expectedReason != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedReason, GetReason(err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedLabels, fake.Labels(fakeRepoName, 1))
+			assert.Equal(t, test.expectedBase, fake.Log(fakeRepoName, "main"))
+			assert.Equal(t, test.expectedHead, fake.Log(headRepo, "feature"))
+		})
+	}
+}
+
+// addE2EPullRequest adds the pull request #1 from the branch "feature" of a repository.
+func addE2EPullRequest(fake *ghapitest.Fake, headRepo string, maintainerCanModify bool, labelNames ...string) {
+	fake.AddPullRequest(fakeRepoName, &github.PullRequest{
+		Title:               github.Ptr("Fix the bug in the router"),
+		Labels:              labels(labelNames...),
+		MaintainerCanModify: github.Ptr(maintainerCanModify),
+		Head: &github.PullRequestBranch{
+			Ref:  github.Ptr("feature"),
+			Repo: &github.Repository{FullName: github.Ptr(headRepo)},
+		},
+	})
+
+	fake.AddReview(fakeRepoName, 1, "ldez", Approved)
+}
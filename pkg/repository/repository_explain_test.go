@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+func TestRepository_Explain(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		labels         []string
+		milestone      *github.Milestone
+		reviews        []*github.PullRequestReview
+		checkState     string
+		mergeable      bool
+		behindBy       int
+		expectedFailed []string
+		expectedAction string
+	}{
+		{
+			desc:           "ready to merge",
+			labels:         []string{"status/3-needs-merge"},
+			milestone:      &github.Milestone{Title: github.Ptr("v1.0")},
+			reviews:        []*github.PullRequestReview{{User: &github.User{Login: github.Ptr("foo")}, State: github.Ptr(Approved)}},
+			checkState:     Success,
+			mergeable:      true,
+			expectedAction: NextMerge,
+		},
+		{
+			desc:           "behind",
+			labels:         []string{"status/3-needs-merge"},
+			milestone:      &github.Milestone{Title: github.Ptr("v1.0")},
+			reviews:        []*github.PullRequestReview{{User: &github.User{Login: github.Ptr("foo")}, State: github.Ptr(Approved)}},
+			checkState:     Success,
+			mergeable:      true,
+			behindBy:       2,
+			expectedFailed: []string{GateUpToDate},
+			expectedAction: NextUpdate,
+		},
+		{
+			desc:           "CI pending",
+			labels:         []string{"status/3-needs-merge"},
+			milestone:      &github.Milestone{Title: github.Ptr("v1.0")},
+			reviews:        []*github.PullRequestReview{{User: &github.User{Login: github.Ptr("foo")}, State: github.Ptr(Approved)}},
+			checkState:     Pending,
+			mergeable:      true,
+			expectedFailed: []string{GateChecks},
+			expectedAction: NextWaitCI,
+		},
+		{
+			desc:           "all the gates are evaluated",
+			checkState:     "failure",
+			behindBy:       1,
+			expectedFailed: []string{GateLabels, GateMilestone, GateReviews, GateChecks, GateMergeable, GateUpToDate},
+			expectedAction: NextNone,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+
+			mux.HandleFunc("GET /repos/traefik/traefik/pulls/1", func(rw http.ResponseWriter, _ *http.Request) {
+				var labels []*github.Label
+				for _, name := range test.labels {
+					labels = append(labels, &github.Label{Name: github.Ptr(name)})
+				}
+
+				writeJSON(t, rw, &github.PullRequest{
+					Number:         github.Ptr(1),
+					Title:          github.Ptr("Fix the bug"),
+					State:          github.Ptr(StateOpen),
+					Labels:         labels,
+					Milestone:      test.milestone,
+					Mergeable:      github.Ptr(test.mergeable),
+					MergeableState: github.Ptr(MergeableStateClean),
+					Head:           &github.PullRequestBranch{SHA: github.Ptr("abc"), Ref: github.Ptr("fix"), User: &github.User{Login: github.Ptr("bar")}},
+					Base:           &github.PullRequestBranch{Ref: github.Ptr("master")},
+				})
+			})
+
+			mux.HandleFunc("GET /repos/traefik/traefik/pulls/1/reviews", func(rw http.ResponseWriter, _ *http.Request) {
+				writeJSON(t, rw, test.reviews)
+			})
+
+			mux.HandleFunc("GET /repos/traefik/traefik/commits/abc/check-runs", func(rw http.ResponseWriter, _ *http.Request) {
+				writeJSON(t, rw, &github.ListCheckRunsResults{})
+			})
+
+			mux.HandleFunc("GET /repos/traefik/traefik/commits/abc/check-suites", func(rw http.ResponseWriter, _ *http.Request) {
+				writeJSON(t, rw, &github.ListCheckSuiteResults{})
+			})
+
+			mux.HandleFunc("GET /repos/traefik/traefik/commits/abc/status", func(rw http.ResponseWriter, _ *http.Request) {
+				writeJSON(t, rw, &github.CombinedStatus{Statuses: []*github.RepoStatus{{Context: github.Ptr("ci"), State: github.Ptr(test.checkState)}}})
+			})
+
+			mux.HandleFunc("GET /repos/traefik/traefik/compare/master...bar:fix", func(rw http.ResponseWriter, _ *http.Request) {
+				writeJSON(t, rw, &github.CommitsComparison{BehindBy: github.Ptr(test.behindBy)})
+			})
+
+			// nothing must be mutated.
+			mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+				t.Errorf("unexpected request: %s %s", req.Method, req.URL.Path)
+				http.NotFound(rw, req)
+			})
+
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			config := conf.RepoConfig{
+				MergeMethod:       conf.String(conf.MergeMethodSquash),
+				MinReview:         conf.Int(1),
+				NeedMilestone:     conf.Bool(true),
+				ForceNeedUpToDate: conf.Bool(true),
+			}
+
+			markers := conf.Markers{
+				NeedMerge:         "status/3-needs-merge",
+				NeedHumanMerge:    "bot/need-human-merge",
+				NoMerge:           "bot/no-merge",
+				MergeMethodPrefix: "bot/merge-method-",
+			}
+
+			repo := New(ghapi.New(client), "traefik/traefik", "", markers, conf.Retry{}, conf.Timeouts{}, conf.Git{}, config, conf.Extra{}, nil, nil, nil)
+
+			report, err := repo.Explain(t.Context(), 1)
+			require.NoError(t, err)
+
+			assert.Equal(t, "traefik/traefik", report.Repository)
+			assert.Equal(t, 1, report.Number)
+			assert.Equal(t, "Fix the bug", report.Title)
+
+			var names, failed []string
+			for _, gate := range report.Gates {
+				names = append(names, gate.Name)
+
+				if !gate.Passed {
+					failed = append(failed, gate.Name)
+				}
+			}
+
+			assert.Equal(t, []string{GateConfig, GateLabels, GateMilestone, GateReviews, GateChecks, GateMergeable, GateMergeMethod, GateUpToDate}, names)
+			assert.Equal(t, test.expectedFailed, failed)
+			assert.Equal(t, test.expectedAction, report.Action)
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi/ghapitest"
+)
+
+const fakeRepoName = "traefik/traefik"
+
+var fakeMarkers = conf.Markers{
+	LightReview:       "bot/light-review",
+	NeedMerge:         "status/3-needs-merge",
+	MergeInProgress:   "status/4-merge-in-progress",
+	MergeMethodPrefix: "bot/merge-method-",
+	MergeRetryPrefix:  "bot/merge-retry-",
+	NeedHumanMerge:    "bot/need-human-merge",
+	NoMerge:           "bot/no-merge",
+	MergeNoRebase:     "bot/no-rebase",
+}
+
+func newFakeGitHub() *ghapitest.Fake {
+	fake := ghapitest.New()
+
+	fake.AddRepository(&github.Repository{
+		Owner: &github.User{Login: github.Ptr("traefik")},
+		Name:  github.Ptr("traefik"),
+	})
+
+	return fake
+}
+
+func labels(names ...string) []*github.Label {
+	var result []*github.Label
+	for _, name := range names {
+		result = append(result, &github.Label{Name: github.Ptr(name)})
+	}
+
+	return result
+}
+
+func successfulRun(name string) *github.CheckRun {
+	return &github.CheckRun{Name: github.Ptr(name), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)}
+}
+
+func TestRepository_Process(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config conf.RepoConfig
+		retry  conf.Retry
+		number int
+		setup  func(fake *ghapitest.Fake)
+
+		expectedReason string
+		expectedLabels []string
+		expectedMerges []ghapitest.Merge
+		expectedUpdate bool
+		assert         func(t *testing.T, fake *ghapitest.Fake)
+	}{
+		{
+			desc: "merge",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Title: github.Ptr("Fix"), Labels: labels(fakeMarkers.NeedMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+				fake.AddCheckRun(fakeRepoName, fake.PullRequest(fakeRepoName, number).GetHead().GetSHA(), successfulRun("test"))
+			},
+			expectedMerges: []ghapitest.Merge{{Number: 1, Method: conf.MergeMethodSquash, Title: "Fix", Message: "\n"}},
+		},
+		{
+			desc: "merge method label",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Title: github.Ptr("Fix"), Labels: labels(fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix+conf.MergeMethodMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+			},
+			expectedMerges: []ghapitest.Merge{{Number: 1, Method: conf.MergeMethodMerge, Title: "Fix"}},
+		},
+		{
+			desc: "missing reviews",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+				fake.AddReview(fakeRepoName, number, "juliens", Commented)
+			},
+			config:         conf.RepoConfig{MinReview: conf.Int(2)},
+			expectedReason: ReasonReview,
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+			assert: func(t *testing.T, fake *ghapitest.Fake) {
+				t.Helper()
+
+				assert.Equal(t, []string{":no_entry_sign: error related to review: need more review [1/2]"}, fake.Comments(fakeRepoName, 1))
+			},
+		},
+		{
+			desc: "pending checks",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+				fake.AddCheckRun(fakeRepoName, fake.PullRequest(fakeRepoName, number).GetHead().GetSHA(), &github.CheckRun{Name: github.Ptr("test"), Status: github.Ptr(InProgress)})
+			},
+			expectedLabels: []string{fakeMarkers.NeedMerge},
+		},
+		{
+			desc: "failed checks with retry",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+				fake.AddCheckRun(fakeRepoName, fake.PullRequest(fakeRepoName, number).GetHead().GetSHA(), &github.CheckRun{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")})
+			},
+			retry:          conf.Retry{Number: 2, OnStatuses: true},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeRetryPrefix + "1", fakeMarkers.MergeInProgress},
+		},
+		{
+			desc: "failed checks without retry",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+				fake.AddStatus(fakeRepoName, fake.PullRequest(fakeRepoName, number).GetHead().GetSHA(), &github.RepoStatus{Context: github.Ptr("ci"), State: github.Ptr("failure")})
+			},
+			expectedReason: ReasonChecks,
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+		},
+		{
+			desc: "update with the API",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{
+					Labels: labels(fakeMarkers.NeedMerge),
+					Head: &github.PullRequestBranch{
+						Ref:  github.Ptr("feature"),
+						User: &github.User{Login: github.Ptr("ldez")},
+						Repo: &github.Repository{GitURL: github.Ptr("git://github.com/ldez/traefik.git")},
+					},
+				})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+
+				// the base branch moves.
+				fake.AddBranch(fakeRepoName, "main", "Another pull request")
+			},
+			config:         conf.RepoConfig{ForceNeedUpToDate: conf.Bool(true)},
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress},
+			expectedUpdate: true,
+		},
+		{
+			desc: "fast-forward on a branch not up-to-date",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix+conf.MergeMethodFastForward)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+
+				fake.AddBranch(fakeRepoName, "main", "Another pull request")
+			},
+			expectedReason: ReasonMergeMethod,
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodFastForward, fakeMarkers.NeedHumanMerge},
+		},
+		{
+			desc: "merge failure",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(fakeMarkers.NeedMerge)})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+
+				fake.SetError("PullRequests.Merge", errors.New("boom"))
+			},
+			expectedReason: ReasonMerge,
+			expectedLabels: []string{fakeMarkers.NeedMerge, fakeMarkers.NeedHumanMerge},
+		},
+		{
+			desc: "already merged",
+			setup: func(fake *ghapitest.Fake) {
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Merged: github.Ptr(true), Labels: labels(fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress, "kind/bug")})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+			},
+			expectedLabels: []string{"kind/bug"},
+		},
+		{
+			desc:   "close the related issues",
+			number: 11,
+			setup: func(fake *ghapitest.Fake) {
+				fake.AddBranch(fakeRepoName, "v2.0", "Release")
+				fake.AddIssue(fakeRepoName, &github.Issue{Number: github.Ptr(10)})
+
+				number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{
+					Number: github.Ptr(11),
+					Body:   github.Ptr("Fixes #10"),
+					Labels: labels(fakeMarkers.NeedMerge),
+					Base:   &github.PullRequestBranch{Ref: github.Ptr("v2.0")},
+				})
+				fake.AddReview(fakeRepoName, number, "ldez", Approved)
+			},
+			expectedMerges: []ghapitest.Merge{{Number: 11, Method: conf.MergeMethodSquash, Message: "\n"}},
+			assert: func(t *testing.T, fake *ghapitest.Fake) {
+				t.Helper()
+
+				assert.Equal(t, "closed", fake.Issue(fakeRepoName, 10).GetState())
+				assert.Equal(t, []string{"Closed by #11."}, fake.Comments(fakeRepoName, 10))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitHub()
+			test.setup(fake)
+
+			config := test.config
+			if config.MergeMethod == nil {
+				config.MergeMethod = conf.String(conf.MergeMethodSquash)
+			}
+
+			if config.MinReview == nil {
+				config.MinReview = conf.Int(1)
+			}
+
+			config.AddErrorInComment = conf.Bool(true)
+
+			repo := New(fake.Client(), fakeRepoName, "", fakeMarkers, test.retry, conf.Timeouts{}, conf.Git{}, config, conf.Extra{}, nil, nil, nil)
+
+			number := cmp.Or(test.number, 1)
+
+			err := repo.Process(t.Context(), number)
+
+			if test.expectedReason != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedReason, GetReason(err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			merges := fake.Merges(fakeRepoName)
+			for i := range merges {
+				merges[i].SHA = ""
+			}
+
+			assert.Equal(t, test.expectedMerges, merges)
+
+			if test.expectedMerges != nil {
+				pr := fake.PullRequest(fakeRepoName, number)
+				assert.True(t, pr.GetMerged())
+				assert.Equal(t, pr.GetMergeCommitSHA(), fake.Branch(fakeRepoName, pr.GetBase().GetRef()))
+			}
+
+			assert.Equal(t, test.expectedLabels, fake.Labels(fakeRepoName, number))
+
+			if test.expectedUpdate {
+				assert.Equal(t, []int{number}, fake.Updates(fakeRepoName))
+			} else {
+				assert.Empty(t, fake.Updates(fakeRepoName))
+			}
+
+			if test.assert != nil {
+				test.assert(t, fake)
+			}
+		})
+	}
+}
+
+func TestRepository_getStatus_fake(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   conf.RepoConfig
+		setup    func(fake *ghapitest.Fake, sha string)
+		expected string
+	}{
+		{
+			desc:     "no checks",
+			setup:    func(_ *ghapitest.Fake, _ string) {},
+			expected: Success,
+		},
+		{
+			desc: "check runs and statuses",
+			setup: func(fake *ghapitest.Fake, sha string) {
+				fake.AddCheckRun(fakeRepoName, sha, successfulRun("test"))
+				fake.AddStatus(fakeRepoName, sha, &github.RepoStatus{Context: github.Ptr("ci/semaphore"), State: github.Ptr(Success)})
+			},
+			expected: Success,
+		},
+		{
+			desc: "latest status of a context",
+			setup: func(fake *ghapitest.Fake, sha string) {
+				fake.AddStatus(fakeRepoName, sha, &github.RepoStatus{Context: github.Ptr("ci/semaphore"), State: github.Ptr("failure")})
+				fake.AddStatus(fakeRepoName, sha, &github.RepoStatus{Context: github.Ptr("ci/semaphore"), State: github.Ptr(Pending)})
+			},
+			expected: Pending,
+		},
+		{
+			desc: "failed check run",
+			setup: func(fake *ghapitest.Fake, sha string) {
+				fake.AddCheckRun(fakeRepoName, sha, successfulRun("lint"))
+				fake.AddCheckRun(fakeRepoName, sha, &github.CheckRun{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")})
+			},
+			expected: "failure",
+		},
+		{
+			desc:   "ignored failed check run",
+			config: conf.RepoConfig{IgnoredChecks: []string{"test"}},
+			setup: func(fake *ghapitest.Fake, sha string) {
+				fake.AddCheckRun(fakeRepoName, sha, successfulRun("lint"))
+				fake.AddCheckRun(fakeRepoName, sha, &github.CheckRun{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")})
+			},
+			expected: Success,
+		},
+		{
+			desc:   "missing check required by the branch protection",
+			config: conf.RepoConfig{UseProtectionChecks: conf.Bool(true)},
+			setup: func(fake *ghapitest.Fake, sha string) {
+				fake.SetRequiredStatusChecks(fakeRepoName, "main", "test")
+				fake.AddCheckRun(fakeRepoName, sha, successfulRun("lint"))
+			},
+			expected: Pending,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitHub()
+			number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{})
+
+			pr := fake.PullRequest(fakeRepoName, number)
+
+			test.setup(fake, pr.GetHead().GetSHA())
+
+			repo := New(fake.Client(), fakeRepoName, "", fakeMarkers, conf.Retry{}, conf.Timeouts{}, conf.Git{}, test.config, conf.Extra{}, nil, nil, nil)
+
+			state, err := repo.getStatus(t.Context(), pr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, state.state)
+		})
+	}
+}
+
+func TestRepository_CheckReviews(t *testing.T) {
+	type review struct {
+		login string
+		state string
+	}
+
+	testCases := []struct {
+		desc     string
+		labels   []string
+		reviews  []review
+		errorMsg string
+	}{
+		{
+			desc:    "approved",
+			reviews: []review{{login: "ldez", state: Approved}, {login: "juliens", state: Approved}},
+		},
+		{
+			desc:     "missing review",
+			reviews:  []review{{login: "ldez", state: Approved}, {login: "juliens", state: Commented}},
+			errorMsg: "need more review [1/2]",
+		},
+		{
+			desc:     "dismissed review",
+			reviews:  []review{{login: "ldez", state: Approved}, {login: "juliens", state: Approved}, {login: "juliens", state: Dismissed}},
+			errorMsg: "need more review [1/2]",
+		},
+		{
+			desc:     "changes requested",
+			reviews:  []review{{login: "ldez", state: Approved}, {login: "juliens", state: "CHANGES_REQUESTED"}},
+			errorMsg: "CHANGES_REQUESTED by juliens",
+		},
+		{
+			desc:    "changes requested then approved",
+			reviews: []review{{login: "ldez", state: Approved}, {login: "juliens", state: "CHANGES_REQUESTED"}, {login: "juliens", state: Approved}},
+		},
+		{
+			desc:    "light review",
+			labels:  []string{fakeMarkers.LightReview},
+			reviews: []review{{login: "ldez", state: Approved}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeGitHub()
+			number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{Labels: labels(test.labels...)})
+
+			for _, r := range test.reviews {
+				fake.AddReview(fakeRepoName, number, r.login, r.state)
+			}
+
+			config := conf.RepoConfig{MinReview: conf.Int(2), MinLightReview: conf.Int(1)}
+
+			repo := New(fake.Client(), fakeRepoName, "", fakeMarkers, conf.Retry{}, conf.Timeouts{}, conf.Git{}, config, conf.Extra{}, nil, nil, nil)
+
+			pr := fake.PullRequest(fakeRepoName, number)
+
+			err := repo.CheckReviews(t.Context(), pr, repo.pipeline.getMinReview(repo.Describe(pr)))
+
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
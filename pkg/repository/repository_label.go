@@ -4,78 +4,71 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"strings"
+	"slices"
+	"time"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/rs/zerolog/log"
 )
 
-// removeLabels remove some labels on an issue (PR).
-func (r *Repository) removeLabels(ctx context.Context, pr numbered, labelsToRemove []string) error {
-	freshIssue, _, err := r.client.Issues.Get(ctx, r.owner, r.name, pr.GetNumber())
+// AddLabels adds some labels on a pull request.
+// The labels of the pull request are refreshed by the response.
+func (r *Repository) AddLabels(ctx context.Context, pr *github.PullRequest, labels ...string) error {
+	updated, resp, err := r.client.Issues.AddLabelsToIssue(ctx, r.owner, r.name, pr.GetNumber(), labels)
 	if err != nil {
 		return err
 	}
 
-	var newLabels []string
-	for _, lbl := range freshIssue.Labels {
-		if !contains(labelsToRemove, lbl.GetName()) {
-			newLabels = append(newLabels, lbl.GetName())
-		}
-	}
-
-	if len(freshIssue.Labels) == len(newLabels) {
-		return nil
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to add labels %v. Status code: %d", labels, resp.StatusCode)
 	}
 
-	if newLabels == nil {
-		// Due to go-github/GitHub API constraint
-		newLabels = []string{}
-	}
+	pr.Labels = updated
 
-	_, _, err = r.client.Issues.ReplaceLabelsForIssue(ctx, r.owner, r.name, pr.GetNumber(), newLabels)
-	return err
+	return nil
 }
 
-// removeLabel remove a label on an issue (PR).
-func (r *Repository) removeLabel(ctx context.Context, pr *github.PullRequest, label string) error {
-	if !hasLabel(pr, label) {
-		return nil
-	}
+// RemoveLabels removes some labels of a pull request.
+// Several labels are removed by replacing the labels of the pull request: only one call to the API.
+func (r *Repository) RemoveLabels(ctx context.Context, pr *github.PullRequest, labels ...string) error {
+	if len(labels) == 1 {
+		resp, err := r.client.Issues.RemoveLabelForIssue(ctx, r.owner, r.name, pr.GetNumber(), labels[0])
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to remove label %s. Status code: %d", labels[0], resp.StatusCode)
+		}
+
+		pr.Labels = slices.DeleteFunc(pr.Labels, func(lbl *github.Label) bool { return lbl.GetName() == labels[0] })
 
-	log.Ctx(ctx).Debug().Msgf("Remove label: %s. Dry run: %v", label, r.dryRun)
-	if r.dryRun {
 		return nil
 	}
 
-	resp, err := r.client.Issues.RemoveLabelForIssue(ctx, r.owner, r.name, pr.GetNumber(), label)
+	freshIssue, _, err := r.client.Issues.Get(ctx, r.owner, r.name, pr.GetNumber())
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to remove label %s. Status code: %d", label, resp.StatusCode)
+	// Due to go-github/GitHub API constraint
+	newLabels := []string{}
+	for _, lbl := range freshIssue.Labels {
+		if !slices.Contains(labels, lbl.GetName()) {
+			newLabels = append(newLabels, lbl.GetName())
+		}
 	}
 
-	return nil
-}
-
-// addLabels add some labels on an issue (PR).
-func (r *Repository) addLabels(ctx context.Context, pr numbered, labels ...string) error {
-	log.Ctx(ctx).Debug().Msgf("Add labels: %s. Dry run: %v", labels, r.dryRun)
-
-	if r.dryRun {
+	if len(freshIssue.Labels) == len(newLabels) {
 		return nil
 	}
 
-	_, resp, err := r.client.Issues.AddLabelsToIssue(ctx, r.owner, r.name, pr.GetNumber(), labels)
+	updated, _, err := r.client.Issues.ReplaceLabelsForIssue(ctx, r.owner, r.name, pr.GetNumber(), newLabels)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to add labels %v. Status code: %d", labels, resp.StatusCode)
-	}
+	pr.Labels = updated
 
 	return nil
 }
@@ -91,22 +84,48 @@ func hasLabel(pr *github.PullRequest, label string) bool {
 	return false
 }
 
-// findLabelNameWithPrefix Find an issue with a specific label prefix.
-func findLabelNameWithPrefix(labels []*github.Label, prefix string) string {
-	for _, lbl := range labels {
-		if strings.HasPrefix(lbl.GetName(), prefix) {
-			return lbl.GetName()
-		}
+// observeMergeTime observes the time between the addition of the need-merge label and the merge.
+func (r *Repository) observeMergeTime(ctx context.Context, pr numbered) {
+	if r.metrics == nil {
+		return
+	}
+
+	labeledAt, err := r.getLabeledAt(ctx, pr, r.markers.NeedMerge)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Unable to get the date of the need-merge label.")
+		return
 	}
 
-	return ""
+	if labeledAt.IsZero() {
+		return
+	}
+
+	r.metrics.MergeTime(r.fullName(), time.Since(labeledAt))
 }
 
-func contains(values []string, value string) bool {
-	for _, val := range values {
-		if value == val {
-			return true
+// getLabeledAt gets the last time a label was added to an issue (PR).
+// Returns a zero time if the label was never added.
+func (r *Repository) getLabeledAt(ctx context.Context, pr numbered, label string) (time.Time, error) {
+	var labeledAt time.Time
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		events, resp, err := r.client.Issues.ListIssueEvents(ctx, r.owner, r.name, pr.GetNumber(), opts)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to list issue events: %w", err)
 		}
+
+		for _, event := range events {
+			if event.GetEvent() == "labeled" && event.GetLabel().GetName() == label {
+				labeledAt = event.GetCreatedAt().Time
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return labeledAt, nil
+		}
+
+		opts.Page = resp.NextPage
 	}
-	return false
 }
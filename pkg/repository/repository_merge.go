@@ -4,17 +4,21 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
 	"github.com/ldez/go-git-cmd-wrapper/v2/merge"
 	"github.com/ldez/go-git-cmd-wrapper/v2/push"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
 )
 
 // Remote name.
@@ -30,103 +34,89 @@ type Result struct {
 }
 
 func (r *Repository) getMergeMethod(pr *github.PullRequest) (string, error) {
-	if r.markers.MergeMethodPrefix == "" {
-		return r.config.GetMergeMethod(), nil
-	}
-
 	var labels []string
 	for _, lbl := range pr.Labels {
-		if strings.HasPrefix(lbl.GetName(), r.markers.MergeMethodPrefix) {
-			labels = append(labels, lbl.GetName())
+		labels = append(labels, lbl.GetName())
+	}
+
+	return getMergeMethod(r.markers, r.config, labels)
+}
+
+// getMergeMethod gets the merge method of a pull request: the merge method of its label, or the merge method of the configuration.
+func getMergeMethod(markers conf.Markers, config conf.RepoConfig, labels []string) (string, error) {
+	if markers.MergeMethodPrefix == "" {
+		return config.GetMergeMethod(), nil
+	}
+
+	var methodLabels []string
+	for _, lbl := range labels {
+		if strings.HasPrefix(lbl, markers.MergeMethodPrefix) {
+			methodLabels = append(methodLabels, lbl)
 		}
 	}
 
-	if len(labels) == 0 {
-		return r.config.GetMergeMethod(), nil
+	if len(methodLabels) == 0 {
+		return config.GetMergeMethod(), nil
 	}
 
-	if len(labels) > 1 {
-		return "", fmt.Errorf("too many custom merge method labels: %v", labels)
+	if len(methodLabels) > 1 {
+		return "", fmt.Errorf("too many custom merge method labels: %v", methodLabels)
 	}
 
-	switch labels[0] {
-	case r.markers.MergeMethodPrefix + conf.MergeMethodSquash:
-		return conf.MergeMethodSquash, nil
-	case r.markers.MergeMethodPrefix + conf.MergeMethodMerge:
-		return conf.MergeMethodMerge, nil
-	case r.markers.MergeMethodPrefix + conf.MergeMethodRebase:
-		return conf.MergeMethodRebase, nil
-	case r.markers.MergeMethodPrefix + conf.MergeMethodFastForward:
-		return conf.MergeMethodFastForward, nil
+	switch method := strings.TrimPrefix(methodLabels[0], markers.MergeMethodPrefix); method {
+	case conf.MergeMethodSquash, conf.MergeMethodMerge, conf.MergeMethodRebase, conf.MergeMethodFastForward:
+		return method, nil
 	default:
-		return r.config.GetMergeMethod(), nil
+		return config.GetMergeMethod(), nil
 	}
 }
 
-func (r *Repository) merge(ctx context.Context, pr *github.PullRequest, mergeMethod string) error {
+// MergeMethods gets the merge methods supported by GitHub.
+func (r *Repository) MergeMethods() []string {
+	return []string{conf.MergeMethodSquash, conf.MergeMethodMerge, conf.MergeMethodRebase, conf.MergeMethodFastForward}
+}
+
+// Merge merges a pull request, and closes its related issues.
+func (r *Repository) Merge(ctx context.Context, pr *github.PullRequest, mergeMethod string, message CommitMessage) error {
 	if !pr.GetMaintainerCanModify() && !isOnMainRepository(pr) && mergeMethod == conf.MergeMethodFastForward {
 		// note: it's not possible to edit a PR from an organization.
 		return fmt.Errorf("the use of the merge method [%s] is impossible when a branch from an organization "+
 			"or if the contributor doesn't allow maintainer modification (GitHub option)", mergeMethod)
 	}
 
-	log.Ctx(ctx).Info().Msgf("MERGE(%s)\n", mergeMethod)
-
-	err := r.removeLabel(ctx, pr, r.markers.MergeInProgress)
-	ignoreError(ctx, err)
-
-	if !r.dryRun {
-		var result Result
-		result, err = r.mergePullRequest(ctx, pr, mergeMethod)
-		ignoreError(ctx, err)
+	result, err := r.mergePullRequest(ctx, pr, mergeMethod, message)
+	IgnoreError(ctx, err)
 
-		log.Ctx(ctx).Info().Msg(result.Message)
+	log.Ctx(ctx).Info().Msg(result.Message)
 
-		if !result.Merged {
-			return fmt.Errorf("failed to merge PR: %s", result.Message)
-		}
-
-		labelsToRemove := []string{
-			r.markers.NeedMerge,
-			r.markers.LightReview,
-			r.markers.MergeMethodPrefix + conf.MergeMethodSquash,
-			r.markers.MergeMethodPrefix + conf.MergeMethodMerge,
-			r.markers.MergeMethodPrefix + conf.MergeMethodRebase,
-			r.markers.MergeMethodPrefix + conf.MergeMethodFastForward,
-			r.markers.MergeNoRebase,
-		}
-		err = r.removeLabels(ctx, pr, labelsToRemove)
-		ignoreError(ctx, err)
+	if !result.Merged {
+		return errors.New(result.Message)
 	}
 
+	r.observeMergeTime(ctx, pr)
+
 	err = r.mjolnir.CloseRelatedIssues(ctx, pr)
-	ignoreError(ctx, err)
+	IgnoreError(ctx, err)
 
 	return nil
 }
 
 // mergePullRequest Merge a Pull Request.
-func (r *Repository) mergePullRequest(ctx context.Context, pr *github.PullRequest, mergeMethod string) (Result, error) {
+func (r *Repository) mergePullRequest(ctx context.Context, pr *github.PullRequest, mergeMethod string, message CommitMessage) (Result, error) {
 	if mergeMethod == conf.MergeMethodFastForward {
 		return r.fastForward(ctx, pr)
 	}
 
-	return r.githubMerge(ctx, pr, mergeMethod)
+	return r.githubMerge(ctx, pr, mergeMethod, message)
 }
 
-func (r *Repository) githubMerge(ctx context.Context, pr *github.PullRequest, mergeMethod string) (Result, error) {
-	if r.dryRun {
-		return Result{Message: "Fake merge: dry run", Merged: true}, nil
-	}
-
+func (r *Repository) githubMerge(ctx context.Context, pr *github.PullRequest, mergeMethod string, message CommitMessage) (Result, error) {
 	options := &github.PullRequestOptions{
 		MergeMethod: mergeMethod,
 		CommitTitle: pr.GetTitle(),
 	}
 
-	message := r.getCommitMessage(mergeMethod, pr)
-
-	result, _, err := r.client.PullRequests.Merge(ctx, r.owner, r.name, pr.GetNumber(), message, options)
+	result, _, err := r.client.PullRequests.Merge(ctx, r.owner, r.name, pr.GetNumber(), r.getCommitDescription(mergeMethod, message), options)
 	if err != nil {
 		return Result{Message: err.Error(), Merged: false}, err
 	}
@@ -137,24 +127,18 @@ func (r *Repository) githubMerge(ctx context.Context, pr *github.PullRequest, me
 	}, nil
 }
 
-func (r *Repository) getCommitMessage(mergeMethod string, pr *github.PullRequest) string {
-	if mergeMethod != conf.MergeMethodSquash {
+// getCommitDescription gets the description of a squash commit: an empty description means the default description of GitHub.
+func (r *Repository) getCommitDescription(mergeMethod string, message CommitMessage) string {
+	if mergeMethod != conf.MergeMethodSquash || r.config.GetCommitMessage() == conf.CommitMessageGitHub {
 		return ""
 	}
 
-	switch r.config.GetCommitMessage() {
-	case "github":
-		return ""
-	case "description":
-		return pr.GetBody()
-	default:
-		message := strings.Join(getCoAuthors(pr), "\n")
-		if message == "" {
-			// force the description in the commit message to be empty.
-			message = "\n"
-		}
-		return message
+	if message.Body == "" {
+		// force the description in the commit message to be empty.
+		return "\n"
 	}
+
+	return message.Body
 }
 
 func (r *Repository) fastForward(ctx context.Context, pr *github.PullRequest) (Result, error) {
@@ -163,19 +147,12 @@ func (r *Repository) fastForward(ctx context.Context, pr *github.PullRequest) (R
 		return Result{Message: err.Error(), Merged: false}, err
 	}
 
-	defer func() { ignoreError(ctx, os.RemoveAll(dir)) }()
-
-	err = os.Chdir(dir)
-	if err != nil {
-		return Result{Message: err.Error(), Merged: false}, err
-	}
-
-	tempDir, _ := os.Getwd()
+	defer func() { IgnoreError(ctx, os.RemoveAll(dir)) }()
 
 	logger := log.Ctx(ctx)
-	logger.Info().Msg(tempDir)
+	logger.Info().Msg(dir)
 
-	output, err := r.clone.PullRequestForMerge(ctx, pr)
+	output, err := r.clone.PullRequestForMerge(ctx, dir, pr)
 	if err != nil {
 		logger.Error().Err(err).Msg(output)
 		return Result{Message: err.Error(), Merged: false}, err
@@ -188,17 +165,26 @@ func (r *Repository) fastForward(ctx context.Context, pr *github.PullRequest) (R
 
 	ref := fmt.Sprintf("%s/%s", remoteName, pr.Head.GetRef())
 
-	output, err = git.MergeWithContext(ctx, merge.FfOnly, merge.Commits(ref), git.Debugger(r.debug))
+	output, err = GitWithTimeout(ctx, r.timeouts.Rebase, func(ctx context.Context) (string, error) {
+		return git.MergeWithContext(ctx, global.UpperC(dir), merge.FfOnly, merge.Commits(ref), git.Debugger(r.debug))
+	})
 	if err != nil {
 		logger.Error().Err(err).Msg(output)
 		return Result{Message: err.Error(), Merged: false}, err
 	}
 
-	output, err = git.PushWithContext(ctx,
-		git.Cond(r.dryRun, push.DryRun),
-		push.Remote(RemoteOrigin),
-		push.RefSpec(pr.Base.GetRef()),
-		git.Debugger(r.debug))
+	start := time.Now()
+
+	output, err = GitWithTimeout(ctx, r.timeouts.Push, func(ctx context.Context) (string, error) {
+		return git.PushWithContext(ctx,
+			global.UpperC(dir),
+			push.Remote(RemoteOrigin),
+			push.RefSpec(pr.Base.GetRef()),
+			git.Debugger(r.debug))
+	})
+
+	r.metrics.GitDuration(metrics.OperationPush, time.Since(start))
+
 	if err != nil {
 		logger.Error().Err(err).Msg(output)
 		return Result{Message: err.Error(), Merged: false}, err
@@ -207,14 +193,14 @@ func (r *Repository) fastForward(ctx context.Context, pr *github.PullRequest) (R
 	return Result{Merged: true, Message: "Merged"}, nil
 }
 
-// getCoAuthors Extracts co-author from PR description.
+// getCoAuthors Extracts co-author from the description of a pull request.
 //
 //	Co-authored-by: login <email@email.com>
-func getCoAuthors(pr *github.PullRequest) []string {
+func getCoAuthors(body string) []string {
 	exp := regexp.MustCompile(`^(?i)Co-authored-by:\s+(.+)\s+<(.+)>$`)
 
 	var coAuthors []string
-	scanner := bufio.NewScanner(bytes.NewBufferString(pr.GetBody()))
+	scanner := bufio.NewScanner(bytes.NewBufferString(body))
 	for scanner.Scan() {
 		line := scanner.Text()
 		if exp.MatchString(line) {
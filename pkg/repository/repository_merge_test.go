@@ -73,11 +73,7 @@ Goat port-salut st. agur blue cheese camembert de normandie manchego.
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			pr := &github.PullRequest{
-				Body: github.Ptr(test.body),
-			}
-
-			coAuthors := getCoAuthors(pr)
+			coAuthors := getCoAuthors(test.body)
 
 			assert.Equal(t, test.expected, coAuthors)
 		})
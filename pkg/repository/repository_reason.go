@@ -0,0 +1,52 @@
+package repository
+
+import "errors"
+
+// Reasons of the pull requests sent to a human.
+const (
+	ReasonMilestone      = "milestone"
+	ReasonReview         = "review"
+	ReasonChecks         = "checks"
+	ReasonConflicts      = "conflicts"
+	ReasonMergeableState = "mergeable_state"
+	ReasonMergeMethod    = "merge_method"
+	ReasonUpdate         = "update"
+	ReasonMerge          = "merge"
+	ReasonConfig         = "config"
+	ReasonTimeout        = "timeout"
+	ReasonUnknown        = "unknown"
+)
+
+// reasonError an error with the reason why a pull request cannot be merged.
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+// WithReason adds a reason to an error.
+// Returns nil if the error is nil.
+func WithReason(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &reasonError{reason: reason, err: err}
+}
+
+// GetReason gets the reason of an error.
+func GetReason(err error) string {
+	var reasonErr *reasonError
+	if errors.As(err, &reasonErr) {
+		return reasonErr.reason
+	}
+
+	return ReasonUnknown
+}
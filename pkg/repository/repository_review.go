@@ -20,6 +20,8 @@ const (
 	Queued = "queued"
 	// Skipped Check state.
 	Skipped = "skipped"
+	// Completed Check state.
+	Completed = "completed"
 
 	// Approved Review state.
 	Approved = "APPROVED"
@@ -29,10 +31,8 @@ const (
 	Dismissed = "DISMISSED"
 )
 
-// hasReviewsApprove check if a PR have the required number of review.
-func (r *Repository) hasReviewsApprove(ctx context.Context, pr *github.PullRequest) error {
-	minReview := r.getMinReview(pr)
-
+// CheckReviews checks if a pull request has the required number of approvals.
+func (r *Repository) CheckReviews(ctx context.Context, pr *github.PullRequest, minReview int) error {
 	if minReview == 0 {
 		return nil
 	}
@@ -64,7 +64,7 @@ func (r *Repository) hasReviewsApprove(ctx context.Context, pr *github.PullReque
 	}
 
 	if len(reviewsState) < minReview {
-		return fmt.Errorf("need more review [%d/%d]", len(reviewsState), minReview)
+		return &missingReviewsError{approved: len(reviewsState), required: minReview}
 	}
 
 	for login, state := range reviewsState {
@@ -76,11 +76,12 @@ func (r *Repository) hasReviewsApprove(ctx context.Context, pr *github.PullReque
 	return nil
 }
 
-// getMinReview Get minimal number of review for an issue.
-func (r *Repository) getMinReview(pr *github.PullRequest) int {
-	if r.config.GetMinLightReview() != 0 && hasLabel(pr, r.markers.LightReview) {
-		return r.config.GetMinLightReview()
-	}
+// missingReviewsError the pull request doesn't have enough reviews.
+type missingReviewsError struct {
+	approved int
+	required int
+}
 
-	return r.config.GetMinReview()
+func (e *missingReviewsError) Error() string {
+	return fmt.Sprintf("need more review [%d/%d]", e.approved, e.required)
 }
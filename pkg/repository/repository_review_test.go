@@ -7,7 +7,7 @@ import (
 	"github.com/traefik/lobicornis/v3/pkg/conf"
 )
 
-func TestRepository_getMinReview(t *testing.T) {
+func TestForgeRepository_getMinReview(t *testing.T) {
 	testCases := []struct {
 		name              string
 		config            conf.RepoConfig
@@ -40,18 +40,16 @@ func TestRepository_getMinReview(t *testing.T) {
 		},
 	}
 
-	for i, test := range testCases {
+	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			repository := Repository{
+			repository := ForgeRepository[*fakePull]{
 				markers: test.markers,
 				config:  test.config,
 			}
 
-			pr := makePullRequestWithLabels(test.labels, i)
-
-			minReview := repository.getMinReview(pr)
+			minReview := repository.getMinReview(PullRequest{Labels: test.labels})
 
 			assert.Equal(t, test.expectedMinReview, minReview)
 		})
@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/rs/zerolog/log"
@@ -37,8 +41,8 @@ const (
 	MergeableStateDraft = "draft"
 )
 
-// isUpToDateBranch check if a PR is up to date.
-func (r *Repository) isUpToDateBranch(ctx context.Context, pr *github.PullRequest) (bool, error) {
+// IsUpToDate checks if the head branch of a pull request contains the head of its base branch.
+func (r *Repository) IsUpToDate(ctx context.Context, pr *github.PullRequest) (bool, error) {
 	head := fmt.Sprintf("%s:%s", pr.Head.User.GetLogin(), pr.Head.GetRef())
 
 	cc, _, err := r.client.Repositories.CompareCommits(ctx, r.owner, r.name, pr.Base.GetRef(), head, nil)
@@ -51,69 +55,357 @@ func (r *Repository) isUpToDateBranch(ctx context.Context, pr *github.PullReques
 	return cc.GetBehindBy() == 0, nil
 }
 
-// getAggregatedState provide checks status (status + checksSuite).
-func (r *Repository) getAggregatedState(ctx context.Context, pr *github.PullRequest) (string, error) {
-	status, err := r.getStatus(ctx, pr)
+// NeedUpdate checks if the branch protection of the base branch requires an up-to-date branch (strict status checks).
+func (r *Repository) NeedUpdate(ctx context.Context, pr *github.PullRequest) (bool, error) {
+	rcs, _, err := r.client.Repositories.GetRequiredStatusChecks(ctx, r.owner, r.name, pr.Base.GetRef())
 	if err != nil {
-		return "", err
+		return false, fmt.Errorf("unable to get status checks: %w", err)
+	}
+
+	return rcs.Strict, nil
+}
+
+// check the state of a check run, a check suite, or a commit status.
+type check struct {
+	name        string
+	state       string
+	description string
+}
+
+// aggregatedState the aggregated view of all the checks (check runs, check suites, and commit statuses) of a commit.
+type aggregatedState struct {
+	state string
+	// notSuccessful the failed and pending checks by name.
+	notSuccessful map[string]check
+	// ignored the names of the checks not taken into account.
+	ignored []string
+}
+
+// checksFilter selects the checks deciding the aggregated state.
+type checksFilter struct {
+	// required the names (or glob patterns) of the required checks, all the checks are required if empty.
+	required []string
+	// ignored the names (or glob patterns) of the ignored checks.
+	ignored []string
+	// own the context of the commit status published by the bot, never part of the aggregated state.
+	own string
+}
+
+func (f checksFilter) isIgnored(name string) bool {
+	if matchAny(f.ignored, name) {
+		return true
 	}
 
-	if status == Pending || status == Success || status == Skipped {
-		return status, nil
+	return len(f.required) > 0 && !matchAny(f.required, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
 	}
 
-	return "", fmt.Errorf("PR status: %s", status)
+	return false
 }
 
-// getStatus provide checks status (status).
-func (r *Repository) getStatus(ctx context.Context, pr *github.PullRequest) (string, error) {
-	prRef := pr.Head.GetSHA()
+// summary describes the non-successful checks.
+func (a aggregatedState) summary() string {
+	names := slices.Sorted(maps.Keys(a.notSuccessful))
+
+	var lines []string
+	for _, name := range names {
+		chk := a.notSuccessful[name]
+
+		line := fmt.Sprintf("%s (%s)", name, chk.state)
+		if chk.description != "" {
+			line += ": " + chk.description
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
 
-	checks, _, err := r.client.Checks.ListCheckRunsForRef(ctx, r.owner, r.name, prRef, nil)
+// GetChecksState gets the aggregated state of the checks of a pull request (check runs, check suites, and statuses).
+func (r *Repository) GetChecksState(ctx context.Context, pr *github.PullRequest) (string, error) {
+	state, err := r.getStatus(ctx, pr)
 	if err != nil {
 		return "", err
 	}
 
-	for _, checkRun := range checks.CheckRuns {
-		if checkRun.GetConclusion() != Success && checkRun.GetConclusion() != Neutral {
-			if checkRun.GetStatus() == InProgress || checkRun.GetStatus() == Queued {
-				return Pending, nil
-			}
+	r.metrics.CheckState(r.fullName(), state.state)
 
-			return checkRun.GetConclusion(), nil
-		}
+	logger := log.Ctx(ctx)
+
+	for _, name := range state.ignored {
+		logger.Debug().Str("check", name).Msg("Check ignored.")
 	}
 
-	// Github action check are not part of combined status.
-	sts, _, err := r.client.Repositories.GetCombinedStatus(ctx, r.owner, r.name, prRef, nil)
+	for name, chk := range state.notSuccessful {
+		logger.Debug().Str("check", name).Str("state", chk.state).Msg(chk.description)
+	}
+
+	if state.state == Pending || state.state == Success {
+		return state.state, nil
+	}
+
+	return "", fmt.Errorf("PR status: %s\n%s", state.state, state.summary())
+}
+
+// getStatus provide the aggregated view of the checks (check runs, check suites, and statuses).
+func (r *Repository) getStatus(ctx context.Context, pr *github.PullRequest) (aggregatedState, error) {
+	return r.getRefStatus(ctx, pr.Head.GetSHA(), pr.Base.GetRef())
+}
+
+// getRefStatus provide the aggregated view of the checks (check runs, check suites, and statuses) of a commit.
+func (r *Repository) getRefStatus(ctx context.Context, ref, baseRef string) (aggregatedState, error) {
+	checkRuns, err := r.listCheckRuns(ctx, ref)
 	if err != nil {
-		return "", err
+		return aggregatedState{}, fmt.Errorf("failed to list check runs: %w", err)
 	}
 
-	if sts.GetState() == Success {
-		return sts.GetState(), nil
+	checkSuites, err := r.listCheckSuites(ctx, ref)
+	if err != nil {
+		return aggregatedState{}, fmt.Errorf("failed to list check suites: %w", err)
 	}
 
-	// pending: if there are no statuses or a context is pending
-	// https://developer.github.com/v3/repos/statuses/#get-the-combined-status-for-a-specific-ref
-	if sts.GetState() == Pending {
-		if sts.GetTotalCount() == 0 {
-			return Success, nil
-		}
-		return sts.GetState(), nil
+	// GitHub action check are not part of combined status.
+	statuses, err := r.listStatuses(ctx, ref)
+	if err != nil {
+		return aggregatedState{}, fmt.Errorf("failed to list statuses: %w", err)
 	}
 
-	statuses, _, err := r.client.Repositories.ListStatuses(ctx, r.owner, r.name, prRef, nil)
+	filter, err := r.getChecksFilter(ctx, baseRef)
 	if err != nil {
-		return "", err
+		return aggregatedState{}, err
+	}
+
+	return aggregateChecks(checkRuns, checkSuites, statuses, filter), nil
+}
+
+// getChecksFilter gets the checks filter from the configuration and, if enabled, from the branch protection.
+func (r *Repository) getChecksFilter(ctx context.Context, baseRef string) (checksFilter, error) {
+	filter := checksFilter{
+		required: slices.Clone(r.config.GetRequiredChecks()),
+		ignored:  r.config.GetIgnoredChecks(),
+		own:      r.statuses.getContext(),
+	}
+
+	if !r.config.GetUseProtectionChecks() {
+		return filter, nil
+	}
+
+	rcs, resp, err := r.client.Repositories.GetRequiredStatusChecks(ctx, r.owner, r.name, baseRef)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			// the branch is not protected.
+			return filter, nil
+		}
+
+		return checksFilter{}, fmt.Errorf("unable to get required status checks: %w", err)
 	}
 
-	var summary string
-	for _, stat := range statuses {
-		if stat.GetState() != Success {
-			summary += stat.GetDescription() + "\n"
+	if rcs.Checks != nil {
+		for _, chk := range *rcs.Checks {
+			filter.required = append(filter.required, chk.Context)
 		}
+	} else if rcs.Contexts != nil {
+		filter.required = append(filter.required, *rcs.Contexts...)
+	}
+
+	return filter, nil
+}
+
+func (r *Repository) listCheckRuns(ctx context.Context, ref string) ([]*github.CheckRun, error) {
+	opts := &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var checkRuns []*github.CheckRun
+	for {
+		result, resp, err := r.client.Checks.ListCheckRunsForRef(ctx, r.owner, r.name, ref, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		checkRuns = append(checkRuns, result.CheckRuns...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return checkRuns, nil
+}
+
+func (r *Repository) listCheckSuites(ctx context.Context, ref string) ([]*github.CheckSuite, error) {
+	opts := &github.ListCheckSuiteOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
 	}
 
-	return "", errors.New(summary)
+	var checkSuites []*github.CheckSuite
+	for {
+		result, resp, err := r.client.Checks.ListCheckSuitesForRef(ctx, r.owner, r.name, ref, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		checkSuites = append(checkSuites, result.CheckSuites...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return checkSuites, nil
+}
+
+// listStatuses lists the latest status of each context.
+func (r *Repository) listStatuses(ctx context.Context, ref string) ([]*github.RepoStatus, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var statuses []*github.RepoStatus
+	for {
+		result, resp, err := r.client.Repositories.GetCombinedStatus(ctx, r.owner, r.name, ref, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, result.Statuses...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return statuses, nil
+}
+
+// aggregateChecks aggregates the check runs, the check suites, and the statuses.
+// The aggregated state is failed if at least one check is failed, pending if at least one check is pending, otherwise successful.
+// Only the checks selected by the filter are taken into account, and a required check without any result is pending.
+func aggregateChecks(checkRuns []*github.CheckRun, checkSuites []*github.CheckSuite, statuses []*github.RepoStatus, filter checksFilter) aggregatedState {
+	var checks []check
+
+	// the check suites with check runs.
+	suites := make(map[int64]struct{})
+
+	for _, checkRun := range checkRuns {
+		if checkRun.GetCheckSuite().GetID() != 0 {
+			suites[checkRun.GetCheckSuite().GetID()] = struct{}{}
+		}
+
+		checks = append(checks, check{
+			name:        checkRun.GetName(),
+			state:       getCheckRunState(checkRun),
+			description: checkRun.GetOutput().GetTitle(),
+		})
+	}
+
+	for _, checkSuite := range checkSuites {
+		// The check suites are created for all the installed apps even if they don't run any check:
+		// only the completed check suites are relevant, the others are represented by their check runs.
+		if checkSuite.GetStatus() != Completed {
+			continue
+		}
+
+		// The conclusion of a check suite includes all its check runs (even the ignored ones):
+		// a check suite with check runs is represented by its check runs.
+		if _, ok := suites[checkSuite.GetID()]; ok || checkSuite.GetLatestCheckRunsCount() > 0 {
+			continue
+		}
+
+		checks = append(checks, check{
+			name:  checkSuite.GetApp().GetSlug(),
+			state: getConclusionState(checkSuite.GetConclusion()),
+		})
+	}
+
+	for _, status := range statuses {
+		if filter.own != "" && status.GetContext() == filter.own {
+			continue
+		}
+
+		checks = append(checks, check{
+			name:        status.GetContext(),
+			state:       status.GetState(),
+			description: status.GetDescription(),
+		})
+	}
+
+	result := aggregatedState{
+		state:         Success,
+		notSuccessful: make(map[string]check),
+	}
+
+	for _, pattern := range filter.required {
+		if !slices.ContainsFunc(checks, func(chk check) bool { return matchAny([]string{pattern}, chk.name) }) {
+			checks = append(checks, check{name: pattern, state: Pending, description: "expected"})
+		}
+	}
+
+	for _, chk := range checks {
+		if filter.isIgnored(chk.name) {
+			if !slices.Contains(result.ignored, chk.name) {
+				result.ignored = append(result.ignored, chk.name)
+			}
+
+			continue
+		}
+
+		if chk.state == Success {
+			continue
+		}
+
+		if existing, ok := result.notSuccessful[chk.name]; ok && severity(existing.state) >= severity(chk.state) {
+			continue
+		}
+
+		result.notSuccessful[chk.name] = chk
+
+		if severity(chk.state) > severity(result.state) {
+			result.state = chk.state
+		}
+	}
+
+	return result
+}
+
+func getCheckRunState(checkRun *github.CheckRun) string {
+	if checkRun.GetStatus() != Completed {
+		return Pending
+	}
+
+	return getConclusionState(checkRun.GetConclusion())
+}
+
+func getConclusionState(conclusion string) string {
+	switch conclusion {
+	case Success, Neutral, Skipped:
+		return Success
+	case "":
+		return Pending
+	default:
+		return conclusion
+	}
+}
+
+// severity the order of the states: a failure is more severe than a pending state, which is more severe than a success.
+func severity(state string) int {
+	switch state {
+	case Success:
+		return 0
+	case Pending:
+		return 1
+	default:
+		return 2
+	}
 }
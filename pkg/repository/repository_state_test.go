@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+func Test_aggregateChecks(t *testing.T) {
+	testCases := []struct {
+		desc                  string
+		checkRuns             []*github.CheckRun
+		checkSuites           []*github.CheckSuite
+		statuses              []*github.RepoStatus
+		filter                checksFilter
+		expectedState         string
+		expectedNotSuccessful []string
+	}{
+		{
+			desc:          "no checks",
+			expectedState: Success,
+		},
+		{
+			desc: "all successful",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("a"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+				{Name: github.Ptr("b"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Skipped)},
+				{Name: github.Ptr("c"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Neutral)},
+			},
+			checkSuites: []*github.CheckSuite{
+				{App: &github.App{Slug: github.Ptr("actions")}, Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+				{App: &github.App{Slug: github.Ptr("other")}, Status: github.Ptr(Queued)},
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("ci"), State: github.Ptr(Success)},
+			},
+			expectedState: Success,
+		},
+		{
+			desc: "pending check run",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("a"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+				{Name: github.Ptr("b"), Status: github.Ptr(InProgress)},
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("ci"), State: github.Ptr(Success)},
+			},
+			expectedState:         Pending,
+			expectedNotSuccessful: []string{"b"},
+		},
+		{
+			desc: "failed check run after a pending check run",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("a"), Status: github.Ptr(Queued)},
+				{Name: github.Ptr("b"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")},
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("ci"), State: github.Ptr("error")},
+			},
+			expectedState:         "failure",
+			expectedNotSuccessful: []string{"a", "b", "ci"},
+		},
+		{
+			desc: "own status",
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("ci"), State: github.Ptr(Success)},
+				{Context: github.Ptr("lobicornis/merge-queue"), State: github.Ptr("error")},
+			},
+			filter:        checksFilter{required: []string{"*"}, own: "lobicornis/merge-queue"},
+			expectedState: Success,
+		},
+		{
+			desc: "failed check suite",
+			checkSuites: []*github.CheckSuite{
+				{App: &github.App{Slug: github.Ptr("actions")}, Status: github.Ptr(Completed), Conclusion: github.Ptr("timed_out")},
+			},
+			expectedState:         "timed_out",
+			expectedNotSuccessful: []string{"actions"},
+		},
+		{
+			desc: "pending status",
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("ci"), State: github.Ptr(Success)},
+				{Context: github.Ptr("deploy"), State: github.Ptr(Pending)},
+			},
+			expectedState:         Pending,
+			expectedNotSuccessful: []string{"deploy"},
+		},
+		{
+			desc: "ignored failed check",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+				{Name: github.Ptr("coverage upload"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")},
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("codecov/patch"), State: github.Ptr("failure")},
+			},
+			filter:        checksFilter{ignored: []string{"coverage*", "codecov/*"}},
+			expectedState: Success,
+		},
+		{
+			desc: "ignored failed check in a failed check suite",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success), CheckSuite: &github.CheckSuite{ID: github.Ptr(int64(1))}},
+				{Name: github.Ptr("codecov/patch"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure"), CheckSuite: &github.CheckSuite{ID: github.Ptr(int64(1))}},
+			},
+			checkSuites: []*github.CheckSuite{
+				{ID: github.Ptr(int64(1)), App: &github.App{Slug: github.Ptr("github-actions")}, Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")},
+			},
+			filter:        checksFilter{ignored: []string{"codecov/*"}},
+			expectedState: Success,
+		},
+		{
+			desc: "failed check in a failed check suite",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure"), CheckSuite: &github.CheckSuite{ID: github.Ptr(int64(1))}},
+				{Name: github.Ptr("codecov/patch"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure"), CheckSuite: &github.CheckSuite{ID: github.Ptr(int64(1))}},
+			},
+			checkSuites: []*github.CheckSuite{
+				{ID: github.Ptr(int64(1)), App: &github.App{Slug: github.Ptr("github-actions")}, Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")},
+			},
+			filter:                checksFilter{ignored: []string{"codecov/*"}},
+			expectedState:         "failure",
+			expectedNotSuccessful: []string{"test"},
+		},
+		{
+			desc: "ignored failed check in a failed check suite without the check runs",
+			checkSuites: []*github.CheckSuite{
+				{ID: github.Ptr(int64(1)), App: &github.App{Slug: github.Ptr("github-actions")}, Status: github.Ptr(Completed), Conclusion: github.Ptr("failure"), LatestCheckRunsCount: github.Ptr(int64(2))},
+			},
+			filter:        checksFilter{ignored: []string{"codecov/*"}},
+			expectedState: Success,
+		},
+		{
+			desc: "only required checks",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+				{Name: github.Ptr("lint"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")},
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.Ptr("deploy"), State: github.Ptr(Pending)},
+			},
+			filter:        checksFilter{required: []string{"test"}},
+			expectedState: Success,
+		},
+		{
+			desc: "missing required check",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("test"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+			},
+			filter:                checksFilter{required: []string{"test", "integration (*)"}},
+			expectedState:         Pending,
+			expectedNotSuccessful: []string{"integration (*)"},
+		},
+		{
+			desc: "ignored required check",
+			checkRuns: []*github.CheckRun{
+				{Name: github.Ptr("test (1)"), Status: github.Ptr(Completed), Conclusion: github.Ptr(Success)},
+				{Name: github.Ptr("test (2)"), Status: github.Ptr(Completed), Conclusion: github.Ptr("failure")},
+			},
+			filter:        checksFilter{required: []string{"test *"}, ignored: []string{"test (2)"}},
+			expectedState: Success,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			state := aggregateChecks(test.checkRuns, test.checkSuites, test.statuses, test.filter)
+
+			assert.Equal(t, test.expectedState, state.state)
+
+			var names []string
+			for name := range state.notSuccessful {
+				names = append(names, name)
+			}
+
+			assert.ElementsMatch(t, test.expectedNotSuccessful, names)
+		})
+	}
+}
+
+func TestRepository_getStatus_pagination(t *testing.T) {
+	mux := http.NewServeMux()
+
+	// 3 pages of check runs: the failed one is on the last page.
+	mux.HandleFunc("GET /repos/traefik/traefik/commits/abc/check-runs", func(rw http.ResponseWriter, req *http.Request) {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		var runs []*github.CheckRun
+		for i := range 100 {
+			runs = append(runs, &github.CheckRun{
+				Name:       github.Ptr(fmt.Sprintf("job-%d-%d", page, i)),
+				Status:     github.Ptr(Completed),
+				Conclusion: github.Ptr(Success),
+			})
+		}
+
+		if page == 3 {
+			runs[99].Conclusion = github.Ptr("failure")
+		} else {
+			rw.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, req.URL.Path, page+1))
+		}
+
+		writeJSON(t, rw, &github.ListCheckRunsResults{Total: github.Ptr(300), CheckRuns: runs})
+	})
+
+	mux.HandleFunc("GET /repos/traefik/traefik/commits/abc/check-suites", func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, rw, &github.ListCheckSuiteResults{})
+	})
+
+	mux.HandleFunc("GET /repos/traefik/traefik/commits/abc/status", func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page") == "" {
+			rw.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, req.URL.Path))
+			writeJSON(t, rw, &github.CombinedStatus{Statuses: []*github.RepoStatus{{Context: github.Ptr("ci"), State: github.Ptr(Success)}}})
+
+			return
+		}
+
+		writeJSON(t, rw, &github.CombinedStatus{Statuses: []*github.RepoStatus{{Context: github.Ptr("deploy"), State: github.Ptr(Pending)}}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	repo := &Repository{client: ghapi.New(client), owner: "traefik", name: "traefik", config: conf.RepoConfig{IgnoredChecks: []string{"job-1-*"}}}
+
+	pr := &github.PullRequest{Head: &github.PullRequestBranch{SHA: github.Ptr("abc")}}
+
+	state, err := repo.getStatus(t.Context(), pr)
+	require.NoError(t, err)
+
+	assert.Equal(t, "failure", state.state)
+	assert.Len(t, state.notSuccessful, 2)
+	assert.Contains(t, state.notSuccessful, "job-3-99")
+	assert.Contains(t, state.notSuccessful, "deploy")
+	assert.Len(t, state.ignored, 100)
+}
+
+func writeJSON(t *testing.T, rw http.ResponseWriter, value any) {
+	t.Helper()
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(rw).Encode(value)
+	require.NoError(t, err)
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+// Commit status states.
+const (
+	statusError   = "error"
+	statusPending = "pending"
+	statusSuccess = "success"
+)
+
+// maxStatusDescription the maximum length (in characters) of the description of a commit status.
+const maxStatusDescription = 140
+
+// maxPublishedStatuses the maximum number of pull requests tracked by the publisher.
+// The pull requests closed without merge are never evicted: the tracking is reset when the limit is reached.
+const maxPublishedStatuses = 10000
+
+// StatusPublisher publishes the commit status of the bot on the head of the pull requests.
+// A status is only published when it changes: the publisher can be shared by the jobs of the same process.
+type StatusPublisher struct {
+	context string
+	dryRun  bool
+
+	mu sync.Mutex
+	// last the last status published by pull request.
+	last map[string]publishedStatus
+}
+
+type publishedStatus struct {
+	sha         string
+	state       string
+	description string
+
+	// updatedAt the update date of the pull request when the status was published.
+	updatedAt time.Time
+}
+
+func (s publishedStatus) equal(other publishedStatus) bool {
+	return s.sha == other.sha && s.state == other.state && s.description == other.description
+}
+
+// NewStatusPublisher creates a new status publisher.
+// Returns nil if the context is empty: the commit status is disabled.
+func NewStatusPublisher(statusContext string, dryRun bool) *StatusPublisher {
+	if statusContext == "" {
+		return nil
+	}
+
+	return &StatusPublisher{
+		context: statusContext,
+		dryRun:  dryRun,
+		last:    make(map[string]publishedStatus),
+	}
+}
+
+func (p *StatusPublisher) getContext() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.context
+}
+
+// publish publishes a commit status, if it differs from the last published status.
+func (p *StatusPublisher) publish(ctx context.Context, client *ghapi.Client, owner, name string, pr *github.PullRequest, state, description string) error {
+	if p == nil {
+		return nil
+	}
+
+	description = truncate(description, maxStatusDescription)
+
+	key := statusKey(owner, name, pr.GetNumber())
+	status := publishedStatus{sha: pr.Head.GetSHA(), state: state, description: description, updatedAt: pr.GetUpdatedAt().Time}
+
+	// the status is recorded before the call: the same status is not published twice concurrently.
+	p.mu.Lock()
+	if previous, ok := p.last[key]; ok && previous.equal(status) {
+		p.mu.Unlock()
+		return nil
+	}
+
+	if len(p.last) >= maxPublishedStatuses {
+		clear(p.last)
+	}
+
+	p.last[key] = status
+	p.mu.Unlock()
+
+	log.Ctx(ctx).Debug().Int("pr", pr.GetNumber()).Msgf("Publish status: %s: %s. Dry run: %v", state, description, p.dryRun)
+
+	if p.dryRun {
+		return nil
+	}
+
+	_, _, err := client.Repositories.CreateStatus(ctx, owner, name, status.sha, &github.RepoStatus{
+		State:       github.Ptr(state),
+		Description: github.Ptr(description),
+		Context:     github.Ptr(p.context),
+	})
+	if err != nil {
+		// the status is published again by the next call.
+		p.mu.Lock()
+		if current, found := p.last[key]; found && current.equal(status) {
+			delete(p.last, key)
+		}
+		p.mu.Unlock()
+
+		return fmt.Errorf("failed to publish the commit status: %w", err)
+	}
+
+	return nil
+}
+
+// forget stops tracking the status of a merged or closed pull request.
+func (p *StatusPublisher) forget(owner, name string, number int) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	delete(p.last, statusKey(owner, name, number))
+	p.mu.Unlock()
+}
+
+// headSHA gets the head SHA of the last published status, if the pull request has not been updated since.
+func (p *StatusPublisher) headSHA(owner, name string, number int, updatedAt time.Time) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status, ok := p.last[statusKey(owner, name, number)]
+	if !ok || status.updatedAt.IsZero() || !status.updatedAt.Equal(updatedAt) {
+		return "", false
+	}
+
+	return status.sha, true
+}
+
+func statusKey(owner, name string, number int) string {
+	return owner + "/" + name + "#" + strconv.Itoa(number)
+}
+
+// truncate truncates a text to a number of characters, on a rune boundary.
+func truncate(text string, size int) string {
+	if utf8.RuneCountInString(text) <= size {
+		return text
+	}
+
+	return string([]rune(text)[:size-3]) + "..."
+}
+
+// PublishStatus publishes the commit status of the bot on a pull request.
+func (r *Repository) PublishStatus(ctx context.Context, pr *github.PullRequest, state, description string) {
+	err := r.statuses.publish(ctx, r.client, r.owner, r.name, pr, state, description)
+	IgnoreError(ctx, err)
+}
+
+// ForgetStatus stops tracking the commit status of a merged pull request.
+func (r *Repository) ForgetStatus(pr *github.PullRequest) {
+	r.statuses.forget(r.owner, r.name, pr.GetNumber())
+}
+
+// PublishQueue publishes the position in the queue of the waiting pull requests.
+// The issues are sorted by position, ahead is the number of pull requests in progress.
+// The pull request is only fetched if it has been updated since its last published status.
+func (r *Repository) PublishQueue(ctx context.Context, issues []*github.Issue, ahead int) {
+	if r.statuses == nil {
+		return
+	}
+
+	for i, issue := range issues {
+		pr := &github.PullRequest{Number: issue.Number, UpdatedAt: issue.UpdatedAt}
+
+		sha, ok := r.statuses.headSHA(r.owner, r.name, issue.GetNumber(), issue.GetUpdatedAt().Time)
+		if ok {
+			pr.Head = &github.PullRequestBranch{SHA: github.Ptr(sha)}
+		} else {
+			var err error
+			pr, _, err = r.client.PullRequests.Get(ctx, r.owner, r.name, issue.GetNumber())
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Int("pr", issue.GetNumber()).Msg("failed to get pull request")
+				continue
+			}
+		}
+
+		r.PublishStatus(ctx, pr, statusPending, fmt.Sprintf("position %d in queue", ahead+i+1))
+	}
+}
+
+// getStatusDescription describes why a pull request cannot be merged.
+func getStatusDescription(err error) string {
+	var reviewsErr *missingReviewsError
+	if errors.As(err, &reviewsErr) {
+		missing := reviewsErr.required - reviewsErr.approved
+		if missing == 1 {
+			return "needs 1 more review"
+		}
+
+		return fmt.Sprintf("needs %d more reviews", missing)
+	}
+
+	switch GetReason(err) {
+	case ReasonMilestone:
+		return "blocked: missing milestone"
+	case ReasonConflicts:
+		return "blocked: conflicts must be resolved"
+	default:
+		return "blocked: " + err.Error()
+	}
+}
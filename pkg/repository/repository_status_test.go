@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+func Test_getStatusDescription(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		expected string
+	}{
+		{
+			desc:     "missing review",
+			err:      WithReason(ReasonReview, fmt.Errorf("error related to review: %w", &missingReviewsError{approved: 1, required: 2})),
+			expected: "needs 1 more review",
+		},
+		{
+			desc:     "missing reviews",
+			err:      WithReason(ReasonReview, fmt.Errorf("error related to review: %w", &missingReviewsError{approved: 0, required: 3})),
+			expected: "needs 3 more reviews",
+		},
+		{
+			desc:     "missing milestone",
+			err:      WithReason(ReasonMilestone, errors.New("the milestone is missing")),
+			expected: "blocked: missing milestone",
+		},
+		{
+			desc:     "other",
+			err:      WithReason(ReasonMergeableState, errors.New(`the mergeable state is "blocked"`)),
+			expected: `blocked: the mergeable state is "blocked"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, getStatusDescription(test.err))
+		})
+	}
+}
+
+func TestStatusPublisher_publish(t *testing.T) {
+	var mu sync.Mutex
+	var published []github.RepoStatus
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/traefik/traefik/statuses/{sha}", func(rw http.ResponseWriter, req *http.Request) {
+		var status github.RepoStatus
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&status))
+
+		mu.Lock()
+		published = append(published, status)
+		mu.Unlock()
+
+		writeJSON(t, rw, &status)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	publisher := NewStatusPublisher("lobicornis/merge-queue", false)
+
+	pr := &github.PullRequest{Number: github.Ptr(1), Head: &github.PullRequestBranch{SHA: github.Ptr("abc")}}
+
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusPending, "position 2 in queue"))
+	// unchanged.
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusPending, "position 2 in queue"))
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusPending, "position 1 in queue"))
+	// too long.
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusError, strings.Repeat("a", 200)))
+	// too long, with multi-byte characters.
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusError, strings.Repeat("é", 200)))
+
+	publisher.forget("traefik", "traefik", 1)
+	// published again after forget.
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusError, strings.Repeat("é", 200)))
+
+	require.Len(t, published, 5)
+
+	assert.Equal(t, "lobicornis/merge-queue", published[0].GetContext())
+	assert.Equal(t, "position 2 in queue", published[0].GetDescription())
+	assert.Equal(t, "position 1 in queue", published[1].GetDescription())
+	assert.Equal(t, statusError, published[2].GetState())
+	assert.Len(t, published[2].GetDescription(), maxStatusDescription)
+	assert.Equal(t, strings.Repeat("é", maxStatusDescription-3)+"...", published[3].GetDescription())
+	assert.True(t, utf8.ValidString(published[3].GetDescription()))
+	assert.Equal(t, published[3], published[4])
+}
+
+func TestStatusPublisher_publish_error(t *testing.T) {
+	var calls atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/traefik/traefik/statuses/{sha}", func(rw http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(rw, "boom", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(t, rw, &github.RepoStatus{})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	publisher := NewStatusPublisher("lobicornis/merge-queue", false)
+
+	pr := &github.PullRequest{Number: github.Ptr(1), Head: &github.PullRequestBranch{SHA: github.Ptr("abc")}}
+
+	require.Error(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusPending, "waiting for CI"))
+	// published again: the failed status is not recorded.
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusPending, "waiting for CI"))
+	require.NoError(t, publisher.publish(t.Context(), ghapi.New(client), "traefik", "traefik", pr, statusPending, "waiting for CI"))
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRepository_PublishQueue(t *testing.T) {
+	var mu sync.Mutex
+	var gets []string
+	published := map[string]string{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/traefik/traefik/pulls/{number}", func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		gets = append(gets, req.PathValue("number"))
+		mu.Unlock()
+
+		number, err := strconv.Atoi(req.PathValue("number"))
+		require.NoError(t, err)
+
+		writeJSON(t, rw, &github.PullRequest{
+			Number:    github.Ptr(number),
+			Head:      &github.PullRequestBranch{SHA: github.Ptr("sha" + req.PathValue("number"))},
+			UpdatedAt: &github.Timestamp{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		})
+	})
+	mux.HandleFunc("POST /repos/traefik/traefik/statuses/{sha}", func(rw http.ResponseWriter, req *http.Request) {
+		var status github.RepoStatus
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&status))
+
+		mu.Lock()
+		published[req.PathValue("sha")] = status.GetDescription()
+		mu.Unlock()
+
+		writeJSON(t, rw, &status)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	repo := New(ghapi.New(client), "traefik/traefik", "", conf.Markers{}, conf.Retry{}, conf.Timeouts{}, conf.Git{}, conf.RepoConfig{}, conf.Extra{}, nil, nil,
+		NewStatusPublisher("lobicornis/merge-queue", false))
+
+	updatedAt := &github.Timestamp{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	issues := []*github.Issue{
+		{Number: github.Ptr(1), UpdatedAt: updatedAt},
+		{Number: github.Ptr(2), UpdatedAt: updatedAt},
+	}
+
+	repo.PublishQueue(t.Context(), issues, 1)
+
+	assert.Equal(t, []string{"1", "2"}, gets)
+	assert.Equal(t, map[string]string{"sha1": "position 2 in queue", "sha2": "position 3 in queue"}, published)
+
+	// the first pull request is in progress, the second one is not updated: no request.
+	repo.PublishQueue(t.Context(), issues[1:], 2)
+
+	assert.Equal(t, []string{"1", "2"}, gets)
+	assert.Equal(t, "position 3 in queue", published["sha2"])
+
+	// the pull request has been updated: fetched again.
+	issues[1].UpdatedAt = &github.Timestamp{Time: updatedAt.Add(time.Minute)}
+
+	repo.PublishQueue(t.Context(), issues[1:], 1)
+
+	assert.Equal(t, []string{"1", "2", "2"}, gets)
+	assert.Equal(t, "position 2 in queue", published["sha2"])
+}
+
+func TestNewStatusPublisher_disabled(t *testing.T) {
+	publisher := NewStatusPublisher("", false)
+
+	assert.Nil(t, publisher)
+	assert.Empty(t, publisher.getContext())
+	assert.NoError(t, publisher.publish(t.Context(), nil, "traefik", "traefik", &github.PullRequest{}, statusPending, "waiting for CI"))
+}
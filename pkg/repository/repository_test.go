@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+func TestRepository_isProtectedBranch(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /repos/traefik/traefik", func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, rw, &github.Repository{DefaultBranch: github.Ptr("main")})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	testCases := []struct {
+		desc          string
+		defaultBranch string
+		patterns      []string
+		branch        string
+		expected      bool
+	}{
+		{
+			desc:          "default branch",
+			defaultBranch: "main",
+			branch:        "main",
+			expected:      true,
+		},
+		{
+			desc:          "default branch from the API",
+			defaultBranch: "",
+			branch:        "main",
+			expected:      true,
+		},
+		{
+			desc:          "master is not the default branch",
+			defaultBranch: "main",
+			branch:        "master",
+		},
+		{
+			desc:          "protected pattern",
+			defaultBranch: "main",
+			patterns:      []string{"v*.*"},
+			branch:        "v2.11",
+			expected:      true,
+		},
+		{
+			desc:          "unprotected branch",
+			defaultBranch: "main",
+			patterns:      []string{"v*.*"},
+			branch:        "feature",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &Repository{
+				client: ghapi.New(client),
+				config: conf.RepoConfig{ProtectedBranches: test.patterns},
+			}
+
+			pr := &github.PullRequest{
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Owner:         &github.User{Login: github.Ptr("traefik")},
+						Name:          github.Ptr("traefik"),
+						DefaultBranch: github.Ptr(test.defaultBranch),
+					},
+				},
+			}
+
+			protected, err := repo.isProtectedBranch(t.Context(), pr, test.branch)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, protected)
+		})
+	}
+}
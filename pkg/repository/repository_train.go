@@ -0,0 +1,670 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/ldez/go-git-cmd-wrapper/v2/commit"
+	"github.com/ldez/go-git-cmd-wrapper/v2/fetch"
+	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
+	"github.com/ldez/go-git-cmd-wrapper/v2/merge"
+	"github.com/ldez/go-git-cmd-wrapper/v2/push"
+	"github.com/ldez/go-git-cmd-wrapper/v2/revparse"
+	"github.com/ldez/go-git-cmd-wrapper/v2/types"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
+)
+
+// Merge train.
+const (
+	trainBranchPrefix = "lobicornis/train/"
+	trainBaseTrailer  = "Lobicornis-Train-Base:"
+	trainPullsTrailer = "Lobicornis-Train-Pulls:"
+	// trainSuspectsTrailer a group of pull requests of a failed train, not part of the bisected train (one trailer by group).
+	trainSuspectsTrailer = "Lobicornis-Train-Suspects:"
+)
+
+// train a batch of pull requests merged together on top of the base branch.
+type train struct {
+	// head the SHA of the train branch.
+	head string
+	// base the SHA of the base branch used to build the train.
+	base  string
+	pulls []trainPull
+	// suspects the groups of pull requests of the failed trains not in this train (bisection), the last bisected first:
+	// the first group forms the next train when this train succeeds.
+	suspects [][]int
+}
+
+// trainPull a pull request of a train.
+type trainPull struct {
+	number int
+	sha    string
+}
+
+// trailers describes the train in the commit message of the train branch.
+func (t train) trailers() string {
+	var pulls []string
+	for _, pull := range t.pulls {
+		pulls = append(pulls, fmt.Sprintf("#%d@%s", pull.number, pull.sha))
+	}
+
+	trailers := fmt.Sprintf("%s %s\n%s %s", trainBaseTrailer, t.base, trainPullsTrailer, strings.Join(pulls, " "))
+
+	for _, group := range t.suspects {
+		var suspects []string
+		for _, number := range group {
+			suspects = append(suspects, "#"+strconv.Itoa(number))
+		}
+
+		trailers += fmt.Sprintf("\n%s %s", trainSuspectsTrailer, strings.Join(suspects, " "))
+	}
+
+	return trailers
+}
+
+func (t train) numbers() []int {
+	var numbers []int
+	for _, pull := range t.pulls {
+		numbers = append(numbers, pull.number)
+	}
+
+	return numbers
+}
+
+// parseTrain parses the trailers of the commit message of a train branch.
+func parseTrain(message string) (train, error) {
+	var result train
+
+	for line := range strings.Lines(message) {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, trainBaseTrailer):
+			result.base = strings.TrimSpace(strings.TrimPrefix(line, trainBaseTrailer))
+
+		case strings.HasPrefix(line, trainPullsTrailer):
+			for raw := range strings.FieldsSeq(strings.TrimPrefix(line, trainPullsTrailer)) {
+				number, sha, ok := strings.Cut(strings.TrimPrefix(raw, "#"), "@")
+				if !ok {
+					return train{}, fmt.Errorf("invalid train pull request: %s", raw)
+				}
+
+				n, err := strconv.Atoi(number)
+				if err != nil {
+					return train{}, fmt.Errorf("invalid train pull request number: %s: %w", raw, err)
+				}
+
+				result.pulls = append(result.pulls, trainPull{number: n, sha: sha})
+			}
+
+		case strings.HasPrefix(line, trainSuspectsTrailer):
+			var group []int
+			for raw := range strings.FieldsSeq(strings.TrimPrefix(line, trainSuspectsTrailer)) {
+				n, err := strconv.Atoi(strings.TrimPrefix(raw, "#"))
+				if err != nil {
+					return train{}, fmt.Errorf("invalid train suspect: %s: %w", raw, err)
+				}
+
+				group = append(group, n)
+			}
+
+			result.suspects = append(result.suspects, group)
+		}
+	}
+
+	if result.base == "" || len(result.pulls) == 0 {
+		return train{}, errors.New("the train description is missing")
+	}
+
+	return result, nil
+}
+
+// ProcessTrain builds, checks, and merges a merge train from the pull requests of the queue.
+// The numbers are the pull requests of the queue, in the queue order.
+// The processing of the train is limited by the pull request deadline: an interrupted train is resumed by the next run.
+func (r *Repository) ProcessTrain(ctx context.Context, numbers []int) error {
+	ctx, cancel := WithTimeout(ctx, r.timeouts.PullRequest)
+	defer cancel()
+
+	logger := log.Ctx(ctx)
+
+	if len(numbers) == 0 {
+		logger.Debug().Msg("Nothing to merge.")
+		return nil
+	}
+
+	// the configuration file is read from the base branch of the first pull request of the queue.
+	first, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, numbers[0])
+	if err != nil {
+		return fmt.Errorf("failed to get pull request #%d: %w", numbers[0], err)
+	}
+
+	err = r.pipeline.applyConfigFile(ctx, first)
+	if err != nil {
+		return err
+	}
+
+	candidates := r.getTrainCandidates(ctx, numbers)
+	if len(candidates) == 0 {
+		logger.Debug().Msg("Nothing to merge.")
+		return nil
+	}
+
+	// one train by run: the base branch of the first pull request of the queue.
+	baseRef := candidates[0].Base.GetRef()
+	branch := trainBranchPrefix + baseRef
+
+	baseBranch, _, err := r.client.Repositories.GetBranch(ctx, r.owner, r.name, baseRef, 1)
+	if err != nil {
+		return fmt.Errorf("failed to get the base branch %s: %w", baseRef, err)
+	}
+
+	current, err := r.getTrain(ctx, branch)
+	if err != nil {
+		return err
+	}
+
+	if current != nil && !r.isValidTrain(ctx, current, baseBranch.GetCommit().GetSHA()) {
+		logger.Info().Str("branch", branch).Msg("The merge train is outdated.")
+
+		err = r.deleteTrain(ctx, branch, current.numbers())
+		if err != nil {
+			return err
+		}
+
+		current = nil
+	}
+
+	if current == nil {
+		var pulls []*github.PullRequest
+		for _, pr := range candidates {
+			if pr.Base.GetRef() == baseRef {
+				pulls = append(pulls, pr)
+			}
+		}
+
+		return r.buildTrain(ctx, branch, pulls, r.config.GetMergeTrainSize(), nil)
+	}
+
+	state, err := r.getRefStatus(ctx, current.head, baseRef)
+	if err != nil {
+		return fmt.Errorf("merge train checks status: %w", err)
+	}
+
+	switch state.state {
+	case Pending:
+		logger.Info().Str("branch", branch).Msgf("State: pending. Waiting for the CI of the merge train %v.", current.numbers())
+		return nil
+
+	case Success:
+		return r.mergeTrain(ctx, branch, baseRef, current)
+
+	default:
+		return r.bisectTrain(ctx, branch, current, state)
+	}
+}
+
+// getTrainCandidates gets the pull requests that can be part of a merge train.
+func (r *Repository) getTrainCandidates(ctx context.Context, numbers []int) []*github.PullRequest {
+	var candidates []*github.PullRequest
+
+	for _, number := range numbers {
+		logger := log.Ctx(ctx).With().Int("pr", number).Logger()
+
+		pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, number)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to get pull request")
+			continue
+		}
+
+		r.metrics.PullRequestProcessed(r.fullName())
+
+		ready, err := r.checkTrainCandidate(logger.WithContext(ctx), pr)
+		if err != nil {
+			r.pipeline.publishError(logger.WithContext(ctx), pr, err)
+			r.pipeline.callHuman(logger.WithContext(ctx), pr, GetReason(err), err.Error())
+
+			continue
+		}
+
+		if !ready {
+			continue
+		}
+
+		candidates = append(candidates, pr)
+	}
+
+	return candidates
+}
+
+// checkTrainCandidate checks the requirements of a pull request before adding it to a train.
+// The CI is not checked: the CI of the train replaces the CI of the pull request.
+// Returns false, without error, if the pull request is waiting (mergeable state not computed yet, draft, missing reviews):
+// only the terminal reasons are errors.
+func (r *Repository) checkTrainCandidate(ctx context.Context, pr *github.PullRequest) (bool, error) {
+	logger := log.Ctx(ctx)
+
+	if r.config.GetNeedMilestone() && pr.Milestone == nil {
+		return false, WithReason(ReasonMilestone, errors.New("the milestone is missing"))
+	}
+
+	err := r.CheckReviews(ctx, pr, r.pipeline.getMinReview(r.Describe(pr)))
+	if err != nil {
+		var reviewsErr *missingReviewsError
+		if errors.As(err, &reviewsErr) {
+			logger.Debug().Msgf("Waiting for reviews: %v.", err)
+			r.PublishStatus(ctx, pr, statusPending, getStatusDescription(err))
+
+			return false, nil
+		}
+
+		return false, WithReason(ReasonReview, fmt.Errorf("error related to review: %w", err))
+	}
+
+	if pr.Mergeable == nil || pr.GetMergeableState() == MergeableStateDraft {
+		logger.Debug().Msgf("The mergeable state is %q.", pr.GetMergeableState())
+		return false, nil
+	}
+
+	if !pr.GetMergeable() {
+		err = r.pipeline.manageRetryLabel(ctx, pr, r.retry.OnMergeable, errors.New("conflicts must be resolved in the PR"))
+		if err == nil {
+			r.PublishStatus(ctx, pr, statusPending, "waiting for a retry: conflicts")
+			return false, nil
+		}
+
+		return false, WithReason(ReasonConflicts, err)
+	}
+
+	// the train is merged by a fast-forward of the base branch to the train branch: the pull requests are merged with merge commits.
+	mergeMethod, err := r.getMergeMethod(pr)
+	if err != nil {
+		return false, WithReason(ReasonMergeMethod, err)
+	}
+
+	if mergeMethod != conf.MergeMethodMerge {
+		return false, WithReason(ReasonMergeMethod,
+			fmt.Errorf("the merge method [%s] is not supported by the merge train: the pull requests are merged with merge commits", mergeMethod))
+	}
+
+	return true, nil
+}
+
+// getTrain gets the current train, nil if there is no train.
+func (r *Repository) getTrain(ctx context.Context, branch string) (*train, error) {
+	trainBranch, resp, err := r.client.Repositories.GetBranch(ctx, r.owner, r.name, branch, 1)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get the train branch %s: %w", branch, err)
+	}
+
+	current, err := parseTrain(trainBranch.GetCommit().GetCommit().GetMessage())
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("branch", branch).Msg("Invalid merge train.")
+		return &train{head: trainBranch.GetCommit().GetSHA()}, nil
+	}
+
+	current.head = trainBranch.GetCommit().GetSHA()
+
+	return &current, nil
+}
+
+// isValidTrain checks that the base branch and the pull requests have not changed since the creation of the train.
+func (r *Repository) isValidTrain(ctx context.Context, current *train, baseSHA string) bool {
+	if current.base != baseSHA || len(current.pulls) == 0 {
+		return false
+	}
+
+	for _, pull := range current.pulls {
+		pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, pull.number)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Int("pr", pull.number).Msg("failed to get pull request")
+			return false
+		}
+
+		if pr.GetState() != "open" || pr.Head.GetSHA() != pull.sha ||
+			!hasLabel(pr, r.markers.NeedMerge) || hasLabel(pr, r.markers.NoMerge) || hasLabel(pr, r.markers.NeedHumanMerge) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildTrain builds a new train with the first pull requests, and pushes it.
+// The suspects are the groups of pull requests of the failed trains kept for the next trains (bisection).
+func (r *Repository) buildTrain(ctx context.Context, branch string, pulls []*github.PullRequest, size int, suspects [][]int) error {
+	logger := log.Ctx(ctx)
+
+	// the train branch is force-pushed.
+	protected, err := r.isProtectedBranch(ctx, pulls[0], branch)
+	if err != nil {
+		return err
+	}
+
+	if protected {
+		return fmt.Errorf("the train branch %s is protected", branch)
+	}
+
+	dir, err := os.MkdirTemp("", "myrmica-lobicornis")
+	if err != nil {
+		return err
+	}
+
+	defer func() { IgnoreError(ctx, os.RemoveAll(dir)) }()
+
+	err = r.clone.BaseBranch(ctx, dir, pulls[0])
+	if err != nil {
+		return fmt.Errorf("failed to clone: %w", err)
+	}
+
+	output, err := git.RevParseWithContext(ctx, global.UpperC(dir), revparse.Args("HEAD"), git.Debugger(r.debug))
+	if err != nil {
+		logger.Error().Err(err).Msg(output)
+		return fmt.Errorf("failed to get the base SHA: %w", err)
+	}
+
+	next := train{base: strings.TrimSpace(output), suspects: suspects}
+
+	var members []*github.PullRequest
+	for _, pr := range pulls {
+		if len(next.pulls) >= size {
+			break
+		}
+
+		merged, errMerge := r.addToTrain(ctx, dir, pr)
+		if errMerge != nil {
+			return errMerge
+		}
+
+		if !merged {
+			if len(next.pulls) == 0 {
+				r.pipeline.callHuman(ctx, pr, ReasonConflicts, "conflicts with the base branch must be resolved in the PR")
+			} else {
+				logger.Info().Int("pr", pr.GetNumber()).Msg("Conflicts with the merge train, postponed to the next train.")
+			}
+
+			continue
+		}
+
+		next.pulls = append(next.pulls, trainPull{number: pr.GetNumber(), sha: pr.Head.GetSHA()})
+		members = append(members, pr)
+	}
+
+	if len(next.pulls) == 0 {
+		return nil
+	}
+
+	logger.Info().Str("branch", branch).Msgf("MERGE TRAIN %v (suspects: %v)", next.numbers(), next.suspects)
+
+	message, err := git.RawWithContext(ctx, "log", global.UpperC(dir), git.Debugger(r.debug), func(g *types.Cmd) {
+		g.AddOptions("-1")
+		g.AddOptions("--format=%B")
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg(message)
+		return fmt.Errorf("failed to get the commit message: %w", err)
+	}
+
+	output, err = git.CommitWithContext(ctx, global.UpperC(dir), commit.Amend, commit.Message(strings.TrimSpace(message)+"\n\n"+next.trailers()), git.Debugger(r.debug))
+	if err != nil {
+		logger.Error().Err(err).Msg(output)
+		return fmt.Errorf("failed to describe the merge train: %w", err)
+	}
+
+	start := time.Now()
+
+	output, err = GitWithTimeout(ctx, r.timeouts.Push, func(ctx context.Context) (string, error) {
+		return git.PushWithContext(ctx,
+			global.UpperC(dir),
+			git.Cond(r.dryRun, push.DryRun),
+			push.Force,
+			push.Remote(RemoteOrigin),
+			push.RefSpec("HEAD:refs/heads/"+branch),
+			git.Debugger(r.debug))
+	})
+
+	r.metrics.GitDuration(metrics.OperationPush, time.Since(start))
+
+	if err != nil {
+		return fmt.Errorf("failed to push the merge train %s: %w\n %s", branch, err, output)
+	}
+
+	// the status is published only when the train exists.
+	for _, pr := range members {
+		err = r.pipeline.addLabels(ctx, pr, r.markers.MergeInProgress)
+		IgnoreError(ctx, err)
+
+		r.PublishStatus(ctx, pr, statusPending, "in merge train")
+	}
+
+	return nil
+}
+
+// addToTrain merges a pull request into the train.
+// Returns false if the pull request cannot be merged into the train.
+func (r *Repository) addToTrain(ctx context.Context, dir string, pr *github.PullRequest) (bool, error) {
+	logger := log.Ctx(ctx).With().Int("pr", pr.GetNumber()).Logger()
+
+	output, err := GitWithTimeout(ctx, r.timeouts.Fetch, func(ctx context.Context) (string, error) {
+		return git.FetchWithContext(ctx,
+			global.UpperC(dir),
+			fetch.NoTags,
+			fetch.Remote(RemoteOrigin),
+			fetch.RefSpec(fmt.Sprintf("pull/%d/head", pr.GetNumber())),
+			git.Debugger(r.debug))
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg(output)
+		return false, fmt.Errorf("failed to fetch the pull request #%d: %w", pr.GetNumber(), err)
+	}
+
+	message := fmt.Sprintf("Merge pull request #%d from %s\n\n%s", pr.GetNumber(), pr.Head.GetLabel(), pr.GetTitle())
+
+	output, err = GitWithTimeout(ctx, r.timeouts.Rebase, func(ctx context.Context) (string, error) {
+		return git.MergeWithContext(ctx, global.UpperC(dir), merge.NoFf, merge.M(message), merge.Commits(pr.Head.GetSHA()), git.Debugger(r.debug))
+	})
+	if err != nil {
+		logger.Debug().Err(err).Msg(output)
+
+		if isInterrupted(err) {
+			// not a conflict: the pull request is kept in the queue.
+			return false, fmt.Errorf("failed to merge the pull request #%d: %w", pr.GetNumber(), err)
+		}
+
+		output, err = git.MergeWithContext(ctx, global.UpperC(dir), merge.Abort, git.Debugger(r.debug))
+		if err != nil {
+			logger.Error().Err(err).Msg(output)
+			return false, fmt.Errorf("failed to abort the merge of the pull request #%d: %w", pr.GetNumber(), err)
+		}
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// mergeTrain fast-forwards the base branch to the train branch.
+func (r *Repository) mergeTrain(ctx context.Context, branch, baseRef string, current *train) error {
+	logger := log.Ctx(ctx)
+
+	logger.Info().Str("branch", branch).Msgf("MERGE(train) %v", current.numbers())
+
+	if r.dryRun {
+		return nil
+	}
+
+	ref := &github.Reference{
+		Ref:    github.Ptr("heads/" + baseRef),
+		Object: &github.GitObject{SHA: github.Ptr(current.head)},
+	}
+
+	_, resp, err := r.client.Git.UpdateRef(ctx, r.owner, r.name, ref, false)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+			// the branch protection rejects the update: the train will never be merged.
+			return r.rejectTrain(ctx, branch, baseRef, current, err)
+		}
+
+		return fmt.Errorf("failed to fast-forward %s to the merge train: %w", baseRef, err)
+	}
+
+	_, err = r.client.Git.DeleteRef(ctx, r.owner, r.name, "heads/"+branch)
+	IgnoreError(ctx, err)
+
+	for _, number := range current.numbers() {
+		loggerPR := logger.With().Int("pr", number).Logger()
+
+		r.statuses.forget(r.owner, r.name, number)
+
+		pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, number)
+		if err != nil {
+			loggerPR.Error().Err(err).Msg("failed to get pull request")
+			continue
+		}
+
+		r.metrics.PullRequestMerged(r.fullName(), metrics.MethodTrain)
+		r.observeMergeTime(loggerPR.WithContext(ctx), pr)
+
+		err = r.pipeline.removeLabels(loggerPR.WithContext(ctx), pr, mergeLabels(r.markers, r.markers.MergeInProgress)...)
+		IgnoreError(ctx, err)
+
+		err = r.mjolnir.CloseRelatedIssues(loggerPR.WithContext(ctx), pr)
+		IgnoreError(ctx, err)
+	}
+
+	if len(current.suspects) > 0 {
+		// the bisection continues with the last bisected half of the failed trains.
+		return r.buildSuspectsTrain(ctx, branch, current.suspects)
+	}
+
+	return nil
+}
+
+// buildSuspectsTrain builds a train with the first group of suspects still in the queue, the other groups stay suspects.
+func (r *Repository) buildSuspectsTrain(ctx context.Context, branch string, suspects [][]int) error {
+	var pulls []*github.PullRequest
+	for _, number := range suspects[0] {
+		pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.name, number)
+		if err != nil {
+			return fmt.Errorf("failed to get pull request #%d: %w", number, err)
+		}
+
+		if pr.GetState() != "open" || !hasLabel(pr, r.markers.NeedMerge) || hasLabel(pr, r.markers.NoMerge) || hasLabel(pr, r.markers.NeedHumanMerge) {
+			continue
+		}
+
+		pulls = append(pulls, pr)
+	}
+
+	if len(pulls) == 0 {
+		if len(suspects) > 1 {
+			return r.buildSuspectsTrain(ctx, branch, suspects[1:])
+		}
+
+		return nil
+	}
+
+	return r.buildTrain(ctx, branch, pulls, len(pulls), suspects[1:])
+}
+
+// rejectTrain handles a train rejected by the branch protection of the base branch: the pull requests of the train need a human.
+func (r *Repository) rejectTrain(ctx context.Context, branch, baseRef string, current *train, cause error) error {
+	log.Ctx(ctx).Error().Err(cause).Str("branch", branch).Msgf("The base branch %s rejects the merge train %v.", baseRef, current.numbers())
+
+	err := r.deleteTrain(ctx, branch, current.numbers())
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("the merge train cannot update the base branch %s: "+
+		"the branch protection must allow the bot to push without pull request reviews, "+
+		"and the required status checks must run on the train branches: %v", baseRef, cause)
+
+	for _, number := range current.numbers() {
+		loggerPR := log.Ctx(ctx).With().Int("pr", number).Logger()
+
+		pr, _, errGet := r.client.PullRequests.Get(ctx, r.owner, r.name, number)
+		if errGet != nil {
+			loggerPR.Error().Err(errGet).Msg("failed to get pull request")
+			continue
+		}
+
+		r.pipeline.callHuman(loggerPR.WithContext(ctx), pr, ReasonMerge, message)
+	}
+
+	return nil
+}
+
+// bisectTrain handles a failed train: the train is replaced by a train with the first half of the pull requests,
+// the second half is the first group of suspects, tested by the next train if the first half succeeds.
+// A train with only one pull request is the culprit: the suspects go back to the queue.
+func (r *Repository) bisectTrain(ctx context.Context, branch string, current *train, state aggregatedState) error {
+	logger := log.Ctx(ctx)
+
+	logger.Info().Str("branch", branch).Msgf("The merge train %v failed: %s", current.numbers(), state.state)
+
+	err := r.deleteTrain(ctx, branch, current.numbers())
+	if err != nil {
+		return err
+	}
+
+	var pulls []*github.PullRequest
+	for _, pull := range current.pulls {
+		pr, _, errGet := r.client.PullRequests.Get(ctx, r.owner, r.name, pull.number)
+		if errGet != nil {
+			return fmt.Errorf("failed to get pull request #%d: %w", pull.number, errGet)
+		}
+
+		pulls = append(pulls, pr)
+	}
+
+	if len(pulls) == 1 {
+		r.pipeline.callHuman(ctx, pulls[0], ReasonChecks, fmt.Sprintf("the merge train failed: PR status: %s\n%s", state.state, state.summary()))
+		return nil
+	}
+
+	half := len(pulls) / 2
+
+	return r.buildTrain(ctx, branch, pulls[:half], half, slices.Concat([][]int{current.numbers()[half:]}, current.suspects))
+}
+
+// deleteTrain deletes a train branch.
+func (r *Repository) deleteTrain(ctx context.Context, branch string, numbers []int) error {
+	log.Ctx(ctx).Debug().Str("branch", branch).Msgf("Delete merge train. Dry run: %v", r.dryRun)
+
+	if r.dryRun {
+		return nil
+	}
+
+	_, err := r.client.Git.DeleteRef(ctx, r.owner, r.name, "heads/"+branch)
+	if err != nil {
+		return fmt.Errorf("failed to delete the merge train %s: %w", branch, err)
+	}
+
+	for _, number := range numbers {
+		pr, _, errGet := r.client.PullRequests.Get(ctx, r.owner, r.name, number)
+		if errGet != nil {
+			IgnoreError(ctx, errGet)
+			continue
+		}
+
+		IgnoreError(ctx, r.pipeline.removeLabels(ctx, pr, r.markers.MergeInProgress))
+	}
+
+	return nil
+}
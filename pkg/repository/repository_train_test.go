@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi/ghapitest"
+)
+
+func Test_parseTrain(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		message  string
+		expected train
+		errorMsg string
+	}{
+		{
+			desc: "valid train",
+			message: `Merge pull request #3 from ldez/feature
+
+Add a feature
+
+Lobicornis-Train-Base: aaa
+Lobicornis-Train-Pulls: #1@bbb #2@ccc #3@ddd
+`,
+			expected: train{
+				base:  "aaa",
+				pulls: []trainPull{{number: 1, sha: "bbb"}, {number: 2, sha: "ccc"}, {number: 3, sha: "ddd"}},
+			},
+		},
+		{
+			desc: "bisected train",
+			message: `Merge pull request #1 from ldez/feature
+
+Lobicornis-Train-Base: aaa
+Lobicornis-Train-Pulls: #1@bbb
+Lobicornis-Train-Suspects: #2
+Lobicornis-Train-Suspects: #3 #4
+`,
+			expected: train{
+				base:     "aaa",
+				pulls:    []trainPull{{number: 1, sha: "bbb"}},
+				suspects: [][]int{{2}, {3, 4}},
+			},
+		},
+		{
+			desc:     "missing trailers",
+			message:  "Merge pull request #3 from ldez/feature",
+			errorMsg: "the train description is missing",
+		},
+		{
+			desc: "invalid pull request",
+			message: `Lobicornis-Train-Base: aaa
+Lobicornis-Train-Pulls: #1`,
+			errorMsg: "invalid train pull request: #1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := parseTrain(test.message)
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func Test_train_trailers(t *testing.T) {
+	original := train{
+		base:     "aaa",
+		pulls:    []trainPull{{number: 1, sha: "bbb"}, {number: 2, sha: "ccc"}},
+		suspects: [][]int{{3}, {4, 5}},
+	}
+
+	result, err := parseTrain("Merge pull request #2 from ldez/feature\n\n" + original.trailers())
+	require.NoError(t, err)
+
+	assert.Equal(t, original, result)
+	assert.Equal(t, []int{1, 2}, result.numbers())
+}
+
+const trainBranch = trainBranchPrefix + "main"
+
+// newTrainRepository creates a repository with a merge train, backed by a fake GitHub.
+func newTrainRepository(t *testing.T, size int) (*ghapitest.Fake, *Repository) {
+	t.Helper()
+
+	fake, client := newE2EGitHub(t)
+
+	config := conf.RepoConfig{
+		MergeMethod:       conf.String(conf.MergeMethodMerge),
+		MinReview:         conf.Int(1),
+		AddErrorInComment: conf.Bool(true),
+		MergeTrainSize:    conf.Int(size),
+	}
+
+	gitConfig := conf.Git{UserName: "lobicornis", Email: "lobicornis@example.com"}
+
+	repo := New(client, fakeRepoName, "", fakeMarkers, conf.Retry{}, conf.Timeouts{}, gitConfig, config, conf.Extra{}, nil, nil,
+		NewStatusPublisher("lobicornis", false))
+
+	return fake, repo
+}
+
+// addTrainPullRequest adds an approved pull request from a branch of the main repository.
+func addTrainPullRequest(fake *ghapitest.Fake, branch string, labelNames ...string) int {
+	number := fake.AddPullRequest(fakeRepoName, &github.PullRequest{
+		Title:  github.Ptr("Change " + branch),
+		Labels: labels(append([]string{fakeMarkers.NeedMerge}, labelNames...)...),
+		Head:   &github.PullRequestBranch{Ref: github.Ptr(branch)},
+	})
+
+	fake.AddReview(fakeRepoName, number, "ldez", Approved)
+
+	return number
+}
+
+// queue gets the pull requests of the queue: the pull requests without the need human label.
+func queue(fake *ghapitest.Fake, numbers ...int) []int {
+	var result []int
+	for _, number := range numbers {
+		if !slices.Contains(fake.Labels(fakeRepoName, number), fakeMarkers.NeedHumanMerge) &&
+			slices.Contains(fake.Labels(fakeRepoName, number), fakeMarkers.NeedMerge) {
+			result = append(result, number)
+		}
+	}
+
+	return result
+}
+
+// trainPulls gets the pull requests of the train branch, from the subjects of its merge commits not in the base branch.
+func trainPulls(fake *ghapitest.Fake) []int {
+	if fake.Branch(fakeRepoName, trainBranch) == "" {
+		return nil
+	}
+
+	base := fake.Log(fakeRepoName, "main")
+
+	var numbers []int
+	for _, subject := range fake.Log(fakeRepoName, trainBranch) {
+		if slices.Contains(base, subject) {
+			continue
+		}
+
+		var number int
+		if _, err := fmt.Sscanf(subject, "Merge pull request #%d ", &number); err == nil {
+			numbers = append(numbers, number)
+		}
+	}
+
+	slices.Sort(numbers)
+
+	return numbers
+}
+
+func TestRepository_ProcessTrain(t *testing.T) {
+	fake, repo := newTrainRepository(t, 3)
+
+	for i := 1; i <= 3; i++ {
+		addTrainPullRequest(fake, fmt.Sprintf("feature-%d", i))
+	}
+
+	// builds the train.
+	err := repo.ProcessTrain(t.Context(), []int{1, 2, 3})
+	require.NoError(t, err)
+
+	head := fake.Branch(fakeRepoName, trainBranch)
+	require.NotEmpty(t, head)
+
+	assert.Equal(t, []int{1, 2, 3}, trainPulls(fake))
+
+	for number := 1; number <= 3; number++ {
+		assert.Contains(t, fake.Labels(fakeRepoName, number), fakeMarkers.MergeInProgress)
+
+		statuses := fake.Statuses(fakeRepoName, fake.PullRequest(fakeRepoName, number).Head.GetSHA())
+		require.NotEmpty(t, statuses)
+		assert.Equal(t, "in merge train", statuses[len(statuses)-1].GetDescription())
+	}
+
+	// waits for the CI of the train.
+	fake.AddStatus(fakeRepoName, head, &github.RepoStatus{Context: github.Ptr("ci"), State: github.Ptr(Pending)})
+
+	err = repo.ProcessTrain(t.Context(), []int{1, 2, 3})
+	require.NoError(t, err)
+
+	assert.Equal(t, head, fake.Branch(fakeRepoName, trainBranch))
+
+	// merges the train.
+	fake.AddStatus(fakeRepoName, head, &github.RepoStatus{Context: github.Ptr("ci"), State: github.Ptr(Success)})
+
+	err = repo.ProcessTrain(t.Context(), []int{1, 2, 3})
+	require.NoError(t, err)
+
+	assert.Equal(t, head, fake.Branch(fakeRepoName, "main"))
+	assert.Empty(t, fake.Branch(fakeRepoName, trainBranch))
+
+	for number := 1; number <= 3; number++ {
+		assert.Empty(t, fake.Labels(fakeRepoName, number))
+	}
+}
+
+func TestRepository_ProcessTrain_protectedBase(t *testing.T) {
+	fake, repo := newTrainRepository(t, 2)
+
+	// the required status check doesn't run on the train branches.
+	fake.SetRequiredStatusChecks(fakeRepoName, "main", "required")
+
+	addTrainPullRequest(fake, "feature-1")
+	addTrainPullRequest(fake, "feature-2")
+
+	err := repo.ProcessTrain(t.Context(), []int{1, 2})
+	require.NoError(t, err)
+
+	head := fake.Branch(fakeRepoName, trainBranch)
+	require.NotEmpty(t, head)
+
+	fake.AddStatus(fakeRepoName, head, &github.RepoStatus{Context: github.Ptr("ci"), State: github.Ptr(Success)})
+
+	mainHead := fake.Branch(fakeRepoName, "main")
+
+	err = repo.ProcessTrain(t.Context(), []int{1, 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, mainHead, fake.Branch(fakeRepoName, "main"))
+	assert.Empty(t, fake.Branch(fakeRepoName, trainBranch))
+
+	for number := 1; number <= 2; number++ {
+		assert.Contains(t, fake.Labels(fakeRepoName, number), fakeMarkers.NeedHumanMerge)
+		assert.NotContains(t, fake.Labels(fakeRepoName, number), fakeMarkers.MergeInProgress)
+
+		require.Len(t, fake.Comments(fakeRepoName, number), 1)
+		assert.Contains(t, fake.Comments(fakeRepoName, number)[0], "the merge train cannot update the base branch main")
+	}
+}
+
+func TestRepository_ProcessTrain_bisect(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		pulls   int
+		culprit int
+		// expected the successive trains.
+		expected [][]int
+	}{
+		{
+			desc:     "culprit in the second half",
+			pulls:    4,
+			culprit:  3,
+			expected: [][]int{{1, 2, 3, 4}, {1, 2}, {3, 4}, {3}, {4}},
+		},
+		{
+			desc:    "culprit in the first half",
+			pulls:   4,
+			culprit: 2,
+			// the suspects of the culprit go back to the queue.
+			expected: [][]int{{1, 2, 3, 4}, {1, 2}, {1}, {2}, {3, 4}},
+		},
+		{
+			desc:    "second half followed by other pull requests",
+			pulls:   6,
+			culprit: 3,
+			// the second half is tested alone, not in a full train with the next pull requests.
+			expected: [][]int{{1, 2, 3, 4}, {1, 2}, {3, 4}, {3}, {4, 5, 6}},
+		},
+		{
+			desc:     "last of the second half followed by other pull requests",
+			pulls:    6,
+			culprit:  4,
+			expected: [][]int{{1, 2, 3, 4}, {1, 2}, {3, 4}, {3}, {4}, {5, 6}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fake, repo := newTrainRepository(t, 4)
+
+			var all []int
+			for i := 1; i <= test.pulls; i++ {
+				all = append(all, addTrainPullRequest(fake, fmt.Sprintf("feature-%d", i)))
+			}
+
+			// the CI of a train fails if the train contains the culprit.
+			var trains [][]int
+			for range 20 {
+				numbers := queue(fake, all...)
+				if len(numbers) == 0 {
+					break
+				}
+
+				err := repo.ProcessTrain(t.Context(), numbers)
+				require.NoError(t, err)
+
+				head := fake.Branch(fakeRepoName, trainBranch)
+				if head == "" {
+					continue
+				}
+
+				pulls := trainPulls(fake)
+				if len(trains) == 0 || !slices.Equal(trains[len(trains)-1], pulls) {
+					trains = append(trains, pulls)
+				}
+
+				state := Success
+				if slices.Contains(pulls, test.culprit) {
+					state = "failure"
+				}
+
+				fake.AddStatus(fakeRepoName, head, &github.RepoStatus{Context: github.Ptr("ci"), State: github.Ptr(state)})
+			}
+
+			// the red train is halved until the culprit is alone.
+			assert.Equal(t, test.expected, trains)
+
+			assert.Contains(t, fake.Labels(fakeRepoName, test.culprit), fakeMarkers.NeedHumanMerge)
+			require.Len(t, fake.Comments(fakeRepoName, test.culprit), 1)
+			assert.Contains(t, fake.Comments(fakeRepoName, test.culprit)[0], "the merge train failed")
+
+			log := fake.Log(fakeRepoName, "main")
+			assert.NotContains(t, strings.Join(log, "\n"), fmt.Sprintf("Change feature-%d", test.culprit))
+
+			for _, number := range all {
+				if number == test.culprit {
+					continue
+				}
+
+				assert.Empty(t, fake.Labels(fakeRepoName, number), "#%d", number)
+				assert.Contains(t, log, fmt.Sprintf("Change feature-%d", number))
+			}
+		})
+	}
+}
+
+func TestRepository_ProcessTrain_conflicts(t *testing.T) {
+	fake, repo := newTrainRepository(t, 3)
+
+	// #1 conflicts with the base branch.
+	fake.AddFile(fakeRepoName, "feature-1", "README.md", "feature-1", "Change README")
+	// #3 conflicts with #2.
+	fake.AddFile(fakeRepoName, "feature-2", "CHANGELOG.md", "feature-2", "Change CHANGELOG")
+	fake.AddFile(fakeRepoName, "feature-3", "CHANGELOG.md", "feature-3", "Change CHANGELOG again")
+
+	fake.AddFile(fakeRepoName, "main", "README.md", "main", "Another change")
+
+	addTrainPullRequest(fake, "feature-1")
+	addTrainPullRequest(fake, "feature-2")
+	addTrainPullRequest(fake, "feature-3")
+
+	err := repo.ProcessTrain(t.Context(), []int{1, 2, 3})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{2}, trainPulls(fake))
+
+	// the conflict with the base branch needs a human.
+	assert.Contains(t, fake.Labels(fakeRepoName, 1), fakeMarkers.NeedHumanMerge)
+	require.Len(t, fake.Comments(fakeRepoName, 1), 1)
+	assert.Contains(t, fake.Comments(fakeRepoName, 1)[0], "conflicts with the base branch")
+
+	// the conflict with the train is postponed to the next train.
+	assert.Equal(t, []string{fakeMarkers.NeedMerge}, fake.Labels(fakeRepoName, 3))
+	assert.Empty(t, fake.Comments(fakeRepoName, 3))
+}
+
+func TestRepository_ProcessTrain_candidates(t *testing.T) {
+	fake, repo := newTrainRepository(t, 3)
+
+	// waiting for a review.
+	fake.AddPullRequest(fakeRepoName, &github.PullRequest{
+		Title:  github.Ptr("Change feature-1"),
+		Labels: labels(fakeMarkers.NeedMerge),
+		Head:   &github.PullRequestBranch{Ref: github.Ptr("feature-1")},
+	})
+
+	// the merge method is not supported by the train.
+	addTrainPullRequest(fake, "feature-2", fakeMarkers.MergeMethodPrefix+conf.MergeMethodSquash)
+
+	addTrainPullRequest(fake, "feature-3")
+
+	// the train would merge the pull request with a merge commit.
+	addTrainPullRequest(fake, "feature-4", fakeMarkers.MergeMethodPrefix+conf.MergeMethodFastForward)
+
+	err := repo.ProcessTrain(t.Context(), []int{1, 2, 3, 4})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{3}, trainPulls(fake))
+
+	assert.Equal(t, []string{fakeMarkers.NeedMerge}, fake.Labels(fakeRepoName, 1))
+	assert.Empty(t, fake.Comments(fakeRepoName, 1))
+
+	statuses := fake.Statuses(fakeRepoName, fake.PullRequest(fakeRepoName, 1).Head.GetSHA())
+	require.NotEmpty(t, statuses)
+	assert.Equal(t, "needs 1 more review", statuses[len(statuses)-1].GetDescription())
+
+	assert.Contains(t, fake.Labels(fakeRepoName, 2), fakeMarkers.NeedHumanMerge)
+	require.Len(t, fake.Comments(fakeRepoName, 2), 1)
+	assert.Contains(t, fake.Comments(fakeRepoName, 2)[0], "is not supported by the merge train")
+
+	assert.Contains(t, fake.Labels(fakeRepoName, 4), fakeMarkers.NeedHumanMerge)
+	require.Len(t, fake.Comments(fakeRepoName, 4), 1)
+	assert.Contains(t, fake.Comments(fakeRepoName, 4)[0], "the merge method [ff] is not supported by the merge train")
+}
@@ -6,14 +6,17 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/ldez/go-git-cmd-wrapper/v2/git"
+	"github.com/ldez/go-git-cmd-wrapper/v2/global"
 	"github.com/ldez/go-git-cmd-wrapper/v2/merge"
 	"github.com/ldez/go-git-cmd-wrapper/v2/push"
 	"github.com/ldez/go-git-cmd-wrapper/v2/rebase"
 	"github.com/ldez/go-git-cmd-wrapper/v2/types"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/metrics"
 )
 
 // Merge action.
@@ -22,78 +25,77 @@ const (
 	ActionRebase = "rebase"
 )
 
-func (r *Repository) update(ctx context.Context, pr *github.PullRequest) error {
-	logger := log.Ctx(ctx)
-	logger.Info().Msg("UPDATE")
+// CanUpdate checks if the head branch of a pull request can be updated:
+// a protected branch of the main repository is never updated.
+func (r *Repository) CanUpdate(ctx context.Context, pr *github.PullRequest) error {
+	if !isOnMainRepository(pr) {
+		return nil
+	}
 
-	err := r.addLabels(ctx, pr, r.markers.MergeInProgress)
+	protected, err := r.isProtectedBranch(ctx, pr, pr.Head.GetRef())
 	if err != nil {
-		logger.Error().Err(err).Msg("unable to add labels")
+		return err
 	}
 
-	// use GitHub API (update button)
-	if !pr.GetMaintainerCanModify() && !isOnMainRepository(pr) {
-		if r.dryRun {
-			logger.Debug().Msg("Updated via a merge with the GitHub API.")
-			return nil
-		}
+	if protected {
+		return fmt.Errorf("the branch %s on the main repository is protected and cannot be updated", pr.Head.GetRef())
+	}
 
+	return nil
+}
+
+// Update updates the head branch of a pull request with its base branch, and returns the action (merge or rebase).
+// The branches that cannot be pushed by the bot are updated with the GitHub API (update button).
+func (r *Repository) Update(ctx context.Context, pr *github.PullRequest) (string, error) {
+	if !pr.GetMaintainerCanModify() && !isOnMainRepository(pr) {
 		_, _, err := r.client.PullRequests.UpdateBranch(ctx, pr.Base.Repo.Owner.GetLogin(), pr.Base.Repo.GetName(), pr.GetNumber(), nil)
-		if err != nil {
-			return fmt.Errorf("update branch: %w", err)
+		// the update is asynchronous: GitHub accepts the update (202).
+		var acceptedErr *github.AcceptedError
+		if err != nil && !errors.As(err, &acceptedErr) {
+			return "", fmt.Errorf("update branch: %w", err)
 		}
 
-		return nil
+		return ActionMerge, nil
 	}
 
 	return r.cloneAndUpdate(ctx, pr)
 }
 
-// Process clone a PR and update if needed.
-func (r *Repository) cloneAndUpdate(ctx context.Context, pr *github.PullRequest) error {
+// cloneAndUpdate clones a PR and updates it.
+func (r *Repository) cloneAndUpdate(ctx context.Context, pr *github.PullRequest) (string, error) {
 	logger := log.Ctx(ctx)
 
 	logger.Info().Msgf("Base branch: %s - Fork branch: %s", pr.Base.GetRef(), pr.Head.GetRef())
 
 	dir, err := os.MkdirTemp("", "myrmica-lobicornis")
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	defer func() { ignoreError(ctx, os.RemoveAll(dir)) }()
+	defer func() { IgnoreError(ctx, os.RemoveAll(dir)) }()
 
-	err = os.Chdir(dir)
-	if err != nil {
-		return err
-	}
-
-	tempDir, _ := os.Getwd()
-	logger.Info().Msg(tempDir)
+	logger.Info().Msg(dir)
 
-	if isOnMainRepository(pr) && pr.Head.GetRef() == mainBranch {
-		return errors.New("the branch master on a main repository cannot be rebased")
-	}
-
-	mainRemote, err := r.clone.PullRequestForUpdate(ctx, pr)
+	mainRemote, err := r.clone.PullRequestForUpdate(ctx, dir, pr)
 	if err != nil {
-		return fmt.Errorf("failed to clone: %w", err)
+		return "", fmt.Errorf("failed to clone: %w", err)
 	}
 
-	output, err := r.updatePullRequest(ctx, pr, mainRemote)
+	action, output, err := r.updatePullRequest(ctx, dir, pr, mainRemote)
 	logger.Info().Msg(output)
 
 	if err != nil {
-		return fmt.Errorf("failed to update the pull request: %w", err)
+		return "", fmt.Errorf("failed to update the pull request: %w", err)
 	}
 
-	return nil
+	return action, nil
 }
 
-// updatePullRequest Update a pull request.
-func (r *Repository) updatePullRequest(ctx context.Context, pr *github.PullRequest, mainRemote string) (string, error) {
-	action, err := r.getUpdateAction(ctx, pr)
+// updatePullRequest Update a pull request, and returns the action (merge or rebase).
+func (r *Repository) updatePullRequest(ctx context.Context, dir string, pr *github.PullRequest, mainRemote string) (string, string, error) {
+	action, err := r.getUpdateAction(ctx, dir, pr)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	logger := log.Ctx(ctx)
@@ -102,37 +104,48 @@ func (r *Repository) updatePullRequest(ctx context.Context, pr *github.PullReque
 		logger.Info().Msg("Rebase")
 
 		// rebase
-		output, errRebase := rebasePR(ctx, pr, mainRemote, r.debug)
+		output, errRebase := GitWithTimeout(ctx, r.timeouts.Rebase, func(ctx context.Context) (string, error) {
+			return rebasePR(ctx, dir, pr, mainRemote, r.debug)
+		})
 		if errRebase != nil {
 			logger.Error().Err(errRebase).Msg("unable to rebase PR")
-			return output, fmt.Errorf("failed to rebase:\n %s", output)
+			return "", output, fmt.Errorf("failed to rebase: %w\n %s", errRebase, output)
 		}
 	} else {
 		logger.Info().Msg("Merge")
 
 		// merge
-		output, errMerge := mergeBaseHeadIntoPR(ctx, pr, mainRemote, r.debug)
+		output, errMerge := GitWithTimeout(ctx, r.timeouts.Rebase, func(ctx context.Context) (string, error) {
+			return mergeBaseHeadIntoPR(ctx, dir, pr, mainRemote, r.debug)
+		})
 		if errMerge != nil {
 			logger.Error().Err(errMerge).Msg("unable to merge base head into PR")
-			return output, fmt.Errorf("failed to merge base HEAD:\n %s", output)
+			return "", output, fmt.Errorf("failed to merge base HEAD: %w\n %s", errMerge, output)
 		}
 	}
 
 	// push
-	output, err := git.PushWithContext(ctx,
-		git.Cond(r.dryRun, push.DryRun),
-		git.Cond(action == ActionRebase, push.ForceWithLease),
-		push.Remote(RemoteOrigin),
-		push.RefSpec(pr.Head.GetRef()),
-		git.Debugger(r.debug))
+	start := time.Now()
+
+	output, err := GitWithTimeout(ctx, r.timeouts.Push, func(ctx context.Context) (string, error) {
+		return git.PushWithContext(ctx,
+			global.UpperC(dir),
+			git.Cond(action == ActionRebase, push.ForceWithLease),
+			push.Remote(RemoteOrigin),
+			push.RefSpec(pr.Head.GetRef()),
+			git.Debugger(r.debug))
+	})
+
+	r.metrics.GitDuration(metrics.OperationPush, time.Since(start))
+
 	if err != nil {
-		return output, fmt.Errorf("failed to push branch %s: %w\n %s", pr.Head.GetRef(), err, output)
+		return "", output, fmt.Errorf("failed to push branch %s: %w\n %s", pr.Head.GetRef(), err, output)
 	}
 
-	return output, nil
+	return action, output, nil
 }
 
-func (r *Repository) getUpdateAction(ctx context.Context, pr *github.PullRequest) (string, error) {
+func (r *Repository) getUpdateAction(ctx context.Context, dir string, pr *github.PullRequest) (string, error) {
 	// find the first commit of the PR
 	firstCommit, err := r.findFirstCommit(ctx, pr)
 	if err != nil {
@@ -140,7 +153,7 @@ func (r *Repository) getUpdateAction(ctx context.Context, pr *github.PullRequest
 	}
 
 	// check if PR contains merges
-	output, err := git.RawWithContext(ctx, "log", func(g *types.Cmd) {
+	output, err := git.RawWithContext(ctx, "log", global.UpperC(dir), func(g *types.Cmd) {
 		g.AddOptions("--oneline")
 		g.AddOptions("--merges")
 		g.AddOptions(fmt.Sprintf("%s^..HEAD", firstCommit.GetSHA()))
@@ -181,15 +194,17 @@ func (r *Repository) findFirstCommit(ctx context.Context, pr *github.PullRequest
 	return commits[0], nil
 }
 
-func rebasePR(ctx context.Context, pr *github.PullRequest, remoteName string, debug bool) (string, error) {
+func rebasePR(ctx context.Context, dir string, pr *github.PullRequest, remoteName string, debug bool) (string, error) {
 	return git.RebaseWithContext(ctx,
+		global.UpperC(dir),
 		rebase.RebaseMerges(""),
 		rebase.Branch(fmt.Sprintf("%s/%s", remoteName, pr.Base.GetRef())),
 		git.Debugger(debug))
 }
 
-func mergeBaseHeadIntoPR(ctx context.Context, pr *github.PullRequest, remoteName string, debug bool) (string, error) {
+func mergeBaseHeadIntoPR(ctx context.Context, dir string, pr *github.PullRequest, remoteName string, debug bool) (string, error) {
 	return git.MergeWithContext(ctx,
+		global.UpperC(dir),
 		merge.Commits(fmt.Sprintf("%s/%s", remoteName, pr.Base.GetRef())),
 		git.Debugger(debug))
 }
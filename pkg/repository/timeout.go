@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// cleanupTimeout the timeout of the operations used to leave a pull request in a well-defined state after a timeout.
+const cleanupTimeout = 30 * time.Second
+
+// WithTimeout creates a context with a timeout.
+// A zero timeout means no timeout.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// GitWithTimeout runs a git command with a timeout.
+// A git command killed by the context only returns "signal: killed":
+// the error of the context is added to the error of the command to identify the timeouts and the cancellations.
+func GitWithTimeout(ctx context.Context, timeout time.Duration, cmd func(ctx context.Context) (string, error)) (string, error) {
+	ctx, cancel := WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := cmd(ctx)
+	if err != nil && ctx.Err() != nil {
+		return output, fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	return output, err
+}
+
+// isInterrupted checks if an error is caused by a timeout or a cancellation.
+func isInterrupted(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	// the timeout of the HTTP client.
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
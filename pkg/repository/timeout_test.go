@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
+)
+
+func Test_gitWithTimeout(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		timeout     time.Duration
+		args        []string
+		assertError assert.ErrorAssertionFunc
+		interrupted bool
+	}{
+		{
+			desc:        "success",
+			args:        []string{"true"},
+			assertError: assert.NoError,
+		},
+		{
+			desc:        "failure",
+			timeout:     time.Minute,
+			args:        []string{"false"},
+			assertError: assert.Error,
+		},
+		{
+			desc:        "timeout",
+			timeout:     10 * time.Millisecond,
+			args:        []string{"sleep", "10"},
+			assertError: assert.Error,
+			interrupted: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := GitWithTimeout(t.Context(), test.timeout, func(ctx context.Context) (string, error) {
+				return "", exec.CommandContext(ctx, test.args[0], test.args[1:]...).Run()
+			})
+
+			test.assertError(t, err)
+			assert.Equal(t, test.interrupted, isInterrupted(err))
+		})
+	}
+}
+
+func Test_isInterrupted(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{
+			desc: "nil",
+		},
+		{
+			desc: "other error",
+			err:  errors.New("foo"),
+		},
+		{
+			desc:     "deadline",
+			err:      fmt.Errorf("failed to push: %w", context.DeadlineExceeded),
+			expected: true,
+		},
+		{
+			desc:     "canceled",
+			err:      fmt.Errorf("failed to clone: %w", context.Canceled),
+			expected: true,
+		},
+		{
+			desc:     "HTTP client timeout",
+			err:      fmt.Errorf("failed to get pull request: %w", &url.Error{Op: "Get", URL: "https://api.github.com", Err: os.ErrDeadlineExceeded}),
+			expected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, isInterrupted(test.err))
+		})
+	}
+}
+
+func TestRepository_interrupted(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		retry          conf.Retry
+		canceled       bool
+		expectedLabels []string
+		expectedReason string
+	}{
+		{
+			desc:           "timeout with retry",
+			retry:          conf.Retry{Number: 2},
+			expectedLabels: []string{"bot/merge-retry-1", "bot/merge-in-progress"},
+		},
+		{
+			desc:           "timeout without retry",
+			expectedLabels: []string{"bot/need-human-merge"},
+			expectedReason: ReasonTimeout,
+		},
+		{
+			desc:           "canceled",
+			canceled:       true,
+			expectedReason: ReasonUnknown,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var labels []string
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /repos/traefik/traefik/issues/1/labels", func(rw http.ResponseWriter, req *http.Request) {
+				var added []string
+				assert.NoError(t, json.NewDecoder(req.Body).Decode(&added))
+
+				labels = append(labels, added...)
+
+				writeJSON(t, rw, []*github.Label{})
+			})
+			mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+				t.Errorf("unexpected request: %s %s", req.Method, req.URL)
+				rw.WriteHeader(http.StatusNotFound)
+			})
+
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			markers := conf.Markers{
+				MergeInProgress:  "bot/merge-in-progress",
+				MergeRetryPrefix: "bot/merge-retry-",
+				NeedHumanMerge:   "bot/need-human-merge",
+			}
+
+			repo := New(ghapi.New(client), "traefik/traefik", "", markers, test.retry, conf.Timeouts{}, conf.Git{}, conf.RepoConfig{}, conf.Extra{}, nil, nil, nil)
+
+			pr := &github.PullRequest{
+				Number: github.Ptr(1),
+				Base:   &github.PullRequestBranch{Repo: &github.Repository{}},
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), 0)
+			if test.canceled {
+				ctx, cancel = context.WithCancel(t.Context())
+				cancel()
+			}
+			defer cancel()
+
+			<-ctx.Done()
+
+			err := repo.pipeline.interrupted(ctx, pr, fmt.Errorf("failed to push: %w", ctx.Err()))
+
+			if test.expectedReason == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedReason, GetReason(err))
+			}
+
+			assert.Equal(t, test.expectedLabels, labels)
+		})
+	}
+}
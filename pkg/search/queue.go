@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// Pull a pull request (or a merge request) of a queue, whatever the forge.
+type Pull interface {
+	// LabelNames gets the names of the labels of the pull request.
+	LabelNames() []string
+	// LastUpdate gets the date of the last update of the pull request.
+	LastUpdate() time.Time
+}
+
+// GetCurrent gets the index of the current pull request of a queue sorted by priority, -1 if none.
+// priorities: ff > retry > in progress > need merge
+func GetCurrent[P Pull](ctx context.Context, markers conf.Markers, retry conf.Retry, pulls []P) (int, error) {
+	if len(pulls) == 0 {
+		return -1, nil
+	}
+
+	ff := findWithLabel(pulls, markers.MergeMethodPrefix+conf.MergeMethodFastForward)
+	if len(ff) > 1 {
+		return -1, fmt.Errorf("multiple pull requests with the label %s", markers.MergeMethodPrefix+conf.MergeMethodFastForward)
+	}
+
+	if len(ff) > 0 {
+		return ff[0], nil
+	}
+
+	inProgress := findWithLabel(pulls, markers.MergeInProgress)
+
+	if len(inProgress) == 0 {
+		return 0, nil
+	}
+
+	if len(inProgress) > 2 {
+		return -1, fmt.Errorf("illegal state: multiple PR with the label: %s", markers.MergeInProgress)
+	}
+
+	if retry.Number > 0 {
+		// find retries
+
+		var retries []int
+		for _, index := range inProgress {
+			if hasLabelPrefix(pulls[index], markers.MergeRetryPrefix) {
+				retries = append(retries, index)
+			}
+		}
+
+		if len(retries) > 0 {
+			for _, index := range retries {
+				if time.Since(pulls[index].LastUpdate()) > retry.Interval {
+					log.Ctx(ctx).Debug().Msgf("Find PR updated at %v", pulls[index].LastUpdate())
+
+					return index, nil
+				}
+			}
+
+			return -1, nil
+		}
+	}
+
+	return inProgress[0], nil
+}
+
+// findWithLabel finds the indexes of the pull requests with a label.
+func findWithLabel[P Pull](pulls []P, label string) []int {
+	var result []int
+
+	for i, pull := range pulls {
+		if slices.ContainsFunc(pull.LabelNames(), func(name string) bool { return strings.EqualFold(name, label) }) {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+func hasLabelPrefix(pull Pull, prefix string) bool {
+	return slices.ContainsFunc(pull.LabelNames(), func(name string) bool { return strings.HasPrefix(name, prefix) })
+}
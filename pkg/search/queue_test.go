@@ -0,0 +1,132 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+)
+
+// fakePull a pull request of a queue of any forge.
+type fakePull struct {
+	labels    []string
+	updatedAt time.Time
+}
+
+func (p fakePull) LabelNames() []string {
+	return p.labels
+}
+
+func (p fakePull) LastUpdate() time.Time {
+	return p.updatedAt
+}
+
+func TestGetCurrent(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		retry    conf.Retry
+		pulls    []fakePull
+		expected int
+		errorMsg string
+	}{
+		{
+			desc:     "empty",
+			expected: -1,
+		},
+		{
+			desc: "the first",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge}},
+				{labels: []string{fakeMarkers.NeedMerge}},
+			},
+			expected: 0,
+		},
+		{
+			desc: "in progress",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge}},
+				{labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress}},
+			},
+			expected: 1,
+		},
+		{
+			desc: "fast-forward",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress}},
+				{labels: []string{fakeMarkers.NeedMerge, fakeMarkers.MergeMethodPrefix + conf.MergeMethodFastForward}},
+			},
+			expected: 1,
+		},
+		{
+			desc: "fast-forward label with another case",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge}},
+				{labels: []string{fakeMarkers.NeedMerge, "Bot/Merge-Method-FF"}},
+			},
+			expected: 1,
+		},
+		{
+			desc: "multiple fast-forward",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.MergeMethodPrefix + conf.MergeMethodFastForward}},
+				{labels: []string{fakeMarkers.MergeMethodPrefix + conf.MergeMethodFastForward}},
+			},
+			expected: -1,
+			errorMsg: "multiple pull requests with the label bot/merge-method-ff",
+		},
+		{
+			desc: "too many in progress",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.MergeInProgress}},
+				{labels: []string{fakeMarkers.MergeInProgress}},
+				{labels: []string{fakeMarkers.MergeInProgress}},
+			},
+			expected: -1,
+			errorMsg: "illegal state: multiple PR with the label: status/4-merge-in-progress",
+		},
+		{
+			desc:  "retry interval elapsed",
+			retry: conf.Retry{Number: 2, Interval: time.Minute},
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge}},
+				{labels: []string{fakeMarkers.MergeInProgress, fakeMarkers.MergeRetryPrefix + "1"}, updatedAt: time.Now().Add(-time.Hour)},
+			},
+			expected: 1,
+		},
+		{
+			desc:  "retry interval not elapsed",
+			retry: conf.Retry{Number: 2, Interval: time.Minute},
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge}},
+				{labels: []string{fakeMarkers.MergeInProgress, fakeMarkers.MergeRetryPrefix + "1"}, updatedAt: time.Now()},
+			},
+			expected: -1,
+		},
+		{
+			desc: "retries disabled",
+			pulls: []fakePull{
+				{labels: []string{fakeMarkers.NeedMerge}},
+				{labels: []string{fakeMarkers.MergeInProgress, fakeMarkers.MergeRetryPrefix + "1"}, updatedAt: time.Now()},
+			},
+			expected: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			index, err := GetCurrent(t.Context(), fakeMarkers, test.retry, test.pulls)
+
+			if test.errorMsg != "" {
+				require.EqualError(t, err, test.errorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expected, index)
+		})
+	}
+}
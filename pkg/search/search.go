@@ -1,25 +1,28 @@
 package search
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/go-github/v74/github"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi"
 )
 
 // Finder a pull request search manager.
 type Finder struct {
-	client  *github.Client
+	client  *ghapi.Client
 	markers conf.Markers
 	retry   conf.Retry
 }
 
 // New creates a new finder.
-func New(client *github.Client, markers conf.Markers, retry conf.Retry) Finder {
+func New(client *ghapi.Client, markers conf.Markers, retry conf.Retry) Finder {
 	return Finder{
 		client:  client,
 		markers: markers,
@@ -28,6 +31,7 @@ func New(client *github.Client, markers conf.Markers, retry conf.Retry) Finder {
 }
 
 // Search searches all PR in all repositories of the user.
+// The PR are grouped by repository, sorted by priority (the highest first) and then by update (the oldest first).
 func (f Finder) Search(ctx context.Context, user string, parameters ...Parameter) (map[string][]*github.Issue, error) {
 	query := fmt.Sprintf("user:%s type:pr state:open ", user)
 
@@ -69,87 +73,63 @@ func (f Finder) Search(ctx context.Context, user string, parameters ...Parameter
 
 	log.Debug().Str("query", query).Int("count", count).Msg("search queries count")
 
+	for _, issues := range overview {
+		slices.SortStableFunc(issues, func(a, b *github.Issue) int {
+			return cmp.Compare(f.GetPriority(b), f.GetPriority(a))
+		})
+	}
+
 	return overview, nil
 }
 
+// GetPriority gets the priority of a PR from its priority labels.
+func (f Finder) GetPriority(issue *github.Issue) int {
+	return f.markers.GetPriority(issuePull{issue}.LabelNames()...)
+}
+
 // GetCurrentPull gets the current pull request.
 // priorities: ff > retry > in progress > need merge
 func (f Finder) GetCurrentPull(ctx context.Context, issues []*github.Issue) (*github.Issue, error) {
-	if len(issues) == 0 {
-		return nil, nil
-	}
-
-	ff := findIssuesWithLabel(issues, f.markers.MergeMethodPrefix+conf.MergeMethodFastForward)
-	if len(ff) > 1 {
-		f.displayIssues(ff)
-
-		return nil, fmt.Errorf("multiple pull requests with the label %s", f.markers.MergeMethodPrefix+conf.MergeMethodFastForward)
-	}
-
-	if len(ff) > 0 {
-		return ff[0], nil
-	}
-
-	inProgress := findIssuesWithLabel(issues, f.markers.MergeInProgress)
-
-	if len(inProgress) == 0 {
-		f.displayIssues(issues)
-
-		return issues[0], nil
+	pulls := make([]issuePull, 0, len(issues))
+	for _, issue := range issues {
+		pulls = append(pulls, issuePull{issue})
 	}
 
-	if len(inProgress) > 2 {
-		return nil, fmt.Errorf("illegal state: multiple PR with the label: %s", f.markers.MergeInProgress)
+	index, err := GetCurrent(ctx, f.markers, f.retry, pulls)
+	if err != nil || index < 0 {
+		return nil, err
 	}
 
-	if f.retry.Number > 0 {
-		// find retries
-
-		var issuesRetry []*github.Issue
-		for _, issue := range inProgress {
-			if findLabelPrefix(issue.Labels, f.markers.MergeRetryPrefix) != "" {
-				issuesRetry = append(issuesRetry, issue)
-			}
-		}
-
-		if len(issuesRetry) > 0 {
-			logger := log.Ctx(ctx)
+	f.displayIssues(issues)
 
-			for _, issue := range issuesRetry {
-				if time.Since(issue.GetUpdatedAt().Time) > f.retry.Interval {
-					logger.Debug().Msgf("Find PR updated at %v", issue.GetUpdatedAt())
+	return issues[index], nil
+}
 
-					return issue, nil
-				}
-			}
+// issuePull a GitHub pull request of a queue.
+type issuePull struct {
+	*github.Issue
+}
 
-			return nil, nil
-		}
+// LabelNames implements Pull.
+func (i issuePull) LabelNames() []string {
+	var names []string
+	for _, label := range i.Labels {
+		names = append(names, label.GetName())
 	}
 
-	f.displayIssues(inProgress)
-
-	return inProgress[0], nil
+	return names
 }
 
-func (f Finder) displayIssues(issues []*github.Issue) {
-	for _, issue := range issues {
-		log.Debug().Int("pr", issue.GetNumber()).Msgf("Find PR updated at %v", issue.GetUpdatedAt())
-	}
+// LastUpdate implements Pull.
+func (i issuePull) LastUpdate() time.Time {
+	return i.GetUpdatedAt().Time
 }
 
-func findIssuesWithLabel(issues []*github.Issue, lbl string) []*github.Issue {
-	var result []*github.Issue
-
-	for _, issue := range issues {
-		for _, label := range issue.Labels {
-			if strings.EqualFold(label.GetName(), lbl) {
-				result = append(result, issue)
-			}
-		}
+func (f Finder) displayIssues(issues []*github.Issue) {
+	for i, issue := range issues {
+		log.Debug().Int("pr", issue.GetNumber()).Int("position", i+1).Int("priority", f.GetPriority(issue)).
+			Msgf("Find PR updated at %v", issue.GetUpdatedAt())
 	}
-
-	return result
 }
 
 func getFullName(repoURL string) string {
@@ -157,14 +137,3 @@ func getFullName(repoURL string) string {
 
 	return n[len(n)-2] + "/" + n[len(n)-1]
 }
-
-// findLabelPrefix Find an issue with a specific label prefix.
-func findLabelPrefix(labels []*github.Label, prefix string) string {
-	for _, lbl := range labels {
-		if strings.HasPrefix(lbl.GetName(), prefix) {
-			return lbl.GetName()
-		}
-	}
-
-	return ""
-}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/lobicornis/v3/pkg/conf"
+	"github.com/traefik/lobicornis/v3/pkg/ghapi/ghapitest"
+)
+
+var fakeMarkers = conf.Markers{
+	NeedMerge:         "status/3-needs-merge",
+	MergeInProgress:   "status/4-merge-in-progress",
+	MergeMethodPrefix: "bot/merge-method-",
+	MergeRetryPrefix:  "bot/merge-retry-",
+	NeedHumanMerge:    "bot/need-human-merge",
+	NoMerge:           "bot/no-merge",
+	Priorities: map[string]int{
+		"bot/priority-high": 10,
+		"bot/priority-low":  -10,
+	},
+}
+
+func newFake() *ghapitest.Fake {
+	fake := ghapitest.New()
+
+	for _, name := range []string{"traefik", "lobicornis"} {
+		fake.AddRepository(&github.Repository{
+			Owner: &github.User{Login: github.Ptr("traefik")},
+			Name:  github.Ptr(name),
+		})
+	}
+
+	return fake
+}
+
+func labels(names ...string) []*github.Label {
+	var result []*github.Label
+	for _, name := range names {
+		result = append(result, &github.Label{Name: github.Ptr(name)})
+	}
+
+	return result
+}
+
+func ago(d time.Duration) *github.Timestamp {
+	return &github.Timestamp{Time: time.Now().Add(-d)}
+}
+
+func TestFinder_Search(t *testing.T) {
+	fake := newFake()
+
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(1), Labels: labels(fakeMarkers.NeedMerge), UpdatedAt: ago(time.Hour)})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(2), Labels: labels(fakeMarkers.NeedMerge), UpdatedAt: ago(2 * time.Hour)})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(3), Labels: labels(fakeMarkers.NeedMerge, fakeMarkers.NoMerge)})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(4), Labels: labels(fakeMarkers.NeedMerge), State: github.Ptr("closed")})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(5)})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(6), Labels: labels(fakeMarkers.NeedMerge, "bot/priority-low"), UpdatedAt: ago(3 * time.Hour)})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{Number: github.Ptr(7), Labels: labels(fakeMarkers.NeedMerge, "bot/priority-high")})
+	fake.AddPullRequest("traefik/lobicornis", &github.PullRequest{Number: github.Ptr(1), Labels: labels(fakeMarkers.NeedMerge)})
+
+	finder := New(fake.Client(), fakeMarkers, conf.Retry{})
+
+	testCases := []struct {
+		desc       string
+		parameters []Parameter
+		expected   map[string][]int
+	}{
+		{
+			desc:       "labels",
+			parameters: []Parameter{WithLabels(fakeMarkers.NeedMerge), WithExcludedLabels(fakeMarkers.NoMerge)},
+			expected: map[string][]int{
+				"traefik/traefik":    {7, 2, 1, 6},
+				"traefik/lobicornis": {1},
+			},
+		},
+		{
+			desc:       "repository",
+			parameters: []Parameter{WithLabels(fakeMarkers.NeedMerge), WithRepository("traefik/traefik")},
+			expected: map[string][]int{
+				"traefik/traefik": {7, 2, 1, 3, 6},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			overview, err := finder.Search(t.Context(), "traefik", test.parameters...)
+			require.NoError(t, err)
+
+			numbers := make(map[string][]int)
+			for fullName, issues := range overview {
+				for _, issue := range issues {
+					numbers[fullName] = append(numbers[fullName], issue.GetNumber())
+				}
+			}
+
+			assert.Equal(t, test.expected, numbers)
+		})
+	}
+}
+
+func TestFinder_GetCurrentPull_retry(t *testing.T) {
+	fake := newFake()
+
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{
+		Number:    github.Ptr(1),
+		Labels:    labels(fakeMarkers.NeedMerge, fakeMarkers.MergeInProgress, fakeMarkers.MergeRetryPrefix+"1"),
+		UpdatedAt: ago(time.Hour),
+	})
+	fake.AddPullRequest("traefik/traefik", &github.PullRequest{
+		Number:    github.Ptr(2),
+		Labels:    labels(fakeMarkers.NeedMerge),
+		UpdatedAt: ago(2 * time.Hour),
+	})
+
+	client := fake.Client()
+
+	finder := New(client, fakeMarkers, conf.Retry{Number: 2, Interval: time.Minute})
+
+	search := func() []*github.Issue {
+		overview, err := finder.Search(t.Context(), "traefik", WithLabels(fakeMarkers.NeedMerge))
+		require.NoError(t, err)
+
+		return overview["traefik/traefik"]
+	}
+
+	// the retry interval is elapsed.
+	issue, err := finder.GetCurrentPull(t.Context(), search())
+	require.NoError(t, err)
+	require.NotNil(t, issue)
+	assert.Equal(t, 1, issue.GetNumber())
+
+	// the retry label is incremented, the interval starts again.
+	_, err = client.Issues.RemoveLabelForIssue(t.Context(), "traefik", "traefik", 1, fakeMarkers.MergeRetryPrefix+"1")
+	require.NoError(t, err)
+
+	_, _, err = client.Issues.AddLabelsToIssue(t.Context(), "traefik", "traefik", 1, []string{fakeMarkers.MergeRetryPrefix + "2"})
+	require.NoError(t, err)
+
+	issue, err = finder.GetCurrentPull(t.Context(), search())
+	require.NoError(t, err)
+	assert.Nil(t, issue)
+
+	// the retry is done.
+	_, err = client.Issues.RemoveLabelForIssue(t.Context(), "traefik", "traefik", 1, fakeMarkers.MergeInProgress)
+	require.NoError(t, err)
+
+	issue, err = finder.GetCurrentPull(t.Context(), search())
+	require.NoError(t, err)
+	require.NotNil(t, issue)
+	assert.Equal(t, 2, issue.GetNumber())
+}
@@ -55,3 +55,10 @@ func WithExcludedLabels(labels ...string) Parameter {
 		return " " + labelsFilter
 	}
 }
+
+// WithRepository add a search filter by repository.
+func WithRepository(fullName string) Parameter {
+	return func() string {
+		return fmt.Sprintf(" repo:%s ", fullName)
+	}
+}